@@ -0,0 +1,226 @@
+package dewy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/linyows/dewy/container"
+	"github.com/linyows/dewy/registry"
+)
+
+// Deploy tick outcomes reported by POST /api/deploy.
+const (
+	outcomeDeployed = "deployed"
+	outcomeSkipped  = "skipped"
+	outcomeFailed   = "failed"
+)
+
+// tickResult is the outcome of one deploy tick.
+type tickResult struct {
+	Outcome     string       `json:"outcome"`
+	Tag         string       `json:"tag,omitempty"`
+	PreviousTag string       `json:"previous_tag,omitempty"`
+	Forced      bool         `json:"forced"`
+	Duration    jsonDuration `json:"duration,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// jsonDuration is a time.Duration that travels as a string such as "1.5s",
+// rounded to the millisecond.
+type jsonDuration time.Duration
+
+func (d jsonDuration) String() string {
+	return time.Duration(d).Round(time.Millisecond).String()
+}
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(v)
+	return nil
+}
+
+// finish marks r failed when err is set and returns both, so tick phases can
+// end with `return r.finish(err)`.
+func (r tickResult) finish(err error) (tickResult, error) {
+	if err != nil {
+		r.Outcome = outcomeFailed
+		r.Error = err.Error()
+	}
+	return r, err
+}
+
+// restartResult is the outcome of POST /api/restart.
+type restartResult struct {
+	Outcome  string `json:"outcome"` // restarted or failed
+	Tag      string `json:"tag,omitempty"`
+	Replicas int    `json:"replicas,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// errNoServer is returned when a restart is requested with no managed
+// server running.
+var errNoServer = errors.New("no managed server is running")
+
+// restartManagedServer restarts the running server between the restart hooks
+// and records the restart under reason. When a readiness probe is configured
+// it waits for the new worker to pass it.
+func (d *Dewy) restartManagedServer(ctx context.Context, reason string) error {
+	d.tickMu.Lock()
+	defer d.tickMu.Unlock()
+
+	d.RLock()
+	hasServer := d.config.Command == SERVER && d.isServerRunning
+	hc := hookContext{Tag: d.cVer, PreviousTag: d.cVer, ReleaseDir: d.currentRelease()}
+	d.RUnlock()
+	if !hasServer {
+		return errNoServer
+	}
+
+	if err := d.runHook(ctx, hookBeforeRestart, hc); err != nil {
+		return fmt.Errorf("restart skipped: %w", err)
+	}
+	if err := d.restartServer(); err != nil {
+		return err
+	}
+	d.publish(ctx, Event{Type: EventRestarted, Tag: hc.Tag, Reason: reason})
+	_ = d.runHook(ctx, hookAfterRestart, hc)
+
+	if d.config.HealthCheck != nil {
+		return d.verifyServerHealth(ctx)
+	}
+	return nil
+}
+
+// restartContainers replaces every replica with a fresh container of the
+// image that is running now, using the same rolling update (health checks,
+// traffic switch, drain) as a deploy. It returns the tag and the number of
+// replicas started.
+func (d *Dewy) restartContainers(ctx context.Context) (string, int, error) {
+	d.tickMu.Lock()
+	defer d.tickMu.Unlock()
+
+	rt := d.containerRuntime
+	if rt == nil {
+		return "", 0, errors.New("no container has been deployed yet")
+	}
+	res, err := d.runningContainerRelease(ctx, rt)
+	if err != nil {
+		return "", 0, err
+	}
+	if res == nil {
+		return "", 0, errors.New("no managed container is running")
+	}
+
+	n, err := d.deployContainer(ctx, res, rt)
+	if err != nil {
+		return res.Tag, n, err
+	}
+	d.followContainerLogs(ctx)
+	d.publish(ctx, Event{Type: EventRestarted, Tag: res.Tag, Replicas: n, Reason: "api"})
+	return res.Tag, n, nil
+}
+
+// runningContainerRelease describes the image a running managed container
+// was started from, or nil when none runs.
+func (d *Dewy) runningContainerRelease(ctx context.Context, rt *container.Runtime) (*registry.CurrentResponse, error) {
+	statuses, err := rt.InspectManaged(ctx, d.appName())
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range statuses {
+		if st.State == "running" {
+			return &registry.CurrentResponse{Tag: st.Version, ArtifactURL: "img://" + st.Image}, nil
+		}
+	}
+	return nil, nil
+}
+
+// handlePostDeploy handles POST /api/deploy endpoint. It runs a deploy tick
+// right away (waiting for one in progress to finish first) and reports its
+// outcome. force=true redeploys the current tag even when it is already
+// deployed.
+func (d *Dewy) handlePostDeploy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	force := false
+	if v := r.URL.Query().Get("force"); v != "" {
+		var err error
+		if force, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid force", http.StatusBadRequest)
+			return
+		}
+	}
+	if d.registry == nil {
+		http.Error(w, "Registry is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	d.logger.Info("Deploy requested via admin API", slog.Bool("force", force))
+	d.writeJSON(w, d.tick(force))
+}
+
+// handlePostRestart handles POST /api/restart endpoint: a graceful restart
+// of the managed server, or a rolling restart of the container replicas.
+func (d *Dewy) handlePostRestart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The restart outlives a client that hangs up mid-way.
+	ctx := context.WithoutCancel(r.Context())
+	d.logger.Info("Restart requested via admin API")
+
+	var res restartResult
+	var err error
+	switch d.config.Command {
+	case SERVER:
+		err = d.restartManagedServer(ctx, "api")
+		res.Tag = d.currentVersion()
+	case CONTAINER:
+		res.Tag, res.Replicas, err = d.restartContainers(ctx)
+	default:
+		http.Error(w, "Restart is not supported for the assets command", http.StatusConflict)
+		return
+	}
+
+	if errors.Is(err, errNoServer) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	res.Outcome = "restarted"
+	if err != nil {
+		d.logger.Error("Restart failure", slog.String("error", err.Error()))
+		res.Outcome = outcomeFailed
+		res.Error = err.Error()
+	}
+	d.writeJSON(w, res)
+}
+
+// writeJSON writes v as the JSON response body.
+func (d *Dewy) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		d.logger.Error("Failed to encode response",
+			slog.String("error", err.Error()))
+	}
+}
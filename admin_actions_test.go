@@ -0,0 +1,123 @@
+package dewy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func postDeploy(t *testing.T, d *Dewy, target string) tickResult {
+	t.Helper()
+	w := httptest.NewRecorder()
+	d.handlePostDeploy(w, httptest.NewRequest(http.MethodPost, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST %s status = %d: %s", target, w.Code, w.Body.String())
+	}
+	var res tickResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res
+}
+
+func TestHandlePostDeploy(t *testing.T) {
+	d, _, _ := newHookRunDewy(t)
+
+	res := postDeploy(t, d, "/api/deploy")
+	if res.Outcome != outcomeDeployed || res.Tag != "v2.0.0" || res.PreviousTag != "v1.0.0" || res.Duration <= 0 {
+		t.Errorf("first deploy = %+v, want v2.0.0 deployed over v1.0.0 with its duration", res)
+	}
+
+	res = postDeploy(t, d, "/api/deploy")
+	if res.Outcome != outcomeSkipped || res.Tag != "v2.0.0" {
+		t.Errorf("second deploy = %+v, want skipped", res)
+	}
+
+	prev := d.currentRelease()
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	res = postDeploy(t, d, "/api/deploy?force=true")
+	if res.Outcome != outcomeDeployed || !res.Forced {
+		t.Errorf("forced deploy = %+v, want deployed", res)
+	}
+	if d.currentRelease() == prev {
+		t.Error("forced deploy did not create a new release")
+	}
+}
+
+func TestTickResultJSON(t *testing.T) {
+	b, err := json.Marshal(tickResult{Outcome: outcomeDeployed, Duration: jsonDuration(1234567 * time.Microsecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"outcome":"deployed","forced":false,"duration":"1.235s"}`; string(b) != want {
+		t.Errorf("json = %s, want %s", b, want)
+	}
+	var r tickResult
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(r.Duration) != 1235*time.Millisecond {
+		t.Errorf("duration = %v, want 1.235s", time.Duration(r.Duration))
+	}
+
+	b, _ = json.Marshal(tickResult{Outcome: outcomeSkipped})
+	if want := `{"outcome":"skipped","forced":false}`; string(b) != want {
+		t.Errorf("json = %s, want %s", b, want)
+	}
+}
+
+func TestHandlePostDeploy_MethodAndForce(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.registry = &mockRegistry{}
+
+	w := httptest.NewRecorder()
+	d.handlePostDeploy(w, httptest.NewRequest(http.MethodGet, "/api/deploy", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	d.handlePostDeploy(w, httptest.NewRequest(http.MethodPost, "/api/deploy?force=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad force status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandlePostRestart_NothingToRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		command Command
+	}{
+		{"assets", ASSETS},
+		{"server not running", SERVER},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newPhaseTestDewy(t)
+			d.config.Command = tt.command
+
+			w := httptest.NewRecorder()
+			d.handlePostRestart(w, httptest.NewRequest(http.MethodPost, "/api/restart", nil))
+			if w.Code != http.StatusConflict {
+				t.Errorf("status = %d, want 409", w.Code)
+			}
+		})
+	}
+}
+
+func TestHandlePostRestart_ContainerNotDeployed(t *testing.T) {
+	d := newAdminTestDewy(t)
+	d.notifier = &mockNotify{}
+
+	w := httptest.NewRecorder()
+	d.handlePostRestart(w, httptest.NewRequest(http.MethodPost, "/api/restart", nil))
+	var res restartResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if res.Outcome != outcomeFailed || res.Error == "" {
+		t.Errorf("restart = %+v, want failed before the first deploy", res)
+	}
+}
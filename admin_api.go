@@ -2,18 +2,93 @@ package dewy
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/linyows/dewy/container"
 )
 
-// startAdminAPI starts the admin API server on TCP localhost.
+// adminAPIRequested reports whether an admin flag was given, which turns the
+// admin API on outside container mode.
+func (d *Dewy) adminAPIRequested() bool {
+	return d.config.AdminSocket != "" || d.config.AdminPort != 0 || d.config.AdminAuth != nil
+}
+
+// startAdminAPI starts the admin API server on TCP localhost and, when
+// configured, on a Unix socket. With a socket, TCP is only served when
+// --admin-port is given explicitly.
 func (d *Dewy) startAdminAPI(ctx context.Context) error {
+	auth, tlsConfig, err := newAdminAuth(d.config.AdminAuth)
+	if err != nil {
+		return fmt.Errorf("failed to configure admin API auth: %w", err)
+	}
+	d.adminAuth = auth
+
+	var listeners []net.Listener
+	if d.config.AdminSocket != "" {
+		listener, p, err := d.listenAdminSocket()
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, listener)
+		d.logger.Info("Admin API server started",
+			slog.String("socket", p),
+			slog.Bool("auth", auth.enabled()))
+	}
+	if d.config.AdminSocket == "" || d.config.AdminPort != 0 {
+		listener, err := d.listenAdminTCP(auth, tlsConfig)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	// Create HTTP mux for admin API
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/containers", d.requireScope(scopeRead, d.handleGetContainers))
+	mux.HandleFunc("/api/status", d.requireScope(scopeRead, d.handleGetStatus))
+	mux.HandleFunc("/api/logs", d.requireScope(scopeRead, d.handleGetLogs))
+	mux.HandleFunc("/api/events", d.requireScope(scopeRead, d.handleGetEvents))
+	mux.HandleFunc("/api/deploy", d.requireScope(scopeWrite, d.handlePostDeploy))
+	mux.HandleFunc("/api/restart", d.requireScope(scopeWrite, d.handlePostRestart))
+	mux.HandleFunc("/api/replicas", d.requireScope(scopeWrite, d.handlePutReplicas))
+
+	// Add Prometheus metrics endpoint if telemetry is enabled
+	if d.telemetry != nil && d.telemetry.Enabled() {
+		mux.Handle("/metrics", d.requireScope(scopeRead, d.telemetry.PrometheusHandler().ServeHTTP))
+		d.logger.Info("Prometheus metrics endpoint enabled", slog.String("path", "/metrics"))
+	}
+
+	d.adminServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: defaultAdminReadHeaderTimeout,
+		ConnContext:       markSocketConn,
+	}
+
+	// Start server in background
+	for _, listener := range listeners {
+		go func() {
+			if err := d.adminServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				d.logger.Error("Admin API server error", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	return nil
+}
+
+// listenAdminTCP binds the admin API on localhost, trying the next port
+// while the current one is in use.
+func (d *Dewy) listenAdminTCP(auth *adminAuth, tlsConfig *tls.Config) (net.Listener, error) {
 	// Default admin port is 17539 (DEWY: D=4, E=5, W=23, Y=25 -> 4+5+2+3+2+5=21, but 17539 is more unique)
 	adminPort := d.config.AdminPort
 	if adminPort == 0 {
@@ -42,40 +117,21 @@ func (d *Dewy) startAdminAPI(ctx context.Context) error {
 	}
 
 	if listener == nil {
-		return fmt.Errorf("failed to bind admin API after %d attempts: %w", maxAttempts, err)
+		return nil, fmt.Errorf("failed to bind admin API after %d attempts: %w", maxAttempts, err)
 	}
 
-	// Create HTTP mux for admin API
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/containers", d.handleGetContainers)
-	mux.HandleFunc("/api/status", d.handleGetStatus)
-
-	// Add Prometheus metrics endpoint if telemetry is enabled
-	if d.telemetry != nil && d.telemetry.Enabled() {
-		mux.Handle("/metrics", d.telemetry.PrometheusHandler())
-		d.logger.Info("Prometheus metrics endpoint enabled", slog.String("path", "/metrics"))
+	scheme := "http"
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
 	}
 
-	d.adminServer = &http.Server{
-		Handler:           mux,
-		ReadHeaderTimeout: defaultAdminReadHeaderTimeout,
-	}
-
-	// Start server in background
-	go func() {
-		d.logger.Info("Starting admin API server",
-			slog.Int("port", adminPort))
-
-		if err := d.adminServer.Serve(listener); err != nil && err != http.ErrServerClosed {
-			d.logger.Error("Admin API server error", slog.String("error", err.Error()))
-		}
-	}()
-
 	d.logger.Info("Admin API server started",
 		slog.Int("port", adminPort),
-		slog.String("address", fmt.Sprintf("http://localhost:%d", adminPort)))
+		slog.String("address", fmt.Sprintf("%s://localhost:%d", scheme, adminPort)),
+		slog.Bool("auth", auth.enabled()))
 
-	return nil
+	return listener, nil
 }
 
 // stopAdminAPI stops the admin API server.
@@ -149,29 +205,90 @@ func (d *Dewy) handleGetContainers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleGetStatus handles GET /api/status endpoint.
-func (d *Dewy) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+// handleGetLogs handles GET /api/logs endpoint. It writes the last `lines`
+// lines of a replica's captured output and, with follow=true, keeps streaming
+// new output until the client goes away.
+func (d *Dewy) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if d.appLogs == nil {
+		http.Error(w, "Log capture is disabled (start dewy with --capture-logs)", http.StatusNotFound)
+		return
+	}
 
-	d.RLock()
-	defer d.RUnlock()
+	q := r.URL.Query()
+	replica, lines := 0, defaultLogTailLines
+	follow := false
+	var err error
+	if v := q.Get("replica"); v != "" {
+		if replica, err = strconv.Atoi(v); err != nil || replica < 0 {
+			http.Error(w, "Invalid replica", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("lines"); v != "" {
+		if lines, err = strconv.Atoi(v); err != nil || lines < 0 {
+			http.Error(w, "Invalid lines", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("follow"); v != "" {
+		if follow, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid follow", http.StatusBadRequest)
+			return
+		}
+	}
 
-	// Count total backends across all proxies
-	totalBackends := d.totalProxyBackends()
+	l, ok := d.appLogs.lookup(replica)
+	if !ok {
+		http.Error(w, fmt.Sprintf("No logs for replica %d", replica), http.StatusNotFound)
+		return
+	}
 
-	// Return JSON response
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"name":            d.appName(),
-		"command":         d.config.Command,
-		"current_version": d.cVer,
-		"proxy_backends":  totalBackends,
-		"is_running":      d.isServerRunning,
-	}); err != nil {
-		d.logger.Error("Failed to encode response",
-			slog.String("error", err.Error()))
+	// Subscribe before reading the tail so nothing written in between is lost;
+	// a line may show up twice instead.
+	var ch <-chan []byte
+	if follow {
+		var cancel func()
+		ch, cancel = l.subscribe()
+		defer cancel()
+	}
+
+	tail, err := tailLines(l.path(), lines)
+	if err != nil {
+		d.logger.Error("Failed to read app log", slog.String("error", err.Error()))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(tail) > 0 {
+		_, _ = w.Write(tail)
+		if tail[len(tail)-1] != '\n' {
+			_, _ = w.Write([]byte("\n"))
+		}
+	}
+	if !follow {
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case b := <-ch:
+			if _, err := w.Write(b); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
 	}
 }
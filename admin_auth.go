@@ -0,0 +1,282 @@
+package dewy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultAdminTokenFile is the token file client commands read when
+// --admin-token-file is not given. It is relative to the current directory,
+// so commands run from the dewy root pick up an instance's token when it was
+// started with --admin-token-file admin.token.
+const defaultAdminTokenFile = "admin.token"
+
+// AdminAuthConfig secures the admin API. A nil config (the default) keeps the
+// historical behavior: no TLS and no authentication on localhost.
+type AdminAuthConfig struct {
+	TokenFile string // Bearer tokens, one per line as "<token> [read|write]"; created with a write token when missing
+	TLSCert   string // Server certificate; serving TLS requires both TLSCert and TLSKey
+	TLSKey    string
+	ClientCA  string // CA bundle verifying client certificates (mTLS); verified clients get write scope
+}
+
+// adminAuthChallenge is the WWW-Authenticate value of an admin API refusing
+// a request without credentials. Client commands scanning for an instance
+// take it as the sign that dewy, not some other program, holds the port.
+const adminAuthChallenge = `Bearer realm="dewy"`
+
+// adminScope is the access an admin API endpoint requires. write implies
+// read.
+type adminScope int
+
+const (
+	scopeRead adminScope = iota
+	scopeWrite
+)
+
+func (s adminScope) String() string {
+	if s == scopeWrite {
+		return "write"
+	}
+	return "read"
+}
+
+func parseAdminScope(s string) (adminScope, error) {
+	switch s {
+	case "read":
+		return scopeRead, nil
+	case "write":
+		return scopeWrite, nil
+	default:
+		return 0, fmt.Errorf("unknown admin scope: %s", s)
+	}
+}
+
+type adminToken struct {
+	value []byte
+	scope adminScope
+}
+
+// adminAuth authorizes admin API requests.
+type adminAuth struct {
+	tokens []adminToken
+	mtls   bool
+}
+
+// enabled reports whether requests must authenticate at all.
+func (a *adminAuth) enabled() bool {
+	return a != nil && (len(a.tokens) > 0 || a.mtls)
+}
+
+// authorize returns the scope the request was granted, or false when it
+// carries no valid credential. Socket peers passed the socket's file mode
+// and need nothing more.
+func (a *adminAuth) authorize(r *http.Request) (adminScope, bool) {
+	if viaAdminSocket(r.Context()) {
+		return scopeWrite, true
+	}
+	if a.mtls && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return scopeWrite, true
+	}
+	h := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(h, "Bearer ")
+	if !ok || token == "" {
+		return 0, false
+	}
+	granted, found := scopeRead, false
+	// Compare against every token so the timing does not reveal which one
+	// matched.
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.value, []byte(token)) == 1 {
+			if !found || t.scope > granted {
+				granted = t.scope
+			}
+			found = true
+		}
+	}
+	return granted, found
+}
+
+// requireScope wraps an admin handler so it only runs for requests granted
+// at least scope. Without auth configured, reads pass and writes are only
+// taken from socket peers: any local user can reach the TCP listener.
+func (d *Dewy) requireScope(scope adminScope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !d.adminAuth.enabled() {
+			if scope == scopeWrite && !viaAdminSocket(r.Context()) {
+				http.Error(w, "Forbidden: write actions over TCP require --admin-token-file or --admin-client-ca", http.StatusForbidden)
+				return
+			}
+			next(w, r)
+			return
+		}
+		granted, ok := d.adminAuth.authorize(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", adminAuthChallenge)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if granted < scope {
+			http.Error(w, fmt.Sprintf("Forbidden: %s scope required", scope), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// newAdminAuth loads the token file and TLS material named by c. It returns
+// the TLS config to serve with (nil for plain HTTP).
+func newAdminAuth(c *AdminAuthConfig) (*adminAuth, *tls.Config, error) {
+	a := &adminAuth{}
+	if c == nil {
+		return a, nil, nil
+	}
+
+	if c.TokenFile != "" {
+		if err := ensureAdminTokenFile(c.TokenFile); err != nil {
+			return nil, nil, err
+		}
+		tokens, err := loadAdminTokens(c.TokenFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		a.tokens = tokens
+	}
+
+	if c.TLSCert == "" && c.TLSKey == "" {
+		if c.ClientCA != "" {
+			return nil, nil, errors.New("admin client CA requires a TLS certificate and key")
+		}
+		return a, nil, nil
+	}
+	if c.TLSCert == "" || c.TLSKey == "" {
+		return nil, nil, errors.New("admin TLS requires both a certificate and a key")
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load admin TLS certificate: %w", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCA != "" {
+		pool, err := loadCertPool(c.ClientCA)
+		if err != nil {
+			return nil, nil, err
+		}
+		tc.ClientCAs = pool
+		// With tokens configured too, either credential is enough.
+		if len(a.tokens) > 0 {
+			tc.ClientAuth = tls.VerifyClientCertIfGiven
+		} else {
+			tc.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		a.mtls = true
+	}
+	return a, tc, nil
+}
+
+func loadCertPool(p string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %s", p)
+	}
+	return pool, nil
+}
+
+// ensureAdminTokenFile creates p with a fresh write token when it does not
+// exist yet.
+func ensureAdminTokenFile(p string) error {
+	if _, err := os.Stat(p); err == nil || !os.IsNotExist(err) {
+		return nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate admin token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create admin token file: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%s write\n", hex.EncodeToString(b)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadAdminTokens reads a token file. It refuses files readable by group or
+// others, since anyone who can read a token can use it.
+func loadAdminTokens(p string) ([]adminToken, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin token file: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return nil, fmt.Errorf("admin token file %s must not be accessible by group or others (mode %04o, want 0600)", p, perm)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin token file: %w", err)
+	}
+
+	var tokens []adminToken
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		t := adminToken{value: []byte(fields[0]), scope: scopeWrite}
+		switch len(fields) {
+		case 1:
+		case 2:
+			if t.scope, err = parseAdminScope(fields[1]); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", p, n, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: want \"<token> [read|write]\"", p, n)
+		}
+		tokens = append(tokens, t)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("admin token file %s has no tokens", p)
+	}
+	return tokens, nil
+}
+
+// clientAdminToken returns the token a CLI client should present: the
+// highest-scoped one in the file.
+func clientAdminToken(p string) (string, error) {
+	tokens, err := loadAdminTokens(p)
+	if err != nil {
+		return "", err
+	}
+	best := tokens[0]
+	for _, t := range tokens[1:] {
+		if t.scope > best.scope {
+			best = t
+		}
+	}
+	return string(best.value), nil
+}
@@ -0,0 +1,162 @@
+package dewy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTokenFile(t *testing.T, content string, mode os.FileMode) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "admin.token")
+	if err := os.WriteFile(p, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(p, mode); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadAdminTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+		want    []adminScope
+		wantErr string
+	}{
+		{
+			name:    "scopes and comments",
+			content: "# ops\nr3ad read\n\nwr1te write\nplain\n",
+			mode:    0600,
+			want:    []adminScope{scopeRead, scopeWrite, scopeWrite},
+		},
+		{name: "group readable", content: "tok\n", mode: 0640, wantErr: "want 0600"},
+		{name: "unknown scope", content: "tok admin\n", mode: 0600, wantErr: "unknown admin scope"},
+		{name: "empty", content: "# nothing\n", mode: 0600, wantErr: "no tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := loadAdminTokens(writeTokenFile(t, tt.content, tt.mode))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadAdminTokens: %v", err)
+			}
+			if len(tokens) != len(tt.want) {
+				t.Fatalf("got %d tokens, want %d", len(tokens), len(tt.want))
+			}
+			for i, tok := range tokens {
+				if tok.scope != tt.want[i] {
+					t.Errorf("token %d scope = %s, want %s", i, tok.scope, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnsureAdminTokenFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "admin.token")
+	if err := ensureAdminTokenFile(p); err != nil {
+		t.Fatalf("ensureAdminTokenFile: %v", err)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode = %04o, want 0600", perm)
+	}
+	token, err := clientAdminToken(p)
+	if err != nil {
+		t.Fatalf("clientAdminToken: %v", err)
+	}
+
+	// An existing file is left alone.
+	if err := ensureAdminTokenFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := clientAdminToken(p); again != token {
+		t.Error("existing token file was overwritten")
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	t.Run("auth disabled", func(t *testing.T) {
+		d := newAdminTestDewy(t)
+		w := httptest.NewRecorder()
+		d.requireScope(scopeRead, ok)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("read status = %d, want 200", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		d.requireScope(scopeWrite, ok)(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("write over TCP status = %d, want 403", w.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), adminSocketConnKey{}, true))
+		w = httptest.NewRecorder()
+		d.requireScope(scopeWrite, ok)(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("write over socket status = %d, want 200", w.Code)
+		}
+	})
+
+	d := newAdminTestDewy(t)
+	auth, _, err := newAdminAuth(&AdminAuthConfig{
+		TokenFile: writeTokenFile(t, "reader read\nwriter write\n", 0600),
+	})
+	if err != nil {
+		t.Fatalf("newAdminAuth: %v", err)
+	}
+	d.adminAuth = auth
+
+	tests := []struct {
+		name   string
+		scope  adminScope
+		header string
+		want   int
+	}{
+		{"no token", scopeRead, "", http.StatusUnauthorized},
+		{"wrong token", scopeRead, "Bearer nope", http.StatusUnauthorized},
+		{"read token reads", scopeRead, "Bearer reader", http.StatusOK},
+		{"read token cannot write", scopeWrite, "Bearer reader", http.StatusForbidden},
+		{"write token writes", scopeWrite, "Bearer writer", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			d.requireScope(tt.scope, ok)(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAdminAuth_TLSNeedsCertAndKey(t *testing.T) {
+	if _, _, err := newAdminAuth(&AdminAuthConfig{TLSCert: "cert.pem"}); err == nil {
+		t.Error("expected an error for a certificate without a key")
+	}
+	if _, _, err := newAdminAuth(&AdminAuthConfig{ClientCA: "ca.pem"}); err == nil {
+		t.Error("expected an error for a client CA without TLS")
+	}
+}
@@ -0,0 +1,139 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// adminSocketAuto picks /run/dewy/<app>.sock when /run/dewy is
+	// writable and <root>/dewy.sock otherwise.
+	adminSocketAuto = "auto"
+
+	// adminSocketDir is the shared directory client commands search for
+	// instances that are not in the current directory.
+	adminSocketDir = "/run/dewy"
+
+	// adminSocketName is the socket file name under the dewy root.
+	adminSocketName = "dewy.sock"
+
+	// adminSocketMode lets the owner and the group use the socket. Access
+	// control is the file mode (and the directory's), so any process that
+	// can connect is trusted with write scope.
+	adminSocketMode = 0660
+)
+
+// adminSocketConnKey marks requests that arrived over the Unix socket.
+type adminSocketConnKey struct{}
+
+// markSocketConn is the admin server's ConnContext hook.
+func markSocketConn(ctx context.Context, c net.Conn) context.Context {
+	if _, ok := c.(*net.UnixConn); ok {
+		return context.WithValue(ctx, adminSocketConnKey{}, true)
+	}
+	return ctx
+}
+
+func viaAdminSocket(ctx context.Context) bool {
+	v, _ := ctx.Value(adminSocketConnKey{}).(bool)
+	return v
+}
+
+// adminSocketCandidates returns the socket paths to try, in order.
+func (d *Dewy) adminSocketCandidates() []string {
+	if d.config.AdminSocket != adminSocketAuto {
+		return []string{d.config.AdminSocket}
+	}
+	return []string{
+		filepath.Join(adminSocketDir, d.appName()+".sock"),
+		filepath.Join(d.root, adminSocketName),
+	}
+}
+
+// listenAdminSocket listens on the first usable socket path.
+func (d *Dewy) listenAdminSocket() (net.Listener, string, error) {
+	var errs []error
+	for _, p := range d.adminSocketCandidates() {
+		l, err := listenUnixSocket(p)
+		if err == nil {
+			return l, p, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, "", fmt.Errorf("failed to listen on admin socket: %w", errors.Join(errs...))
+}
+
+// listenUnixSocket listens on p, replacing a stale socket left by a dewy
+// that did not shut down cleanly. A socket that still accepts connections
+// belongs to a running instance and is left alone.
+func listenUnixSocket(p string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(p); err == nil {
+		if c, err := net.DialTimeout("unix", p, time.Second); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("%s is in use by another dewy instance", p)
+		}
+		if err := os.Remove(p); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", p)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(p, adminSocketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// discoverAdminSocket finds the instance socket a client command run from
+// dir should use: <name>.sock under runDir when a name is given, then the
+// socket in dir itself, then the only socket under runDir. It returns "" when
+// there is none, and an error when several instances could be meant.
+func discoverAdminSocket(dir, runDir, name string) (string, error) {
+	if name != "" {
+		if p := filepath.Join(runDir, name+".sock"); isSocket(p) {
+			return p, nil
+		}
+	}
+	if p := filepath.Join(dir, adminSocketName); isSocket(p) {
+		return p, nil
+	}
+	if name != "" {
+		return "", nil
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(runDir, "*.sock"))
+	var found []string
+	for _, p := range matches {
+		if isSocket(p) {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	default:
+		sort.Strings(found)
+		return "", fmt.Errorf("several dewy instances found (%s); pick one with --name or --admin-socket",
+			strings.Join(found, ", "))
+	}
+}
+
+func isSocket(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
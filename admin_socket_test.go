@@ -0,0 +1,120 @@
+package dewy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "dewy.sock")
+
+	l, err := listenUnixSocket(p)
+	if err != nil {
+		t.Fatalf("listenUnixSocket: %v", err)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != adminSocketMode {
+		t.Errorf("mode = %04o, want %04o", perm, adminSocketMode)
+	}
+
+	if _, err := listenUnixSocket(p); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("second listen err = %v, want in use", err)
+	}
+
+	// A socket file nobody listens on any more is replaced.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = listenUnixSocket(p)
+	if err != nil {
+		t.Fatalf("listen over stale socket: %v", err)
+	}
+	l.Close()
+}
+
+func TestDiscoverAdminSocket(t *testing.T) {
+	listen := func(t *testing.T, p string) {
+		t.Helper()
+		l, err := listenUnixSocket(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { l.Close() })
+	}
+
+	t.Run("none", func(t *testing.T) {
+		got, err := discoverAdminSocket(t.TempDir(), t.TempDir(), "")
+		if err != nil || got != "" {
+			t.Errorf("got %q, %v; want none", got, err)
+		}
+	})
+
+	t.Run("current directory first", func(t *testing.T) {
+		dir, run := t.TempDir(), t.TempDir()
+		listen(t, filepath.Join(dir, adminSocketName))
+		listen(t, filepath.Join(run, "a.sock"))
+		listen(t, filepath.Join(run, "b.sock"))
+		got, err := discoverAdminSocket(dir, run, "")
+		if err != nil || got != filepath.Join(dir, adminSocketName) {
+			t.Errorf("got %q, %v", got, err)
+		}
+	})
+
+	t.Run("single instance in run dir", func(t *testing.T) {
+		run := t.TempDir()
+		listen(t, filepath.Join(run, "a.sock"))
+		got, err := discoverAdminSocket(t.TempDir(), run, "")
+		if err != nil || got != filepath.Join(run, "a.sock") {
+			t.Errorf("got %q, %v", got, err)
+		}
+	})
+
+	t.Run("ambiguous without name", func(t *testing.T) {
+		run := t.TempDir()
+		listen(t, filepath.Join(run, "a.sock"))
+		listen(t, filepath.Join(run, "b.sock"))
+		if _, err := discoverAdminSocket(t.TempDir(), run, ""); err == nil {
+			t.Error("expected an error for several instances")
+		}
+		got, err := discoverAdminSocket(t.TempDir(), run, "b")
+		if err != nil || got != filepath.Join(run, "b.sock") {
+			t.Errorf("with name: got %q, %v", got, err)
+		}
+	})
+}
+
+// Socket peers are trusted by file mode, so a token-protected instance still
+// answers them without a token, while TCP stays off unless asked for.
+func TestStartAdminAPI_Socket(t *testing.T) {
+	d := newAdminTestDewy(t)
+	p := filepath.Join(t.TempDir(), adminSocketName)
+	d.config.AdminSocket = p
+	d.config.AdminAuth = &AdminAuthConfig{TokenFile: filepath.Join(t.TempDir(), "admin.token")}
+
+	ctx := context.Background()
+	if err := d.startAdminAPI(ctx); err != nil {
+		t.Fatalf("startAdminAPI: %v", err)
+	}
+	t.Cleanup(func() { _ = d.stopAdminAPI(ctx) })
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", p)
+		},
+	}}
+	resp, err := client.Get("http://dewy/api/status")
+	if err != nil {
+		t.Fatalf("GET over socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
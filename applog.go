@@ -0,0 +1,302 @@
+package dewy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	// appLogsDir holds captured application output under the dewy root:
+	// logs/<release>/app.log for the server command and
+	// logs/<tag>/replica-<n>.log for the container command.
+	appLogsDir = "logs"
+
+	serverLogName = "app.log"
+)
+
+// appLog fans the output of one replica out to its rotating file and to the
+// /api/logs followers.
+type appLog struct {
+	mu   sync.Mutex
+	file *logging.RotatingFile
+	subs map[chan []byte]struct{}
+}
+
+// Write never fails: a full disk or a slow follower must not block the
+// application writing into the pipe. Followers that fall behind lose output.
+func (l *appLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subs {
+		select {
+		case ch <- bytes.Clone(p):
+		default:
+		}
+	}
+	if l.file != nil {
+		_, _ = l.file.Write(p)
+	}
+	return len(p), nil
+}
+
+// path returns the live log file, or "" before one was opened.
+func (l *appLog) path() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return ""
+	}
+	return l.file.Path()
+}
+
+// setFile switches output to the log file at p, closing the previous one.
+func (l *appLog) setFile(p string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil && l.file.Path() == p {
+		return nil
+	}
+	f, err := logging.NewRotatingFile(p, defaultAppLogMaxSize, defaultAppLogBackups)
+	if err != nil {
+		return err
+	}
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = f
+	return nil
+}
+
+// subscribe returns a channel receiving everything written from now on and
+// a function that ends the subscription.
+func (l *appLog) subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, 256)
+	l.mu.Lock()
+	if l.subs == nil {
+		l.subs = make(map[chan []byte]struct{})
+	}
+	l.subs[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch, func() {
+		l.mu.Lock()
+		delete(l.subs, ch)
+		l.mu.Unlock()
+	}
+}
+
+// appLogs is the set of captured application logs, keyed by replica (the
+// server command only has replica 0).
+type appLogs struct {
+	dir       string
+	mu        sync.Mutex
+	replicas  map[int]*appLog
+	following map[string]bool // container IDs with a running logs --follow
+}
+
+func newAppLogs(dir string) *appLogs {
+	return &appLogs{
+		dir:       dir,
+		replicas:  make(map[int]*appLog),
+		following: make(map[string]bool),
+	}
+}
+
+// replica returns the log for replica n, creating it on first use.
+func (a *appLogs) replica(n int) *appLog {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l, ok := a.replicas[n]
+	if !ok {
+		l = &appLog{}
+		a.replicas[n] = l
+	}
+	return l
+}
+
+// lookup returns the log for replica n if it was ever written.
+func (a *appLogs) lookup(n int) (*appLog, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l, ok := a.replicas[n]
+	return l, ok
+}
+
+// open points replica n at logs/<release>/<name>.
+func (a *appLogs) open(n int, release, name string) error {
+	return a.replica(n).setFile(filepath.Join(a.dir, release, name))
+}
+
+// follow marks a container as followed; it reports false if it already was.
+func (a *appLogs) follow(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.following[id] {
+		return false
+	}
+	a.following[id] = true
+	return true
+}
+
+func (a *appLogs) unfollow(id string) {
+	a.mu.Lock()
+	delete(a.following, id)
+	a.mu.Unlock()
+}
+
+// prune removes the log directories of all but the most recent releases.
+func (a *appLogs) prune() error {
+	files, err := os.ReadDir(a.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, name := range selectStaleReleases(files, keepReleases) {
+		if err := os.RemoveAll(filepath.Join(a.dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openServerLog points the server's log at the release current serves. The
+// caller must hold the Dewy lock.
+func (d *Dewy) openServerLog() {
+	if d.appLogs == nil {
+		return
+	}
+	release := filepath.Base(d.currentRelease())
+	if err := d.appLogs.open(0, release, serverLogName); err != nil {
+		d.logger.Warn("Open app log failure", slog.String("error", err.Error()))
+	}
+}
+
+// serverOutput returns the stdout and stderr for a server worker: dewy's own
+// streams, copied into the server's app log when output is captured. Nil
+// writers leave the worker on dewy's streams alone. The caller must hold the
+// Dewy lock.
+func (d *Dewy) serverOutput() (stdout, stderr io.Writer) {
+	if d.appLogs == nil {
+		return nil, nil
+	}
+	l := d.appLogs.replica(0)
+	return teeWriter{orig: os.Stdout, log: l}, teeWriter{orig: os.Stderr, log: l}
+}
+
+// teeWriter writes to both orig and log. Errors writing to orig are ignored so
+// that a closed terminal cannot stop capture (and, with it, block the
+// application on a full pipe).
+type teeWriter struct {
+	orig io.Writer
+	log  *appLog
+}
+
+func (t teeWriter) Write(p []byte) (int, error) {
+	_, _ = t.orig.Write(p)
+	return t.log.Write(p)
+}
+
+// followContainerLogs starts following every running managed container that
+// is not followed yet, into logs/<tag>/replica-<n>.log. A container restarted
+// by the runtime keeps its ID and is picked up again on the next call.
+func (d *Dewy) followContainerLogs(ctx context.Context) {
+	a := d.appLogs
+	rt := d.containerRuntime
+	if a == nil || rt == nil {
+		return
+	}
+	statuses, err := rt.InspectManaged(ctx, d.appName())
+	if err != nil {
+		d.logger.Warn("Failed to list containers for log capture", slog.String("error", err.Error()))
+		return
+	}
+	for _, st := range statuses {
+		if st.State != "running" {
+			continue
+		}
+		// Containers started before replica labels existed cannot be keyed.
+		n, err := strconv.Atoi(st.Replica)
+		if err != nil {
+			continue
+		}
+		version := st.Version
+		if version == "" {
+			version = "unknown"
+		}
+		if !a.follow(st.ID) {
+			continue
+		}
+
+		name := fmt.Sprintf("replica-%d.log", n)
+		since := st.StartedAt
+		// After a dewy restart, resume where the file left off instead of
+		// appending the container's whole history again.
+		if info, err := os.Stat(filepath.Join(a.dir, version, name)); err == nil && info.ModTime().After(since) {
+			since = info.ModTime()
+		}
+		if err := a.open(n, version, name); err != nil {
+			d.logger.Warn("Open app log failure", slog.String("error", err.Error()))
+			a.unfollow(st.ID)
+			continue
+		}
+
+		l := a.replica(n)
+		go func(id string) {
+			defer a.unfollow(id)
+			if err := rt.Logs(context.Background(), id, since, l); err != nil {
+				d.logger.Debug("Container log follow ended",
+					slog.String("container", id),
+					slog.String("error", err.Error()))
+			}
+		}(st.ID)
+	}
+}
+
+// pruneAppLogs drops log directories of releases that are no longer kept.
+func (d *Dewy) pruneAppLogs() {
+	if d.appLogs == nil {
+		return
+	}
+	if err := d.appLogs.prune(); err != nil {
+		d.logger.Error("Prune app logs failure", slog.String("error", err.Error()))
+	}
+}
+
+// tailLines returns the last n lines of the file at p (nil when it does not
+// exist yet).
+func tailLines(p string, n int) ([]byte, error) {
+	if p == "" || n <= 0 {
+		return nil, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	end := len(b)
+	if end > 0 && b[end-1] == '\n' {
+		end--
+	}
+	start := end
+	for range n {
+		idx := bytes.LastIndexByte(b[:start], '\n')
+		if idx < 0 {
+			return b, nil
+		}
+		start = idx
+	}
+	return b[start+1:], nil
+}
@@ -0,0 +1,197 @@
+package dewy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailLines(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(p, []byte("one\ntwo\nthree\nfour\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"last two", 2, "three\nfour\n"},
+		{"more than available", 10, "one\ntwo\nthree\nfour\n"},
+		{"zero", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tailLines(p, tt.n)
+			if err != nil {
+				t.Fatalf("tailLines: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("tailLines(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+
+	got, err := tailLines(filepath.Join(t.TempDir(), "missing.log"), 5)
+	if err != nil || got != nil {
+		t.Errorf("tailLines(missing) = %q, %v; want nil, nil", got, err)
+	}
+}
+
+func TestAppLog_WriteFansOut(t *testing.T) {
+	a := newAppLogs(t.TempDir())
+	if err := a.open(0, "20260101T000000Z", serverLogName); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	l := a.replica(0)
+
+	ch, cancel := l.subscribe()
+	defer cancel()
+	if _, err := l.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	select {
+	case b := <-ch:
+		if string(b) != "hello\n" {
+			t.Errorf("subscriber got %q", b)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber got nothing")
+	}
+
+	b, err := os.ReadFile(l.path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello\n" {
+		t.Errorf("log file = %q", b)
+	}
+
+	// Switching releases writes into the new directory.
+	if err := a.open(0, "20260102T000000Z", serverLogName); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if !strings.Contains(l.path(), "20260102T000000Z") {
+		t.Errorf("path = %s, want the new release", l.path())
+	}
+}
+
+func TestStartServer_CapturesOutput(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+	d.config.Starter = StarterConfig{
+		command: "sh",
+		args:    []string{"-c", `echo out; echo err >&2; ` + loopWorker},
+		ports:   []string{"127.0.0.1:0"},
+	}
+	d.appLogs = newAppLogs(t.TempDir())
+	stdout, stderr := os.Stdout, os.Stderr
+	t.Cleanup(func() { d.supervisor.stop(5 * time.Second) })
+
+	if err := d.startServer(); err != nil {
+		t.Fatal(err)
+	}
+	if os.Stdout != stdout || os.Stderr != stderr {
+		t.Error("dewy's stdout or stderr was replaced")
+	}
+
+	b, err := os.ReadFile(d.appLogs.replica(0).path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); !strings.Contains(got, "out\n") || !strings.Contains(got, "err\n") {
+		t.Errorf("app log = %q, want stdout and stderr of the worker", got)
+	}
+}
+
+func TestAppLogs_Prune(t *testing.T) {
+	dir := t.TempDir()
+	a := newAppLogs(dir)
+	for i := range keepReleases + 2 {
+		release := time.Date(2026, 1, 1+i, 0, 0, 0, 0, time.UTC).Format(releaseDir)
+		if err := a.open(0, release, serverLogName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != keepReleases {
+		t.Errorf("kept %d log dirs, want %d", len(entries), keepReleases)
+	}
+}
+
+func TestHandleGetLogs(t *testing.T) {
+	t.Run("capture disabled", func(t *testing.T) {
+		d := newAdminTestDewy(t)
+		w := httptest.NewRecorder()
+		d.handleGetLogs(w, httptest.NewRequest(http.MethodGet, "/api/logs", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+	})
+
+	d := newAdminTestDewy(t)
+	d.appLogs = newAppLogs(t.TempDir())
+	if err := d.appLogs.open(1, "v1.0.0", "replica-1.log"); err != nil {
+		t.Fatal(err)
+	}
+	l := d.appLogs.replica(1)
+	_, _ = l.Write([]byte("a\nb\nc\n"))
+
+	t.Run("unknown replica", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		d.handleGetLogs(w, httptest.NewRequest(http.MethodGet, "/api/logs?replica=3", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("tail", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		d.handleGetLogs(w, httptest.NewRequest(http.MethodGet, "/api/logs?replica=1&lines=2", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Body.String(); got != "b\nc\n" {
+			t.Errorf("body = %q, want %q", got, "b\nc\n")
+		}
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(d.handleGetLogs))
+		defer srv.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/logs?replica=1&lines=1&follow=true", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		buf := make([]byte, 64)
+		n, _ := resp.Body.Read(buf)
+		if got := string(buf[:n]); got != "c\n" {
+			t.Fatalf("tail = %q, want %q", got, "c\n")
+		}
+
+		_, _ = l.Write([]byte("d\n"))
+		n, _ = resp.Body.Read(buf)
+		if got := string(buf[:n]); got != "d\n" {
+			t.Errorf("streamed = %q, want %q", got, "d\n")
+		}
+	})
+}
@@ -38,15 +38,36 @@ var factories = map[string]factoryFn{
 	scheme.GHR: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
 		return NewGHR(ctx, url, logger)
 	},
+	scheme.GLR: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewGLR(ctx, url, logger)
+	},
+	scheme.Gitea: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewGitea(ctx, url, logger)
+	},
 	scheme.S3: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
 		return NewS3(ctx, url, logger)
 	},
 	scheme.GS: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
 		return NewGS(ctx, url, logger)
 	},
+	scheme.AzBlob: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewAzBlob(ctx, url, logger)
+	},
 	scheme.OCI: func(ctx context.Context, url string, logger *slog.Logger, o *options) (Artifact, error) {
 		return NewOCI(ctx, url, o.puller, logger)
 	},
+	scheme.ORAS: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewORAS(ctx, url, logger)
+	},
+	scheme.HTTP: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewHTTP(ctx, url, logger)
+	},
+	scheme.HTTPS: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewHTTP(ctx, url, logger)
+	},
+	scheme.File: func(ctx context.Context, url string, logger *slog.Logger, _ *options) (Artifact, error) {
+		return NewFile(ctx, url, logger)
+	},
 }
 
 func New(ctx context.Context, url string, logger *slog.Logger, opts ...Option) (Artifact, error) {
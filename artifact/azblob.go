@@ -0,0 +1,68 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/linyows/dewy/client"
+)
+
+type AzBlob struct {
+	Account   string `schema:"-"`
+	Container string `schema:"-"`
+	Name      string `schema:"-"`
+	Endpoint  string `schema:"endpoint"`
+	url       string
+	cl        *client.AzureBlob
+	logger    *slog.Logger
+}
+
+// azblob://<account>/<container>/<name>?endpoint=bbb
+func NewAzBlob(ctx context.Context, strURL string, logger *slog.Logger) (*AzBlob, error) {
+	u, err := url.Parse(strURL)
+	if err != nil {
+		return nil, err
+	}
+
+	splitted := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	if u.Host == "" || len(splitted) < 2 || splitted[0] == "" || splitted[1] == "" {
+		return nil, fmt.Errorf("url parse error: %s", strURL)
+	}
+
+	a := &AzBlob{
+		Account:   u.Host,
+		Container: splitted[0],
+		Name:      splitted[1],
+		url:       strURL,
+		logger:    logger,
+	}
+	if err = decoder.Decode(a, u.Query()); err != nil {
+		return nil, err
+	}
+
+	a.cl, err = client.NewAzureBlob(a.Account, a.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AzBlob) Download(ctx context.Context, w io.Writer) error {
+	body, _, err := a.cl.Get(ctx, a.Container, a.Name)
+	if err != nil {
+		return fmt.Errorf("failed to download artifact from Azure Blob Storage: %w", err)
+	}
+	defer body.Close()
+
+	a.logger.Info("Downloaded from Azure Blob Storage", slog.String("url", a.url))
+	_, err = io.Copy(w, body)
+	if err != nil {
+		return fmt.Errorf("failed to write artifact to writer: %w", err)
+	}
+
+	return nil
+}
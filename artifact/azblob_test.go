@@ -0,0 +1,50 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAzBlobDownload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/acct/releases/app/v1.0.0/app.tar.gz" || r.Header.Get("x-ms-version") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	t.Cleanup(ts.Close)
+	for _, k := range []string{"AZURE_STORAGE_CONNECTION_STRING", "AZURE_STORAGE_KEY", "AZURE_STORAGE_SAS_TOKEN"} {
+		t.Setenv(k, "")
+	}
+	ctx := context.Background()
+	q := "?" + url.Values{"endpoint": {ts.URL + "/acct"}}.Encode()
+
+	a, err := New(ctx, "azblob://acct/releases/app/v1.0.0/app.tar.gz"+q, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Download(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "artifact" {
+		t.Errorf("got %q, want %q", buf.String(), "artifact")
+	}
+
+	missing, err := New(ctx, "azblob://acct/releases/app/v9.9.9/app.tar.gz"+q, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.Download(ctx, &buf); err == nil {
+		t.Error("expected an error for a missing blob")
+	}
+
+	if _, err := New(ctx, "azblob://acct/releases", testLogger()); err == nil {
+		t.Error("expected an url without blob name to be rejected")
+	}
+}
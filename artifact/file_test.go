@@ -0,0 +1,41 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileDownload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, []byte("artifact"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	a, err := New(ctx, "file://"+filepath.ToSlash(path), testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Download(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "artifact" {
+		t.Errorf("got %q, want %q", buf.String(), "artifact")
+	}
+
+	a, err = New(ctx, "file://"+filepath.ToSlash(path)+".missing", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Download(ctx, &buf); !os.IsNotExist(err) {
+		t.Errorf("err = %v, want not exist", err)
+	}
+
+	if _, err := New(ctx, "file://host/app.tar.gz", testLogger()); err == nil {
+		t.Error("expected a remote host to be rejected")
+	}
+}
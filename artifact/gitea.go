@@ -0,0 +1,90 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/internal/scheme"
+)
+
+type Gitea struct {
+	owner    string
+	repo     string
+	tag      string
+	artifact string
+	url      string
+	cl       *client.Gitea
+	logger   *slog.Logger
+}
+
+func NewGitea(ctx context.Context, strURL string, logger *slog.Logger) (*Gitea, error) {
+	// gitea://host/owner/repo/tag/v1.0.0/artifact.zip[?scheme=http]
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(strURL, fmt.Sprintf("%s://", scheme.Gitea)), "?")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	splitted := strings.Split(path, "/")
+	if len(splitted) != 6 || splitted[3] != "tag" {
+		return nil, fmt.Errorf("invalid artifact url: %s, %#v", strURL, splitted)
+	}
+	tag, err := url.PathUnescape(splitted[4])
+	if err != nil {
+		return nil, err
+	}
+	name, err := url.PathUnescape(splitted[5])
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := client.NewGitea(splitted[0], q.Get("scheme"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gitea{
+		owner:    splitted[1],
+		repo:     splitted[2],
+		tag:      tag,
+		artifact: name,
+		url:      strURL,
+		cl:       cl,
+		logger:   logger,
+	}, nil
+}
+
+// Download download artifact.
+func (r *Gitea) Download(ctx context.Context, w io.Writer) error {
+	release, err := r.cl.GetReleaseByTag(ctx, r.owner, r.repo, r.tag)
+	if err != nil {
+		return fmt.Errorf("failed gitea.GetReleaseByTag: %w", err)
+	}
+	var link string
+	for _, a := range release.Assets {
+		if a.Name == r.artifact {
+			link = a.BrowserDownloadURL
+			break
+		}
+	}
+	if link == "" {
+		return fmt.Errorf("release asset not found: %s", r.url)
+	}
+
+	reader, err := r.cl.Download(ctx, link)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("failed io.Copy: %w", err)
+	}
+
+	r.logger.Info("Artifact downloaded", slog.String("url", link))
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGiteaDownload(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/app/releases/tags/v1.0.0+blue":
+			_, _ = w.Write([]byte(`{"tag_name": "v1.0.0+blue", "assets": [
+  {"name": "app.tar.gz", "browser_download_url": "` + ts.URL + `/owner/app/releases/download/v1.0.0+blue/app.tar.gz"}]}`))
+		case "/owner/app/releases/download/v1.0.0+blue/app.tar.gz":
+			_, _ = w.Write([]byte("artifact"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("GITEA_TOKEN", "gt")
+	host := strings.TrimPrefix(ts.URL, "http://")
+	ctx := context.Background()
+
+	a, err := New(ctx, "gitea://"+host+"/owner/app/tag/v1.0.0%2Bblue/app.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Download(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "artifact" {
+		t.Errorf("got %q, want %q", buf.String(), "artifact")
+	}
+
+	a, err = New(ctx, "gitea://"+host+"/owner/app/tag/v1.0.0%2Bblue/app.tar.gz?scheme=https", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Download(ctx, &buf); err == nil {
+		t.Error("expected https to the http test server to fail")
+	}
+
+	if _, err := New(ctx, "gitea://"+host+"/owner/app/v1.0.0/app.tar.gz", testLogger()); err == nil {
+		t.Error("expected an url without tag to be rejected")
+	}
+}
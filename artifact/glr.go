@@ -0,0 +1,95 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/internal/scheme"
+)
+
+type GLR struct {
+	project  string
+	tag      string
+	artifact string
+	url      string
+	cl       *client.GitLab
+	logger   *slog.Logger
+}
+
+func NewGLR(ctx context.Context, strURL string, logger *slog.Logger) (*GLR, error) {
+	// glr://group/project/-/releases/v1.0.0/artifact.zip[?base-url=https://git.example.com]
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(strURL, fmt.Sprintf("%s://", scheme.GLR)), "?")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	project, release, ok := strings.Cut(path, "/-/releases/")
+	splitted := strings.Split(release, "/")
+	if !ok || project == "" || len(splitted) != 2 {
+		return nil, fmt.Errorf("invalid artifact url: %s", strURL)
+	}
+	tag, err := url.PathUnescape(splitted[0])
+	if err != nil {
+		return nil, err
+	}
+	name, err := url.PathUnescape(splitted[1])
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := client.NewGitLab(q.Get("base-url"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GLR{
+		project:  project,
+		tag:      tag,
+		artifact: name,
+		url:      strURL,
+		cl:       cl,
+		logger:   logger,
+	}, nil
+}
+
+// Download download artifact.
+func (r *GLR) Download(ctx context.Context, w io.Writer) error {
+	release, err := r.cl.GetRelease(ctx, r.project, r.tag)
+	if err != nil {
+		return fmt.Errorf("failed gitlab.GetRelease: %w", err)
+	}
+	var link string
+	for _, l := range release.Assets.Links {
+		if l.Name != r.artifact {
+			continue
+		}
+		// The direct asset URL is a permalink on the GitLab instance that
+		// redirects to the link target.
+		link = l.DirectAssetURL
+		if link == "" {
+			link = l.URL
+		}
+		break
+	}
+	if link == "" {
+		return fmt.Errorf("asset link not found: %s", r.url)
+	}
+
+	reader, err := r.cl.Download(ctx, link)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("failed io.Copy: %w", err)
+	}
+
+	r.logger.Info("Artifact downloaded", slog.String("url", link))
+
+	return nil
+}
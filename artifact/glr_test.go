@@ -0,0 +1,74 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNewGLR(t *testing.T) {
+	ctx := context.Background()
+	a, err := NewGLR(ctx, "glr://group/sub/app/-/releases/v1.0.0%2Bblue/app.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.project != "group/sub/app" || a.tag != "v1.0.0+blue" || a.artifact != "app.tar.gz" {
+		t.Errorf("got %+v", a)
+	}
+	for _, u := range []string{"glr://group/app/tag/v1.0.0/app.tar.gz", "glr://group/app/-/releases/v1.0.0"} {
+		if _, err := NewGLR(ctx, u, testLogger()); err == nil {
+			t.Errorf("NewGLR(%q) succeeded", u)
+		}
+	}
+}
+
+func TestGLRDownload(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fapp/releases/v1.0.0":
+			_, _ = w.Write([]byte(`{"tag_name": "v1.0.0", "assets": {"links": [
+  {"name": "app.tar.gz", "url": "https://example.com/app.tar.gz",
+   "direct_asset_url": "` + ts.URL + `/group/app/-/releases/v1.0.0/downloads/app.tar.gz"}]}}`))
+		case "/group/app/-/releases/v1.0.0/downloads/app.tar.gz":
+			_, _ = w.Write([]byte("artifact"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("GITLAB_API_URL", ts.URL+"/api/v4")
+	ctx := context.Background()
+
+	download := func(u string) {
+		t.Helper()
+		a, err := New(ctx, u, testLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := a.Download(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "artifact" {
+			t.Errorf("got %q, want %q", buf.String(), "artifact")
+		}
+	}
+	download("glr://group/app/-/releases/v1.0.0/app.tar.gz")
+
+	// The base-url option wins over the environment.
+	t.Setenv("GITLAB_API_URL", "http://127.0.0.1:1/api/v4")
+	download("glr://group/app/-/releases/v1.0.0/app.tar.gz?base-url=" + url.QueryEscape(ts.URL))
+	t.Setenv("GITLAB_API_URL", ts.URL+"/api/v4")
+
+	a, err := New(ctx, "glr://group/app/-/releases/v1.0.0/missing.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Download(ctx, &bytes.Buffer{}); err == nil {
+		t.Error("expected a missing asset link to fail")
+	}
+}
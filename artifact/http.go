@@ -0,0 +1,67 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/linyows/dewy/client"
+)
+
+// HTTP downloads an artifact from a plain web server, such as the one
+// `dewy registry serve` runs. Credentials come from the environment and are
+// only sent when the URL carries client.HTTPAuthFragment, which the http(s)
+// registry sets for artifacts on the host of its index.
+type HTTP struct {
+	url    string
+	auth   bool
+	cl     *http.Client
+	logger *slog.Logger
+}
+
+// http(s)://<host>/<path>[#dewy-auth]
+func NewHTTP(ctx context.Context, strURL string, logger *slog.Logger) (*HTTP, error) {
+	u, err := url.Parse(strURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("url parse error: %s", strURL)
+	}
+	auth := u.Fragment == client.HTTPAuthFragment
+	u.Fragment, u.RawFragment = "", ""
+	return &HTTP{
+		url:    u.String(),
+		auth:   auth,
+		cl:     http.DefaultClient,
+		logger: logger,
+	}, nil
+}
+
+// Download download artifact.
+func (h *HTTP) Download(ctx context.Context, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return err
+	}
+	if h.auth {
+		client.SetHTTPAuth(req)
+	}
+	res, err := h.cl.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", h.url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", h.url, res.Status)
+	}
+
+	h.logger.Info("Downloaded from HTTP", slog.String("url", h.url))
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return err
+	}
+	return nil
+}
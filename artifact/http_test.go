@@ -0,0 +1,77 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linyows/dewy/client"
+)
+
+func TestHTTPDownload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifacts/v1.0.0/app_linux_amd64.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	a, err := New(ctx, ts.URL+"/artifacts/v1.0.0/app_linux_amd64.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Download(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "artifact" {
+		t.Errorf("got %q, want %q", buf.String(), "artifact")
+	}
+
+	a, err = New(ctx, ts.URL+"/artifacts/v9.9.9/missing.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Download(ctx, &buf); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want 404", err)
+	}
+}
+
+func TestHTTPDownloadAuth(t *testing.T) {
+	t.Setenv("DEWY_HTTP_TOKEN", "secret")
+	newServer := func(got *string) *httptest.Server {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*got = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte("artifact"))
+		}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	var indexAuth, cdnAuth string
+	index := newServer(&indexAuth)
+	cdn := newServer(&cdnAuth)
+
+	// The http(s) registry marks artifacts on the host of its index.
+	ctx := context.Background()
+	for _, u := range []string{index.URL + "/app_linux_amd64.tar.gz#" + client.HTTPAuthFragment, cdn.URL + "/app_linux_amd64.tar.gz"} {
+		a, err := New(ctx, u, testLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Download(ctx, &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if indexAuth != "Bearer secret" {
+		t.Errorf("index host Authorization = %q, want the token", indexAuth)
+	}
+	if cdnAuth != "" {
+		t.Errorf("other host Authorization = %q, want none", cdnAuth)
+	}
+}
@@ -0,0 +1,69 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+	"github.com/linyows/dewy/registry"
+)
+
+// ORAS is a layer of an OCI artifact, downloaded from the registry API with
+// the authentication of registry.OCI.
+type ORAS struct {
+	Digest string
+	Name   string
+	url    string
+	oci    *registry.OCI
+	logger *slog.Logger
+}
+
+// oras://<registry>/<repository>/-/blobs/<digest>/<name>[?<OCI options>]
+// The query holds the options of the oras:// registry for registry.OCI, so the
+// blob is fetched with the same settings the release was found with.
+func NewORAS(ctx context.Context, strURL string, logger *slog.Logger) (*ORAS, error) {
+	u, err := url.Parse(strURL)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, blob, found := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/-/blobs/")
+	digest, name, _ := strings.Cut(blob, "/")
+	if u.Host == "" || !found || repo == "" || digest == "" || name == "" {
+		return nil, fmt.Errorf("url parse error: %s (format: %s://<registry>/<repository>/-/blobs/<digest>/<name>)", strURL, scheme.ORAS)
+	}
+
+	ociURL := url.URL{Scheme: scheme.OCI, Host: u.Host, Path: "/" + repo, RawQuery: u.RawQuery}
+	oci, err := registry.NewOCI(ctx, ociURL.String(), &logging.Logger{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ORAS{
+		Digest: digest,
+		Name:   name,
+		url:    strURL,
+		oci:    oci,
+		logger: logger,
+	}, nil
+}
+
+// Download writes the layer to w.
+func (o *ORAS) Download(ctx context.Context, w io.Writer) error {
+	body, err := o.oci.FetchBlob(ctx, o.Digest)
+	if err != nil {
+		return fmt.Errorf("failed to download artifact from OCI registry: %w", err)
+	}
+	defer body.Close()
+
+	o.logger.Info("Downloaded from OCI registry", slog.String("url", o.url))
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("failed to write artifact to writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestORASDownload(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token": "t0ken"}`))
+		case "/v2/team/app/blobs/sha256:abc":
+			if r.Header.Get("Authorization") != "Bearer t0ken" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+ts.URL+`/token",service="test",scope="repository:team/app:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte("artifact"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("DOCKER_USERNAME", "")
+	host := strings.TrimPrefix(ts.URL, "http://")
+	ctx := context.Background()
+
+	// The query holds the options of the oras:// registry.
+	for _, u := range []string{
+		"oras://" + host + "/team/app/-/blobs/sha256:abc/app.tar.gz",
+		"oras://" + host + "/team/app/-/blobs/sha256:abc/app.tar.gz?calver=YYYY.0M.MICRO&pre-release=true",
+	} {
+		a, err := New(ctx, u, testLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := a.Download(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "artifact" {
+			t.Errorf("got %q, want %q", buf.String(), "artifact")
+		}
+		if o := a.(*ORAS); strings.Contains(u, "?") && (!o.oci.PreRelease || o.oci.CalVer != "YYYY.0M.MICRO") {
+			t.Errorf("options of %s not passed to OCI: %+v", u, o.oci)
+		}
+	}
+	var buf bytes.Buffer
+
+	missing, err := New(ctx, "oras://"+host+"/team/app/-/blobs/sha256:def/app.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.Download(ctx, &buf); err == nil {
+		t.Error("expected an error for a missing blob")
+	}
+
+	for _, u := range []string{"oras://" + host + "/team/app", "oras://" + host + "/-/blobs/sha256:abc/app.tar.gz", "oras://" + host + "/team/app/-/blobs/sha256:abc",
+		"oras://" + host + "/team/app/-/blobs/sha256:abc/app.tar.gz?unknown=1"} {
+		if _, err := New(ctx, u, testLogger()); err == nil {
+			t.Errorf("New(%q) succeeded", u)
+		}
+	}
+}
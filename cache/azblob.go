@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/client"
+)
+
+const azblobFormat = "azblob://<account>/<container>/<prefix>"
+
+// AzBlobClient is the blob operation surface used by AzBlob backend, for
+// testability. *client.AzureBlob implements it.
+type AzBlobClient interface {
+	// Read returns the blob bytes and ETag, or an error wrapping
+	// client.ErrBlobNotFound.
+	Read(ctx context.Context, container, name string) ([]byte, string, error)
+	// Put writes only while the blob has the ETag ifMatch, unless it is
+	// empty. Returns an error wrapping client.ErrBlobConditionNotMet on
+	// mismatch.
+	Put(ctx context.Context, container, name string, data []byte, ifMatch string) (string, error)
+	// PutIfAbsent writes only if no blob exists at name.
+	PutIfAbsent(ctx context.Context, container, name string, data []byte) (string, error)
+	Delete(ctx context.Context, container, name string) error
+	List(ctx context.Context, container, prefix, delimiter string) ([]client.Blob, []string, error)
+}
+
+// AzBlob is an Azure Blob Storage backed cache with local filesystem staging.
+type AzBlob struct {
+	Account   string
+	Container string
+	Prefix    string
+
+	cl  AzBlobClient
+	ctx context.Context
+
+	dir         string
+	MaxSize     int64
+	registryTTL time.Duration
+	logger      *slog.Logger
+}
+
+// NewAzBlob returns an AzBlob cache backend configured from a URL. The
+// endpoint query parameter points at Azurite or a sovereign cloud.
+func NewAzBlob(ctx context.Context, u string, log *slog.Logger) (*AzBlob, error) {
+	return NewAzBlobWithClient(ctx, u, log, nil)
+}
+
+// NewAzBlobWithClient is like NewAzBlob but lets callers inject a custom client (for testing).
+func NewAzBlobWithClient(ctx context.Context, u string, log *slog.Logger, cl AzBlobClient) (*AzBlob, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	container, prefix, _ := strings.Cut(strings.TrimPrefix(ur.Path, "/"), "/")
+	if ur.Host == "" {
+		return nil, fmt.Errorf("account is required: %s", azblobFormat)
+	}
+	if container == "" {
+		return nil, fmt.Errorf("container is required: %s", azblobFormat)
+	}
+
+	q := ur.Query()
+	ttl, err := parseRegistryTTL(q)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &AzBlob{
+		Account:     ur.Host,
+		Container:   container,
+		Prefix:      normalizePrefix(prefix),
+		ctx:         ctx,
+		dir:         DefaultCacheDir,
+		MaxSize:     DefaultMaxSize,
+		registryTTL: ttl,
+		logger:      log,
+	}
+
+	if cl != nil {
+		a.cl = cl
+		return a, nil
+	}
+
+	ac, err := client.NewAzureBlob(a.Account, q.Get("endpoint"))
+	if err != nil {
+		return nil, err
+	}
+	a.cl = ac
+	return a, nil
+}
+
+// SetLogger sets the logger.
+func (a *AzBlob) SetLogger(logger *slog.Logger) { a.logger = logger }
+
+// SetDir sets the local staging directory.
+func (a *AzBlob) SetDir(dir string) { a.dir = dir }
+
+// GetDir returns the local staging directory.
+func (a *AzBlob) GetDir() string { return a.dir }
+
+// RegistryTTL returns the configured registry-result cache TTL.
+func (a *AzBlob) RegistryTTL() time.Duration { return a.registryTTL }
+
+func (a *AzBlob) blobName(key string) string { return a.Prefix + key }
+
+// Read returns cache data for key, fetching from Blob Storage and staging locally on miss.
+func (a *AzBlob) Read(key string) ([]byte, error) {
+	localPath, err := validateKeyPath(a.dir, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := os.ReadFile(localPath); err == nil {
+		return data, nil
+	}
+
+	data, _, err := a.cl.Read(a.ctx, a.Container, a.blobName(key))
+	if err != nil {
+		if errors.Is(err, client.ErrBlobNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+
+	if err := a.stageLocal(localPath, data); err != nil && a.logger != nil {
+		a.logger.Warn("Failed to stage blob locally",
+			slog.String("path", localPath), slog.String("error", err.Error()))
+	}
+	return data, nil
+}
+
+// Write stores data both locally and on Blob Storage.
+func (a *AzBlob) Write(key string, data []byte) error {
+	localPath, err := validateKeyPath(a.dir, key)
+	if err != nil {
+		return err
+	}
+
+	if err := a.stageLocal(localPath, data); err != nil {
+		return err
+	}
+
+	if _, err := a.cl.Put(a.ctx, a.Container, a.blobName(key), data, ""); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+
+	if a.logger != nil {
+		a.logger.Info("Write blob",
+			slog.String("container", a.Container),
+			slog.String("name", a.blobName(key)))
+	}
+	return nil
+}
+
+// Delete removes the entry from both local staging and Blob Storage.
+func (a *AzBlob) Delete(key string) error {
+	localPath, err := validateKeyPath(a.dir, key)
+	if err != nil {
+		return err
+	}
+
+	if IsFileExist(localPath) {
+		if err := os.Remove(localPath); err != nil {
+			return err
+		}
+	}
+
+	if err := a.cl.Delete(a.ctx, a.Container, a.blobName(key)); err != nil {
+		if errors.Is(err, client.ErrBlobNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// List returns cache keys present in Blob Storage under the configured prefix.
+func (a *AzBlob) List() ([]string, error) {
+	blobs, _, err := a.cl.List(a.ctx, a.Container, a.Prefix, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	keys := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		k := strings.TrimPrefix(b.Name, a.Prefix)
+		if k == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// ReadWithVersion fetches the blob and returns its ETag as the opaque version.
+// Returns IsNotFound(err) when the blob does not exist.
+func (a *AzBlob) ReadWithVersion(key string) ([]byte, string, error) {
+	data, etag, err := a.cl.Read(a.ctx, a.Container, a.blobName(key))
+	if err != nil {
+		if errors.Is(err, client.ErrBlobNotFound) {
+			return nil, "", fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, "", fmt.Errorf("failed to get blob: %w", err)
+	}
+	return data, etag, nil
+}
+
+// WriteIfMatch writes the blob only if its current ETag matches version.
+// Pass version="" to write only if no blob exists at the key.
+// Returns the new ETag on success, or an error for which IsConflict returns
+// true on precondition mismatch.
+func (a *AzBlob) WriteIfMatch(key string, version string, data []byte) (string, error) {
+	var etag string
+	var err error
+	if version == "" {
+		etag, err = a.cl.PutIfAbsent(a.ctx, a.Container, a.blobName(key), data)
+	} else {
+		etag, err = a.cl.Put(a.ctx, a.Container, a.blobName(key), data, version)
+	}
+	if err != nil {
+		if errors.Is(err, client.ErrBlobConditionNotMet) {
+			return "", fmt.Errorf("%w: %s", ErrConflict, key)
+		}
+		return "", fmt.Errorf("failed to put blob: %w", err)
+	}
+	return etag, nil
+}
+
+func (a *AzBlob) stageLocal(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
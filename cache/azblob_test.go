@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linyows/dewy/client"
+)
+
+type mockAzBlobClient struct {
+	blobs   map[string][]byte
+	etags   map[string]string
+	nextTag int
+}
+
+func newMockAzBlobClient() *mockAzBlobClient {
+	return &mockAzBlobClient{
+		blobs: map[string][]byte{},
+		etags: map[string]string{},
+	}
+}
+
+func (m *mockAzBlobClient) Read(ctx context.Context, container, name string) ([]byte, string, error) {
+	data, ok := m.blobs[name]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", client.ErrBlobNotFound, name)
+	}
+	return data, m.etags[name], nil
+}
+
+func (m *mockAzBlobClient) store(name string, data []byte) string {
+	m.blobs[name] = data
+	m.nextTag++
+	m.etags[name] = fmt.Sprintf(`"0x%d"`, m.nextTag)
+	return m.etags[name]
+}
+
+func (m *mockAzBlobClient) Put(ctx context.Context, container, name string, data []byte, ifMatch string) (string, error) {
+	if ifMatch != "" && m.etags[name] != ifMatch {
+		return "", fmt.Errorf("%w: %s", client.ErrBlobConditionNotMet, name)
+	}
+	return m.store(name, data), nil
+}
+
+func (m *mockAzBlobClient) PutIfAbsent(ctx context.Context, container, name string, data []byte) (string, error) {
+	if _, ok := m.blobs[name]; ok {
+		return "", fmt.Errorf("%w: %s", client.ErrBlobConditionNotMet, name)
+	}
+	return m.store(name, data), nil
+}
+
+func (m *mockAzBlobClient) Delete(ctx context.Context, container, name string) error {
+	if _, ok := m.blobs[name]; !ok {
+		return fmt.Errorf("%w: %s", client.ErrBlobNotFound, name)
+	}
+	delete(m.blobs, name)
+	delete(m.etags, name)
+	return nil
+}
+
+func (m *mockAzBlobClient) List(ctx context.Context, container, prefix, delimiter string) ([]client.Blob, []string, error) {
+	var blobs []client.Blob
+	for k, v := range m.blobs {
+		if strings.HasPrefix(k, prefix) {
+			blobs = append(blobs, client.Blob{Name: k, Size: int64(len(v))})
+		}
+	}
+	return blobs, nil, nil
+}
+
+func newTestAzBlob(t *testing.T) (*AzBlob, *mockAzBlobClient) {
+	t.Helper()
+	mock := newMockAzBlobClient()
+	a := &AzBlob{
+		Account:   "acct",
+		Container: "cache",
+		Prefix:    "team/app/",
+		cl:        mock,
+		ctx:       context.Background(),
+		dir:       t.TempDir(),
+		MaxSize:   DefaultMaxSize,
+	}
+	return a, mock
+}
+
+// Compile-time check that *AzBlob satisfies Cache and AtomicCache, and that
+// the real client satisfies AzBlobClient.
+var (
+	_ Cache        = (*AzBlob)(nil)
+	_ AtomicCache  = (*AzBlob)(nil)
+	_ AzBlobClient = (*client.AzureBlob)(nil)
+)
+
+func TestAzBlobWriteReadDelete(t *testing.T) {
+	a, mock := newTestAzBlob(t)
+	data := []byte("hello blob")
+
+	if err := a.Write("artifact.tar.gz", data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := mock.blobs["team/app/artifact.tar.gz"]; !bytes.Equal(got, data) {
+		t.Errorf("stored bytes mismatch: got %q want %q", got, data)
+	}
+
+	// A read on another host stages the blob locally.
+	if err := os.Remove(filepath.Join(a.dir, "artifact.tar.gz")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.Read("artifact.tar.gz")
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Read = %q, %v", got, err)
+	}
+	if !IsFileExist(filepath.Join(a.dir, "artifact.tar.gz")) {
+		t.Error("expected local stage file")
+	}
+
+	mock.blobs["other/x"] = []byte("ignored")
+	keys, err := a.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "artifact.tar.gz" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	if err := a.Delete("artifact.tar.gz"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := a.Delete("artifact.tar.gz"); err != nil {
+		t.Errorf("expected nil for absent key, got %v", err)
+	}
+	if _, err := a.Read("artifact.tar.gz"); !IsNotFound(err) {
+		t.Errorf("expected IsNotFound, got %v", err)
+	}
+}
+
+func TestAzBlobAtomicReadWriteIfMatch(t *testing.T) {
+	a, _ := newTestAzBlob(t)
+
+	if _, _, err := a.ReadWithVersion("k"); !IsNotFound(err) {
+		t.Errorf("expected IsNotFound, got %v", err)
+	}
+	v1, err := a.WriteIfMatch("k", "", []byte("v1"))
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if _, err := a.WriteIfMatch("k", "", []byte("again")); !IsConflict(err) {
+		t.Errorf("expected IsConflict on second write-if-absent, got %v", err)
+	}
+
+	data, version, err := a.ReadWithVersion("k")
+	if err != nil || string(data) != "v1" || version != v1 {
+		t.Fatalf("ReadWithVersion = %q %q %v, want v1 %q", data, version, err, v1)
+	}
+	v2, err := a.WriteIfMatch("k", version, []byte("v2"))
+	if err != nil || v2 == v1 {
+		t.Fatalf("CAS with current version = %q, %v", v2, err)
+	}
+	if _, err := a.WriteIfMatch("k", v1, []byte("v3")); !IsConflict(err) {
+		t.Errorf("expected IsConflict on stale CAS, got %v", err)
+	}
+}
+
+func TestNewAzBlobURLParse(t *testing.T) {
+	tests := []struct {
+		desc      string
+		url       string
+		container string
+		prefix    string
+		ttl       time.Duration
+		expectErr bool
+	}{
+		{"basic", "azblob://acct/cache", "cache", "", 0, false},
+		{"with prefix", "azblob://acct/cache/team/app", "cache", "team/app/", 0, false},
+		{"prefix and ttl", "azblob://acct/cache/team/app?registry-ttl=1m&endpoint=http://127.0.0.1:10000/acct", "cache", "team/app/", time.Minute, false},
+		{"missing account", "azblob:///cache", "", "", 0, true},
+		{"missing container", "azblob://acct", "", "", 0, true},
+		{"invalid ttl", "azblob://acct/cache?registry-ttl=oops", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			a, err := NewAzBlobWithClient(context.Background(), tt.url, nil, newMockAzBlobClient())
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a.Account != "acct" || a.Container != tt.container || a.Prefix != tt.prefix || a.RegistryTTL() != tt.ttl {
+				t.Errorf("got %s/%s/%s ttl %v", a.Account, a.Container, a.Prefix, a.RegistryTTL())
+			}
+		})
+	}
+}
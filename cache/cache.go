@@ -31,6 +31,7 @@ type Cache interface {
 //   - "" or "file": local filesystem cache (default).
 //   - "s3://<region>/<bucket>/<prefix>": Amazon S3 backed cache with local staging.
 //   - "gs://<bucket>/<prefix>": Google Cloud Storage backed cache with local staging.
+//   - "azblob://<account>/<container>/<prefix>": Azure Blob Storage backed cache with local staging.
 //
 // An empty urlStr returns the default file backend.
 func New(ctx context.Context, urlStr string, log *slog.Logger) (Cache, error) {
@@ -65,8 +66,10 @@ func New(ctx context.Context, urlStr string, log *slog.Logger) (Cache, error) {
 		return NewS3(ctx, urlStr, log)
 	case "gs":
 		return NewGS(ctx, urlStr, log)
+	case "azblob":
+		return NewAzBlob(ctx, urlStr, log)
 	default:
-		return nil, fmt.Errorf("unsupported cache scheme %q; supported schemes: file, s3, gs", scheme)
+		return nil, fmt.Errorf("unsupported cache scheme %q; supported schemes: file, s3, gs, azblob", scheme)
 	}
 }
 
@@ -101,7 +104,7 @@ func IsConflict(err error) bool {
 }
 
 // AtomicCache is an optional capability for cache backends that support
-// conditional writes. Cloud backends (S3, GCS) implement it so that callers
+// conditional writes. Cloud backends (S3, GCS, Azure Blob) implement it so that callers
 // can coordinate writes across instances without an external lock service.
 //
 // version is an opaque token returned by ReadWithVersion. Pass it back in
@@ -30,6 +30,7 @@ func TestFileDefault(t *testing.T) {
 func TestFileRead(t *testing.T) {
 	f := &File{}
 	f.Default()
+	f.SetDir(t.TempDir())
 	data := []byte("this is data for test")
 	err := f.Write("testread", data)
 	if err != nil {
@@ -51,6 +52,7 @@ func TestFileRead(t *testing.T) {
 func TestFileWrite(t *testing.T) {
 	f := &File{}
 	f.Default()
+	f.SetDir(t.TempDir())
 	data := []byte("this is data for test")
 	err := f.Write("test", data)
 	if err != nil {
@@ -79,6 +81,7 @@ func TestFileWrite(t *testing.T) {
 func TestFileDelete(t *testing.T) {
 	f := &File{}
 	f.Default()
+	f.SetDir(t.TempDir())
 	data := []byte("this is data for test")
 	err := f.Write("testdelete", data)
 	if err != nil {
@@ -100,6 +103,7 @@ func TestFileDelete(t *testing.T) {
 func TestFileList(t *testing.T) {
 	f := &File{}
 	f.Default()
+	f.SetDir(t.TempDir())
 	data := []byte("this is data for test")
 	err := f.Write("testlist", data)
 	if err != nil {
@@ -1,13 +1,19 @@
 package dewy
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"reflect"
 	"sort"
 	"strconv"
@@ -18,6 +24,7 @@ import (
 	flags "github.com/jessevdk/go-flags"
 	"github.com/linyows/dewy/container"
 	"github.com/linyows/dewy/telemetry"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -32,28 +39,69 @@ const (
 )
 
 type cli struct {
-	env              Env
-	command          string
-	args             []string
-	Name             string   `long:"name" short:"n" description:"Application name for container deployment"`
-	LogLevel         string   `long:"log-level" short:"l" arg:"(debug|info|warn|error)" description:"Set log level for output (default: error)"`
-	LogFormat        string   `long:"log-format" short:"f" arg:"(text|json)" description:"Set log format for output (default: text)"`
-	Interval         int      `long:"interval" arg:"seconds" short:"i" description:"Polling interval in seconds for checking registry updates (default: 10)"`
-	Ports            []string `long:"port" short:"p" description:"For server: TCP ports to listen on. For container: port mappings in format 'proxy' or 'proxy:container' (multiple flags supported)"`
-	Registry         string   `long:"registry" description:"Registry URL (e.g., ghr://owner/repo, s3://region/bucket/prefix, docker://registry/repo)"`
-	Cache            string   `long:"cache" short:"c" description:"Cache backend URL (e.g., file:///path, s3://region/bucket/prefix, gs://bucket/prefix). Defaults to local file."`
-	Notifier         string   `long:"notifier" description:"Notifier URL for deployment notifications (e.g., slack://channel, mail://smtp:port/recipient)"`
-	BeforeDeployHook string   `long:"before-deploy-hook" description:"Shell command to execute before deployment begins"`
-	AfterDeployHook  string   `long:"after-deploy-hook" description:"Shell command to execute after successful deployment"`
+	env                Env
+	command            string
+	args               []string
+	Name               string   `long:"name" short:"n" description:"Application name for container deployment"`
+	LogLevel           string   `long:"log-level" short:"l" arg:"(debug|info|warn|error)" description:"Set log level for output (default: error)"`
+	LogFormat          string   `long:"log-format" short:"f" arg:"(text|json)" description:"Set log format for output (default: text)"`
+	Interval           int      `long:"interval" arg:"seconds" short:"i" description:"Polling interval in seconds for checking registry updates (default: 10)"`
+	Ports              []string `long:"port" short:"p" description:"For server: TCP ports to listen on. For container: port mappings in format 'proxy' or 'proxy:container' (multiple flags supported)"`
+	Registry           string   `long:"registry" description:"Registry URL (e.g., ghr://owner/repo, s3://region/bucket/prefix, docker://registry/repo)"`
+	Cache              string   `long:"cache" short:"c" description:"Cache backend URL (e.g., file:///path, s3://region/bucket/prefix, gs://bucket/prefix, azblob://account/container/prefix). Defaults to local file."`
+	Notifier           string   `long:"notifier" description:"Notifier URL for deployment notifications (e.g., slack://channel, mail://smtp:port/recipient)"`
+	BeforeDeployHook   string   `long:"before-deploy-hook" description:"Shell command to execute before deployment begins"`
+	AfterDeployHook    string   `long:"after-deploy-hook" description:"Shell command to execute after successful deployment"`
+	PreDownloadHook    string   `long:"pre-download-hook" description:"Shell command to execute before the artifact is downloaded"`
+	PostExtractHook    string   `long:"post-extract-hook" description:"Shell command to execute after extraction, before the release goes live"`
+	BeforeRestartHook  string   `long:"before-restart-hook" description:"Shell command to execute before the server is (re)started"`
+	AfterRestartHook   string   `long:"after-restart-hook" description:"Shell command to execute after the server is (re)started"`
+	OnFailureHook      string   `long:"on-failure-hook" description:"Shell command to execute when a deployment fails (DEWY_ERROR holds the error)"`
+	OnRollbackHook     string   `long:"on-rollback-hook" description:"Shell command to execute after rolling back to the previous release"`
+	BlockingHooks      []string `long:"blocking-hook" description:"Hook stage whose failure aborts the deployment (e.g., before-deploy, post-extract; multiple flags supported)"`
+	HookTimeout        int      `long:"hook-timeout" description:"Timeout in seconds for each deploy hook; the hook's process group is killed on expiry (default: 300)"`
+	Webhooks           string   `long:"webhooks" description:"JSON file declaring HTTP webhook hooks (stage, method, url, headers, body template, expect_status, retries)"`
+	TemplateDir        string   `long:"template-dir" description:"Directory of Go templates rendered into each new release before it goes live (server/assets)"`
+	EnvFile            string   `long:"env-file" description:"Env file (KEY=VALUE lines) given to the server process only; reloaded on every restart"`
+	CrashLoopThreshold int      `long:"crash-loop-threshold" description:"For server: crashes within --crash-loop-window that count as a crash loop and are notified (default: 5)"`
+	CrashLoopWindow    int      `long:"crash-loop-window" description:"For server: crash-loop detection window in seconds (default: 600)"`
+	CaptureLogs        bool     `long:"capture-logs" description:"Capture application output into rotated files under logs/ and serve it on the admin API /api/logs endpoint"`
+	// Client-command options
+	Force      bool   `long:"force" description:"For deploy: redeploy the current tag even when it is already deployed"`
+	Follow     bool   `long:"follow" description:"For logs: keep streaming new output"`
+	LogReplica int    `long:"replica" description:"For logs: container replica to show (default: 0)"`
+	LogLines   int    `long:"lines" description:"For logs: number of recent lines to show first (default: 100)"`
+	Output     string `long:"output" short:"o" arg:"(json|yaml|wide)" description:"For status: output format (default: a short summary). For watch: json prints raw events"`
+	// Registry server options
+	RegistryDir      string `long:"dir" description:"Release directory laid out as <dir>/<tag>/<artifacts>"`
+	RegistryUpstream string `long:"upstream" description:"Existing registry URL to pass through instead of --dir, for clients on this platform (e.g., ghr://owner/repo)"`
+	GRPCAddr         string `long:"grpc-addr" description:"gRPC listen address (default: :9000)"`
+	HTTPAddr         string `long:"http-addr" description:"Listen address for artifact downloads and, with --token-file, /reports (default: :8080)"`
+	ArtifactURL      string `long:"artifact-url" description:"Base URL clients download artifacts from (default: http://<hostname><http-addr>)"`
+	ReportsFile      string `long:"reports-file" description:"JSON Lines file the received reports are kept in (default: memory only)"`
+	PreRelease       bool   `long:"pre-release" description:"Include pre-release tags of --dir"`
+	TLSCert          string `long:"tls-cert" description:"Serve gRPC and HTTP over TLS with this certificate (requires --tls-key)"`
+	TLSKey           string `long:"tls-key" description:"Private key for --tls-cert"`
+	TokenFile        string `long:"token-file" description:"Bearer token file (mode 0600) that gRPC clients and /reports must present, as the token-file option of grpc:// sends it (requires --tls-cert)"`
 	// Container-specific options
 	Replicas         int      `long:"replicas" description:"Number of container replicas to run (default: 1)"`
 	HealthPath       string   `long:"health-path" description:"Health check path (optional, e.g., /health)"`
+	HealthTCP        bool     `long:"health-tcp" description:"For server: probe the first --port with a TCP connect after each (re)start"`
+	HealthPort       string   `long:"health-port" description:"For server: port or host:port the health check probes instead of the first --port"`
 	HealthTimeout    int      `long:"health-timeout" description:"Health check timeout in seconds (default: 30)"`
 	DrainTime        int      `long:"drain-time" description:"Drain time in seconds after traffic switch (default: 30 for container command)"`
 	ContainerRuntime string   `long:"runtime" description:"Container runtime (docker or podman, default: docker)"`
 	ProxyIdleTimeout int      `long:"proxy-idle-timeout" description:"Proxy idle timeout in seconds (default: 300, 0 to disable)"`
 	Cmd              []string `long:"cmd" description:"Command and arguments to pass to container (can be specified multiple times)"`
-	AdminPort        int      `long:"admin-port" description:"Admin API port for container command (default: 17539, auto-increments if in use)"`
+	AdminPort        int      `long:"admin-port" description:"Admin API port (default: 17539, auto-increments if in use). Outside container mode the admin API only starts when an admin flag is given or telemetry is enabled"`
+	AdminSocket      string   `long:"admin-socket" description:"Serve the admin API on this Unix socket, or \"auto\" for /run/dewy/<name>.sock (falling back to ./dewy.sock). Client commands find sockets on their own"`
+	AdminTokenFile   string   `long:"admin-token-file" description:"Admin API bearer token file (mode 0600, \"<token> [read|write]\" per line; created if missing). Without it or --admin-client-ca, write actions are only accepted on --admin-socket. Client commands default to ./admin.token"`
+	AdminTLSCert     string   `long:"admin-tls-cert" description:"Serve the admin API over TLS with this certificate (requires --admin-tls-key)"`
+	AdminTLSKey      string   `long:"admin-tls-key" description:"Private key for --admin-tls-cert"`
+	AdminClientCA    string   `long:"admin-client-ca" description:"CA bundle for admin API client certificates (mTLS)"`
+	AdminCA          string   `long:"admin-ca" description:"For client commands: CA bundle to verify an admin API served over TLS"`
+	AdminClientCert  string   `long:"admin-client-cert" description:"For client commands: client certificate for an mTLS admin API (requires --admin-client-key)"`
+	AdminClientKey   string   `long:"admin-client-key" description:"For client commands: private key for --admin-client-cert"`
 	Slot             string   `long:"slot" short:"s" description:"Deployment slot for blue/green deployment (e.g., blue, green). Only deploys if tag's build metadata matches."`
 	CalVer           string   `long:"calver" description:"CalVer format for version identification (e.g., YYYY.0M.0D.MICRO)"`
 	Telemetry        bool     `long:"telemetry" description:"Enable telemetry (Prometheus metrics on admin API /metrics endpoint)"`
@@ -153,6 +201,21 @@ func (c *cli) showHelp() {
 		"LogFormat",
 		"BeforeDeployHook",
 		"AfterDeployHook",
+		"PreDownloadHook",
+		"PostExtractHook",
+		"OnFailureHook",
+		"OnRollbackHook",
+		"BlockingHooks",
+		"HookTimeout",
+		"Webhooks",
+		"TemplateDir",
+		"CaptureLogs",
+		"AdminPort",
+		"AdminSocket",
+		"AdminTokenFile",
+		"AdminTLSCert",
+		"AdminTLSKey",
+		"AdminClientCA",
 		"Telemetry",
 		"OTLPEndpoint",
 		"OTLPInsecure",
@@ -160,6 +223,15 @@ func (c *cli) showHelp() {
 
 	serverOpts := strings.Join(c.buildHelp([]string{
 		"Ports",
+		"EnvFile",
+		"HealthPath",
+		"HealthTCP",
+		"HealthPort",
+		"HealthTimeout",
+		"CrashLoopThreshold",
+		"CrashLoopWindow",
+		"BeforeRestartHook",
+		"AfterRestartHook",
 	}), "\n")
 
 	containerOpts := strings.Join(c.buildHelp([]string{
@@ -172,12 +244,43 @@ func (c *cli) showHelp() {
 		"ProxyIdleTimeout",
 	}), "\n")
 
+	clientOpts := strings.Join(c.buildHelp([]string{
+		"Force",
+		"Follow",
+		"LogReplica",
+		"LogLines",
+		"Output",
+		"AdminCA",
+		"AdminClientCert",
+		"AdminClientKey",
+	}), "\n")
+
+	registryOpts := strings.Join(c.buildHelp([]string{
+		"RegistryDir",
+		"RegistryUpstream",
+		"GRPCAddr",
+		"HTTPAddr",
+		"ArtifactURL",
+		"ReportsFile",
+		"PreRelease",
+		"CalVer",
+		"TLSCert",
+		"TLSKey",
+	}), "\n")
+
 	help := `Usage: dewy [--version] [--help] command <options>
 
 Commands:
   server     Keep the app server up to date
   assets     Keep assets up to date
   image      Keep container images up to date with zero-downtime deployment
+  deploy     Ask a running instance to deploy now (--force redeploys the current tag)
+  restart    Ask a running instance to restart its server or containers
+  scale      Set the number of container replicas of a running instance (dewy scale N)
+  logs       Show the captured output of a running instance
+  status     Show the deployment state of a running instance
+  watch      Stream the deployment events of a running instance
+  registry   Run a gRPC registry for dewy clients (dewy registry serve)
 
 General Options:
 %s
@@ -187,9 +290,15 @@ Server Command Options:
 
 Container Command Options:
 %s
+
+Client Command Options (status, watch, deploy, restart, scale, logs, container list):
+%s
+
+Registry Serve Options:
+%s
 `
 	Banner(c.env.Out)
-	fmt.Fprintf(c.env.Out, help, generalOpts, serverOpts, containerOpts)
+	fmt.Fprintf(c.env.Out, help, generalOpts, serverOpts, containerOpts, clientOpts, registryOpts)
 }
 
 func (c *cli) run() int {
@@ -217,12 +326,29 @@ func (c *cli) run() int {
 		return ExitOK
 	}
 
-	if len(args) == 0 || (args[0] != "server" && args[0] != "assets" && args[0] != "container") {
+	if len(args) == 0 || (args[0] != "server" && args[0] != "assets" && args[0] != "container" && args[0] != "registry" && !isClientCommand(args[0])) {
 		fmt.Fprintf(c.env.Err, "Error: command is not available\n")
 		c.showHelp()
 		return ExitErr
 	}
 
+	switch args[0] {
+	case "status":
+		return c.runStatus()
+	case "watch":
+		return c.runWatch()
+	case "logs":
+		return c.runLogs()
+	case "deploy":
+		return c.runDeploy()
+	case "restart":
+		return c.runRestart()
+	case "scale":
+		return c.runScale(args[1:])
+	case "registry":
+		return c.runRegistry(args[1:])
+	}
+
 	// Handle container subcommands (e.g., "dewy container list")
 	if args[0] == "container" && len(args) > 1 {
 		switch args[1] {
@@ -266,7 +392,41 @@ func (c *cli) run() int {
 	conf.Notifier = c.Notifier
 	conf.BeforeDeployHook = c.BeforeDeployHook
 	conf.AfterDeployHook = c.AfterDeployHook
+	conf.PreDownloadHook = c.PreDownloadHook
+	conf.PostExtractHook = c.PostExtractHook
+	conf.BeforeRestartHook = c.BeforeRestartHook
+	conf.AfterRestartHook = c.AfterRestartHook
+	conf.OnFailureHook = c.OnFailureHook
+	conf.OnRollbackHook = c.OnRollbackHook
+	for _, stage := range c.BlockingHooks {
+		if !validHookStage(stage) {
+			fmt.Fprintf(c.env.Err, "Error: unknown hook stage for --blocking-hook: %s\n", stage)
+			return ExitErr
+		}
+	}
+	conf.BlockingHooks = c.BlockingHooks
+	conf.HookTimeout = time.Duration(c.HookTimeout) * time.Second
+	if c.Webhooks != "" {
+		hooks, err := LoadWebhooks(c.Webhooks)
+		if err != nil {
+			fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+			return ExitErr
+		}
+		conf.Webhooks = hooks
+	}
+	conf.TemplateDir = c.TemplateDir
+	conf.EnvFile = c.EnvFile
+	conf.CaptureLogs = c.CaptureLogs
 	conf.AdminPort = c.AdminPort
+	conf.AdminSocket = c.AdminSocket
+	if c.AdminTokenFile != "" || c.AdminTLSCert != "" || c.AdminTLSKey != "" || c.AdminClientCA != "" {
+		conf.AdminAuth = &AdminAuthConfig{
+			TokenFile: c.AdminTokenFile,
+			TLSCert:   c.AdminTLSCert,
+			TLSKey:    c.AdminTLSKey,
+			ClientCA:  c.AdminClientCA,
+		}
+	}
 	conf.Slot = c.Slot
 	conf.CalVer = c.CalVer
 
@@ -345,6 +505,26 @@ func (c *cli) configureServerCommand(conf *Config) error {
 		args:      cmdArgs,
 		logformat: c.LogFormat,
 	}
+	conf.CrashLoopThreshold = c.CrashLoopThreshold
+	conf.CrashLoopWindow = time.Duration(c.CrashLoopWindow) * time.Second
+
+	// Health check is optional: an HTTP probe when --health-path is given,
+	// a TCP connect with --health-tcp. Both probe --health-port if given.
+	if c.HealthPort != "" && c.HealthPath == "" && !c.HealthTCP {
+		fmt.Fprintf(c.env.Err, "Error: --health-port needs --health-path or --health-tcp\n")
+		return fmt.Errorf("health port without a health check")
+	}
+	if c.HealthPath != "" || c.HealthTCP {
+		if len(parsedPorts) == 0 && c.HealthPort == "" {
+			fmt.Fprintf(c.env.Err, "Error: --health-path and --health-tcp need --port or --health-port for server command\n")
+			return fmt.Errorf("health check needs a port")
+		}
+		conf.HealthCheck = &HealthCheckConfig{
+			Path:    c.HealthPath,
+			Port:    c.HealthPort,
+			Timeout: time.Duration(c.HealthTimeout) * time.Second,
+		}
+	}
 	return nil
 }
 
@@ -648,43 +828,208 @@ https://github.com/linyows/dewy
 `)
 }
 
-// runContainerList runs the "dewy container list" command.
-func (c *cli) runContainerList() int {
-	// Default admin port
-	adminPort := c.AdminPort
-	if adminPort == 0 {
-		adminPort = 17539
+// isClientCommand reports whether name is a command that talks to a running
+// instance instead of starting one.
+func isClientCommand(name string) bool {
+	switch name {
+	case "status", "watch", "logs", "deploy", "restart", "scale":
+		return true
+	}
+	return false
+}
+
+// adminPort returns the first admin port to try.
+func (c *cli) adminPort() int {
+	if c.AdminPort == 0 {
+		return 17539
+	}
+	return c.AdminPort
+}
+
+// adminTLS returns the TLS config client commands use, or nil when the
+// admin API is plain HTTP.
+func (c *cli) adminTLS() (*tls.Config, error) {
+	if c.AdminCA == "" && c.AdminClientCert == "" && c.AdminClientKey == "" {
+		return nil, nil
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.AdminCA != "" {
+		pool, err := loadCertPool(c.AdminCA)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = pool
+	}
+	if c.AdminClientCert != "" || c.AdminClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.AdminClientCert, c.AdminClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load admin client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// adminToken returns the bearer token client commands send: from
+// --admin-token-file, else from ./admin.token when present, else none.
+func (c *cli) adminToken() (string, error) {
+	p := c.AdminTokenFile
+	if p == "" {
+		if _, err := os.Stat(defaultAdminTokenFile); err != nil {
+			return "", nil
+		}
+		p = defaultAdminTokenFile
+	}
+	return clientAdminToken(p)
+}
+
+// adminClient returns an HTTP client for the admin API over TCP.
+func (c *cli) adminClient(timeout time.Duration) (*http.Client, error) {
+	tc, err := c.adminTLS()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:     (&net.Dialer{Timeout: 2 * time.Second}).DialContext,
+			TLSClientConfig: tc,
+		},
+	}, nil
+}
+
+// adminSocket returns the socket client commands should talk to: the one
+// given with --admin-socket, else one discovered from the current directory
+// and /run/dewy, else "" to fall back to scanning admin ports.
+func (c *cli) adminSocket() (string, error) {
+	if c.AdminSocket != "" && c.AdminSocket != adminSocketAuto {
+		return c.AdminSocket, nil
+	}
+	return discoverAdminSocket(".", adminSocketDir, c.Name)
+}
+
+// adminRequest sends a request to a running dewy admin API, over its Unix socket
+// when one is found and otherwise to the first instance answering on the
+// admin port range. It returns the response and the port that answered (0
+// for a socket). A zero timeout only bounds connecting, for streams that
+// stay open.
+//
+// A scanned port may belong to another instance or to another program, so
+// only reads scan: actions over TCP go to --admin-port alone. The token is
+// sent up front only to --admin-port; a scanned port gets it once it has
+// answered with dewy's auth challenge.
+func (c *cli) adminRequest(method string, timeout time.Duration, path string, body []byte) (*http.Response, int, error) {
+	token, err := c.adminToken()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	socket, err := c.adminSocket()
+	if err != nil {
+		return nil, 0, err
+	}
+	if socket != "" {
+		client := &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{Timeout: 2 * time.Second}).DialContext(ctx, "unix", socket)
+				},
+			},
+		}
+		resp, err := adminDo(client, method, "http://dewy"+path, token, body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to reach dewy on %s: %w", socket, err)
+		}
+		return resp, 0, nil
 	}
 
-	// Try to connect to admin API, scanning through possible ports
 	maxAttempts := 10
-	client := &http.Client{
-		Timeout: 2 * time.Second,
+	if method != http.MethodGet {
+		if c.AdminPort == 0 {
+			return nil, 0, errors.New("no admin socket found: give --admin-socket or --admin-port for this action")
+		}
+		maxAttempts = 1
 	}
 
-	var resp *http.Response
-	var err error
-	var successPort int
+	client, err := c.adminClient(timeout)
+	if err != nil {
+		return nil, 0, err
+	}
+	scheme := "http"
+	if t, ok := client.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		scheme = "https"
+	}
 
+	// Try to connect to admin API, scanning through possible ports
+	adminPort := c.adminPort()
 	for i := range maxAttempts {
 		currentPort := adminPort + i
-		url := fmt.Sprintf("http://localhost:%d/api/containers", currentPort)
+		url := fmt.Sprintf("%s://localhost:%d%s", scheme, currentPort, path)
 
-		resp, err = client.Get(url)
+		var resp *http.Response
+		if c.AdminPort != 0 && i == 0 {
+			resp, err = adminDo(client, method, url, token, body)
+		} else {
+			resp, err = adminProbe(client, url, token)
+		}
 		if err == nil {
-			// Successfully connected
-			successPort = currentPort
-			break
+			return resp, currentPort, nil
 		}
 	}
 
-	if resp == nil {
-		fmt.Fprintf(c.env.Err, "Error: no running dewy instances found (tried ports %d-%d)\n",
-			adminPort, adminPort+maxAttempts-1)
+	if maxAttempts == 1 {
+		return nil, 0, fmt.Errorf("no running dewy instance found on port %d: %w", adminPort, err)
+	}
+	return nil, 0, fmt.Errorf("no running dewy instances found (tried ports %d-%d)",
+		adminPort, adminPort+maxAttempts-1)
+}
+
+// adminProbe GETs url without the token first, so a program that happens to
+// listen on a scanned port never sees it. Only a dewy asking for credentials
+// gets the request again with the token.
+func adminProbe(client *http.Client, url, token string) (*http.Response, error) {
+	resp, err := adminDo(client, http.MethodGet, url, "", nil)
+	if err != nil || token == "" || resp.StatusCode != http.StatusUnauthorized ||
+		resp.Header.Get("WWW-Authenticate") != adminAuthChallenge {
+		return resp, err
+	}
+	resp.Body.Close()
+	return adminDo(client, http.MethodGet, url, token, nil)
+}
+
+// adminDo sends an authenticated request.
+func adminDo(client *http.Client, method, url, token string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Do(req)
+}
+
+// runContainerList runs the "dewy container list" command.
+func (c *cli) runContainerList() int {
+	resp, successPort, err := c.adminRequest(http.MethodGet, 2*time.Second, "/api/containers", nil)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
 		return ExitErr
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		fmt.Fprintf(c.env.Err, "Error: admin API returned status %d (check --admin-token-file)\n", resp.StatusCode)
+		return ExitErr
+	}
 	if resp.StatusCode != http.StatusOK {
 		fmt.Fprintf(c.env.Err, "Error: admin API returned status %d\n", resp.StatusCode)
 		return ExitErr
@@ -703,13 +1048,139 @@ func (c *cli) runContainerList() int {
 	c.displayContainerList(result.Containers)
 
 	// Show which port was used (helpful for debugging)
-	if successPort != adminPort {
+	if successPort != 0 && successPort != c.adminPort() {
 		fmt.Fprintf(c.env.Out, "\n(Connected to admin API on port %d)\n", successPort)
 	}
 
 	return ExitOK
 }
 
+// runLogs runs the "dewy logs" command, printing (and with --follow,
+// streaming) the output captured by a running instance.
+func (c *cli) runLogs() int {
+	q := url.Values{}
+	q.Set("replica", strconv.Itoa(c.LogReplica))
+	if c.LogLines > 0 {
+		q.Set("lines", strconv.Itoa(c.LogLines))
+	}
+	if c.Follow {
+		q.Set("follow", "true")
+	}
+
+	// A followed stream stays open for as long as the user watches it, so
+	// only bound the connection attempt.
+	var timeout time.Duration
+	if !c.Follow {
+		timeout = 10 * time.Second
+	}
+	resp, _, err := c.adminRequest(http.MethodGet, timeout, "/api/logs?"+q.Encode(), nil)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		fmt.Fprintf(c.env.Err, "Error: admin API returned status %d: %s\n", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return ExitErr
+	}
+
+	if _, err := io.Copy(c.env.Out, resp.Body); err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+
+	return ExitOK
+}
+
+// runWatch runs the "dewy watch" command, printing deployment events of a
+// running instance as they happen until the stream ends or is interrupted.
+func (c *cli) runWatch() int {
+	if c.Output != "" && c.Output != "json" {
+		fmt.Fprintf(c.env.Err, "Error: unknown output format for watch: %s (json)\n", c.Output)
+		return ExitErr
+	}
+	resp, _, err := c.adminRequest(http.MethodGet, 0, "/api/events", nil)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		fmt.Fprintf(c.env.Err, "Error: admin API returned status %d: %s\n", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return ExitErr
+	}
+
+	err = readEvents(resp.Body, func(data []byte) {
+		if c.Output == "json" {
+			fmt.Fprintf(c.env.Out, "%s\n", data)
+			return
+		}
+		var e streamEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return
+		}
+		fmt.Fprintln(c.env.Out, formatEvent(e))
+	})
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	return ExitOK
+}
+
+// readEvents calls fn with the data of every event in a text/event-stream
+// body. Comments (keep-alives) and the other fields are skipped.
+func readEvents(r io.Reader, fn func(data []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	var data []byte
+	for sc.Scan() {
+		line := sc.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				fn(data)
+				data = nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+	}
+	return sc.Err()
+}
+
+// formatEvent renders e as one line: time, type and the fields it carries.
+func formatEvent(e streamEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-18s", e.Time.Local().Format(deployTimeFormat), e.Type)
+	field := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, " %s=%s", k, v)
+		}
+	}
+	field("tag", e.Tag)
+	field("previous", e.PreviousTag)
+	field("hook", e.Hook)
+	field("replica", e.Replica)
+	if e.Replicas > 0 {
+		field("replicas", strconv.Itoa(e.Replicas))
+	}
+	field("reason", e.Reason)
+	field("path", e.Path)
+	field("duration", e.Duration)
+	if e.Error != "" {
+		fmt.Fprintf(&b, " error=%q", e.Error)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
 // displayContainerList displays container information in table format.
 func (c *cli) displayContainerList(containers []*container.Info) {
 	if len(containers) == 0 {
@@ -828,3 +1299,229 @@ func extractAppNameFromRegistry(registryURL string) string {
 
 	return ""
 }
+
+// runDeploy runs the "dewy deploy" command.
+func (c *cli) runDeploy() int {
+	path := "/api/deploy"
+	if c.Force {
+		path += "?force=true"
+	}
+	var res tickResult
+	if code := c.adminCall(http.MethodPost, path, nil, &res); code != ExitOK {
+		return code
+	}
+
+	switch res.Outcome {
+	case outcomeDeployed:
+		fmt.Fprintf(c.env.Out, "Deployed %s (previous: %s) in %s\n", res.Tag, orNone(res.PreviousTag), res.Duration)
+	case outcomeSkipped:
+		if res.Tag == "" {
+			fmt.Fprintf(c.env.Out, "Nothing to deploy\n")
+		} else {
+			fmt.Fprintf(c.env.Out, "%s is already deployed (use --force to redeploy)\n", res.Tag)
+		}
+	default:
+		fmt.Fprintf(c.env.Err, "Error: deploy of %s failed: %s\n", orNone(res.Tag), res.Error)
+		return ExitErr
+	}
+	return ExitOK
+}
+
+// runRestart runs the "dewy restart" command.
+func (c *cli) runRestart() int {
+	var res restartResult
+	if code := c.adminCall(http.MethodPost, "/api/restart", nil, &res); code != ExitOK {
+		return code
+	}
+
+	if res.Outcome == outcomeFailed {
+		fmt.Fprintf(c.env.Err, "Error: restart of %s failed: %s\n", orNone(res.Tag), res.Error)
+		return ExitErr
+	}
+	if res.Replicas > 0 {
+		fmt.Fprintf(c.env.Out, "Restarted %d replicas of %s\n", res.Replicas, res.Tag)
+	} else {
+		fmt.Fprintf(c.env.Out, "Restarted %s\n", orNone(res.Tag))
+	}
+	return ExitOK
+}
+
+// runScale runs the "dewy scale N" command.
+func (c *cli) runScale(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(c.env.Err, "Error: usage: dewy scale <replicas>\n")
+		return ExitErr
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		fmt.Fprintf(c.env.Err, "Error: invalid replica count: %s\n", args[0])
+		return ExitErr
+	}
+	body, _ := json.Marshal(map[string]int{"count": n})
+
+	var res scaleResult
+	if code := c.adminCall(http.MethodPut, "/api/replicas", body, &res); code != ExitOK {
+		return code
+	}
+
+	switch res.Outcome {
+	case "scaled":
+		fmt.Fprintf(c.env.Out, "Scaled %s from %d to %d replicas (started %d, removed %d)\n",
+			res.Tag, res.Previous, res.Replicas, res.Started, res.Removed)
+	case "saved":
+		fmt.Fprintf(c.env.Out, "Replicas set to %d; applied with the next deploy\n", res.Replicas)
+	default:
+		fmt.Fprintf(c.env.Err, "Error: scale to %d failed: %s\n", n, res.Error)
+		return ExitErr
+	}
+	return ExitOK
+}
+
+// runStatus runs the "dewy status" command.
+func (c *cli) runStatus() int {
+	var raw json.RawMessage
+	if code := c.adminCall(http.MethodGet, "/api/status", nil, &raw); code != ExitOK {
+		return code
+	}
+
+	switch c.Output {
+	case "json":
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			fmt.Fprintf(c.env.Err, "Error: failed to format response: %v\n", err)
+			return ExitErr
+		}
+		buf.WriteByte('\n')
+		_, _ = buf.WriteTo(c.env.Out)
+	case "yaml":
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			fmt.Fprintf(c.env.Err, "Error: failed to parse response: %v\n", err)
+			return ExitErr
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			fmt.Fprintf(c.env.Err, "Error: failed to format response: %v\n", err)
+			return ExitErr
+		}
+		_, _ = c.env.Out.Write(b)
+	case "", "wide":
+		var s statusReport
+		if err := json.Unmarshal(raw, &s); err != nil {
+			fmt.Fprintf(c.env.Err, "Error: failed to parse response: %v\n", err)
+			return ExitErr
+		}
+		c.displayStatus(s, c.Output == "wide", time.Now())
+	default:
+		fmt.Fprintf(c.env.Err, "Error: unknown output format: %s (json, yaml or wide)\n", c.Output)
+		return ExitErr
+	}
+	return ExitOK
+}
+
+// displayStatus prints a status report as aligned "key: value" lines. wide
+// adds the slot, environment keys and a row per managed container.
+func (c *cli) displayStatus(s statusReport, wide bool, now time.Time) {
+	out := c.env.Out
+	row := func(k, v string) {
+		fmt.Fprintf(out, "%-17s%s\n", k+":", v)
+	}
+	ago := func(t time.Time) string {
+		return fmt.Sprintf("%s (%s ago)", t.Local().Format(deployTimeFormat), now.Sub(t).Round(time.Second))
+	}
+
+	row("Name", s.Name)
+	row("Mode", s.Command)
+	row("Registry", s.Registry)
+	if wide && s.Slot != "" {
+		row("Slot", s.Slot)
+	}
+	row("Current version", orNone(s.CurrentVersion))
+	if s.DeployedAt != nil {
+		row("Deployed at", ago(*s.DeployedAt))
+	}
+	if s.PendingVersion != "" {
+		row("Pending version", s.PendingVersion)
+	}
+	if p := s.LastPoll; p != nil {
+		poll := fmt.Sprintf("%s at %s", p.Outcome, ago(p.At))
+		if p.Tag != "" {
+			poll = fmt.Sprintf("%s %s at %s", p.Outcome, p.Tag, ago(p.At))
+		}
+		row("Last poll", poll)
+		if p.Error != "" {
+			row("Last error", p.Error)
+		}
+	}
+	if s.Command == "server" {
+		state := "stopped"
+		if s.IsRunning {
+			state = "running"
+		}
+		row("Server", state)
+		if loop, _ := s.Crashes["crash_loop"].(bool); loop {
+			row("Crash loop", fmt.Sprintf("yes (%v crashes in window)", s.Crashes["crashes_in_window"]))
+		}
+	}
+	if r := s.Replicas; r != nil {
+		row("Replicas", fmt.Sprintf("%d/%d running", r.Running, r.Desired))
+	}
+	for _, b := range s.Backends {
+		ups := "none (no healthy replica)"
+		if len(b.Upstreams) > 0 {
+			ups = strings.Join(b.Upstreams, ", ")
+		}
+		row("Backends", fmt.Sprintf(":%d -> %s", b.ProxyPort, ups))
+	}
+	if !wide {
+		return
+	}
+	if len(s.EnvKeys) > 0 {
+		row("Env keys", strings.Join(s.EnvKeys, ", "))
+	}
+	if len(s.Containers) == 0 {
+		return
+	}
+
+	sort.Slice(s.Containers, func(i, j int) bool { return s.Containers[i].Name < s.Containers[j].Name })
+	nameWidth := len("NAME")
+	for _, ct := range s.Containers {
+		nameWidth = max(nameWidth, len(ct.Name))
+	}
+	nameWidth += 4
+	fmt.Fprintf(out, "\n%-*s%-9s%-16s%-11s%-10s%s\n", nameWidth, "NAME", "REPLICA", "VERSION", "STATE", "RESTARTS", "STARTED")
+	for _, ct := range s.Containers {
+		fmt.Fprintf(out, "%-*s%-9s%-16s%-11s%-10d%s\n", nameWidth, ct.Name, orNone(ct.Replica), orNone(ct.Version),
+			ct.State, ct.Restarts, ct.StartedAt.Local().Format(deployTimeFormat))
+	}
+}
+
+// adminCall sends an action request and decodes the JSON response into v.
+// Deploys, restarts and scaling take as long as they take, so only
+// connecting is bounded.
+func (c *cli) adminCall(method, path string, body []byte, v any) int {
+	resp, _, err := c.adminRequest(method, 0, path, body)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		fmt.Fprintf(c.env.Err, "Error: admin API returned status %d: %s\n", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return ExitErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		fmt.Fprintf(c.env.Err, "Error: failed to parse response: %v\n", err)
+		return ExitErr
+	}
+	return ExitOK
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -2,7 +2,10 @@ package dewy
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -54,6 +57,18 @@ func TestRunCLI(t *testing.T) {
 			expectExit:  ExitErr,
 			expectError: "Error: --registry is not set",
 		},
+		{
+			name:        "registry without subcommand",
+			args:        []string{"registry"},
+			expectExit:  ExitErr,
+			expectError: "Error: usage: dewy registry serve",
+		},
+		{
+			name:        "registry serve without source",
+			args:        []string{"registry", "serve"},
+			expectExit:  ExitErr,
+			expectError: "exactly one of --dir and --upstream",
+		},
 	}
 
 	for _, tt := range tests {
@@ -944,3 +959,111 @@ func TestDisplayContainerList(t *testing.T) {
 		})
 	}
 }
+
+func TestCLI_ConfigureServerCommand_HealthCheck(t *testing.T) {
+	var errBuf bytes.Buffer
+	c := &cli{
+		Ports:         []string{"8080"},
+		HealthPath:    "/health",
+		HealthTimeout: 10,
+		args:          []string{"/opt/app/current/app"},
+		env:           Env{Err: &errBuf},
+	}
+	conf := DefaultConfig()
+	if err := c.configureServerCommand(&conf); err != nil {
+		t.Fatal(err)
+	}
+	want := &HealthCheckConfig{Path: "/health", Timeout: 10 * time.Second}
+	if !reflect.DeepEqual(conf.HealthCheck, want) {
+		t.Errorf("HealthCheck = %+v, want %+v", conf.HealthCheck, want)
+	}
+
+	// A probe needs something to dial.
+	c = &cli{HealthTCP: true, args: []string{"/opt/worker/current/worker"}, env: Env{Err: &errBuf}}
+	conf = DefaultConfig()
+	if err := c.configureServerCommand(&conf); err == nil {
+		t.Error("expected error for --health-tcp without --port")
+	}
+
+	// --health-port points the probe elsewhere.
+	c = &cli{HealthTCP: true, HealthPort: "127.0.0.1:9090", args: []string{"/opt/worker/current/worker"}, env: Env{Err: &errBuf}}
+	conf = DefaultConfig()
+	if err := c.configureServerCommand(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.HealthCheck == nil || conf.HealthCheck.Port != "127.0.0.1:9090" {
+		t.Errorf("HealthCheck = %+v, want port 127.0.0.1:9090", conf.HealthCheck)
+	}
+
+	c = &cli{Ports: []string{"8080"}, HealthPort: "9090", args: []string{"/opt/app/current/app"}, env: Env{Err: &errBuf}}
+	conf = DefaultConfig()
+	if err := c.configureServerCommand(&conf); err == nil {
+		t.Error("expected error for --health-port without a health check")
+	}
+}
+
+func TestCLI_AdminRequest_PortTrust(t *testing.T) {
+	t.Chdir(t.TempDir())
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(ts.Close)
+	port, err := strconv.Atoi(ts.URL[strings.LastIndex(ts.URL, ":")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := writeTokenFile(t, "secret write\n", 0600)
+	c := &cli{AdminTokenFile: tokenFile}
+
+	// Without a socket or --admin-port, actions are not sent to a scanned port.
+	if _, _, err := c.adminRequest(http.MethodPost, time.Second, "/api/restart", nil); err == nil {
+		t.Error("POST without --admin-port succeeded")
+	}
+
+	// The server is not scanned here, but stands in for a scanned port: it
+	// refuses without dewy's challenge, so the token must not be sent.
+	client := &http.Client{Timeout: time.Second}
+	resp, err := adminProbe(client, ts.URL+"/api/status", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(auths) != 1 || auths[0] != "" {
+		t.Errorf("Authorization sent to a non-dewy listener: %q", auths)
+	}
+
+	// --admin-port is trusted with the token and with actions.
+	auths = nil
+	c.AdminPort = port
+	resp, _, err = c.adminRequest(http.MethodPost, time.Second, "/api/restart", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(auths) != 1 || auths[0] != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the token on --admin-port", auths)
+	}
+}
+
+func TestAdminProbe_DewyChallenge(t *testing.T) {
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", adminAuthChallenge)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	resp, err := adminProbe(&http.Client{Timeout: time.Second}, ts.URL+"/api/status", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(auths) != 2 || auths[1] != "Bearer secret" {
+		t.Errorf("status = %d, Authorization = %q; want the token after dewy's challenge", resp.StatusCode, auths)
+	}
+}
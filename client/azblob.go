@@ -0,0 +1,348 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// azureBlobAPIVersion is the Blob service REST API version requested.
+	azureBlobAPIVersion = "2021-08-06"
+
+	// Azurite, the storage emulator, accepts this well-known development
+	// account and key; see UseDevelopmentStorage=true.
+	azuriteAccount  = "devstoreaccount1"
+	azuriteKey      = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azuriteEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
+)
+
+var (
+	// ErrBlobNotFound is returned for a blob or container that does not exist.
+	ErrBlobNotFound = errors.New("blob not found")
+	// ErrBlobConditionNotMet is returned when the If-Match or If-None-Match
+	// condition of a write does not hold.
+	ErrBlobConditionNotMet = errors.New("blob condition not met")
+)
+
+// AzureBlob is a minimal client of the Azure Blob Storage REST API covering
+// the blob operations dewy needs, without the Azure SDK.
+type AzureBlob struct {
+	Account  string
+	Endpoint *url.URL
+	key      []byte
+	sas      url.Values
+	cl       *http.Client
+}
+
+// Blob is an entry of a blob listing.
+type Blob struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+}
+
+// NewAzureBlob creates a new Azure Blob Storage client for account.
+// The endpoint defaults to https://<account>.blob.core.windows.net; pass one
+// for sovereign clouds or the emulator. Credentials, in priority order:
+//  1. AZURE_STORAGE_CONNECTION_STRING (AccountKey or SharedAccessSignature,
+//     BlobEndpoint; UseDevelopmentStorage=true selects Azurite)
+//  2. Shared key (AZURE_STORAGE_KEY)
+//  3. SAS token (AZURE_STORAGE_SAS_TOKEN)
+//
+// Without credentials only public containers can be read.
+func NewAzureBlob(account, endpoint string) (*AzureBlob, error) {
+	var key, sas string
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		kv := parseConnectionString(cs)
+		if kv["UseDevelopmentStorage"] == "true" {
+			kv["AccountName"], kv["AccountKey"], kv["BlobEndpoint"] = azuriteAccount, azuriteKey, azuriteEndpoint
+		}
+		if name := kv["AccountName"]; name != "" && name != account {
+			return nil, fmt.Errorf("connection string is for account %s, not %s", name, account)
+		}
+		key, sas = kv["AccountKey"], kv["SharedAccessSignature"]
+		if endpoint == "" {
+			endpoint = kv["BlobEndpoint"]
+		}
+	} else {
+		key, sas = os.Getenv("AZURE_STORAGE_KEY"), os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	}
+	if endpoint == "" {
+		endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob endpoint: %w", err)
+	}
+	a := &AzureBlob{
+		Account:  account,
+		Endpoint: u,
+		cl:       &http.Client{Timeout: 60 * time.Second},
+	}
+	if key != "" {
+		if a.key, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("invalid storage account key: %w", err)
+		}
+	} else if sas != "" {
+		if a.sas, err = url.ParseQuery(strings.TrimPrefix(sas, "?")); err != nil {
+			return nil, fmt.Errorf("invalid SAS token: %w", err)
+		}
+	}
+	return a, nil
+}
+
+func parseConnectionString(cs string) map[string]string {
+	kv := make(map[string]string)
+	for _, part := range strings.Split(cs, ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			kv[k] = v
+		}
+	}
+	return kv
+}
+
+// Get returns the content of a blob and its ETag. Blobs can be large, so the
+// download is bounded by ctx rather than the API timeout.
+func (a *AzureBlob) Get(ctx context.Context, container, name string) (io.ReadCloser, string, error) {
+	cl := &http.Client{Transport: a.cl.Transport}
+	res, err := a.send(ctx, cl, http.MethodGet, container, name, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return res.Body, res.Header.Get("ETag"), nil
+}
+
+// Read is Get reading the whole blob.
+func (a *AzureBlob) Read(ctx context.Context, container, name string) ([]byte, string, error) {
+	rc, etag, err := a.Get(ctx, container, name)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return data, etag, err
+}
+
+// Put writes a block blob and returns its new ETag. With ifMatch set, the
+// write happens only while the blob has that ETag; see PutIfAbsent for
+// create-only writes.
+func (a *AzureBlob) Put(ctx context.Context, container, name string, data []byte, ifMatch string) (string, error) {
+	h := http.Header{}
+	if ifMatch != "" {
+		h.Set("If-Match", ifMatch)
+	}
+	return a.put(ctx, container, name, data, h)
+}
+
+// PutIfAbsent writes a block blob only if none exists at name.
+func (a *AzureBlob) PutIfAbsent(ctx context.Context, container, name string, data []byte) (string, error) {
+	return a.put(ctx, container, name, data, http.Header{"If-None-Match": {"*"}})
+}
+
+func (a *AzureBlob) put(ctx context.Context, container, name string, data []byte, h http.Header) (string, error) {
+	h.Set("x-ms-blob-type", "BlockBlob")
+	h.Set("Content-Type", "application/octet-stream")
+	res, err := a.do(ctx, http.MethodPut, container, name, nil, h, data)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+	return res.Header.Get("ETag"), nil
+}
+
+// Delete deletes a blob.
+func (a *AzureBlob) Delete(ctx context.Context, container, name string) error {
+	res, err := a.do(ctx, http.MethodDelete, container, name, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// List returns the blobs of container under prefix. With a delimiter, blobs
+// below the next delimiter are folded into the returned prefixes, like
+// directories.
+func (a *AzureBlob) List(ctx context.Context, container, prefix, delimiter string) ([]Blob, []string, error) {
+	var blobs []Blob
+	var prefixes []string
+	marker := ""
+	for {
+		q := url.Values{"restype": {"container"}, "comp": {"list"}}
+		if prefix != "" {
+			q.Set("prefix", prefix)
+		}
+		if delimiter != "" {
+			q.Set("delimiter", delimiter)
+		}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		res, err := a.do(ctx, http.MethodGet, container, "", q, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var out struct {
+			Blobs struct {
+				Blob []struct {
+					Name       string
+					Properties struct {
+						LastModified  string `xml:"Last-Modified"`
+						ContentLength int64  `xml:"Content-Length"`
+					}
+				}
+				BlobPrefix []struct {
+					Name string
+				}
+			}
+			NextMarker string
+		}
+		err = xml.NewDecoder(res.Body).Decode(&out)
+		res.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid blob listing: %w", err)
+		}
+		for _, b := range out.Blobs.Blob {
+			lm, _ := time.Parse(http.TimeFormat, b.Properties.LastModified)
+			blobs = append(blobs, Blob{Name: b.Name, Size: b.Properties.ContentLength, LastModified: lm})
+		}
+		for _, p := range out.Blobs.BlobPrefix {
+			prefixes = append(prefixes, p.Name)
+		}
+		if out.NextMarker == "" {
+			return blobs, prefixes, nil
+		}
+		marker = out.NextMarker
+	}
+}
+
+// do sends a request for a blob, or for the container when name is empty.
+// The response body must be closed by the caller unless an error is returned.
+func (a *AzureBlob) do(ctx context.Context, method, container, name string, q url.Values, h http.Header, body []byte) (*http.Response, error) {
+	return a.send(ctx, a.cl, method, container, name, q, h, body)
+}
+
+// send is do with the given HTTP client.
+func (a *AzureBlob) send(ctx context.Context, cl *http.Client, method, container, name string, q url.Values, h http.Header, body []byte) (*http.Response, error) {
+	u := *a.Endpoint
+	u.Path += "/" + container
+	if name != "" {
+		u.Path += "/" + name
+	}
+	if q == nil {
+		q = url.Values{}
+	}
+	for k, v := range a.sas {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureBlobAPIVersion)
+	if a.key != nil {
+		req.Header.Set("Authorization", "SharedKey "+a.Account+":"+a.sign(req))
+	}
+
+	res, err := cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode <= 299 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	target := container + "/" + name
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, target)
+	case res.StatusCode == http.StatusPreconditionFailed,
+		res.StatusCode == http.StatusConflict && res.Header.Get("x-ms-error-code") == "BlobAlreadyExists":
+		return nil, fmt.Errorf("%w: %s", ErrBlobConditionNotMet, target)
+	}
+	return nil, fmt.Errorf("azure blob %s %s: %s: %s", method, target, res.Status, strings.TrimSpace(string(msg)))
+}
+
+// sign returns the Shared Key signature of req.
+// See https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func (a *AzureBlob) sign(req *http.Request) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(a.stringToSign(req)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (a *AzureBlob) stringToSign(req *http.Request) string {
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	h := req.Header
+	lines := []string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		length,
+		h.Get("Content-MD5"),
+		h.Get("Content-Type"),
+		"", // Date; x-ms-date is signed instead
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+	}
+
+	var msHeaders []string
+	for k := range h {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders, k)
+		}
+	}
+	sort.Strings(msHeaders)
+	var b strings.Builder
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n")
+	for _, k := range msHeaders {
+		b.WriteString(k + ":" + strings.TrimSpace(h.Get(k)) + "\n")
+	}
+
+	// The canonicalized resource: the account, the path as encoded in the
+	// URI (which for the emulator repeats the account), then each query
+	// parameter as "\nname:value[,value]" sorted by lowercased name.
+	b.WriteString("/" + a.Account + req.URL.EscapedPath())
+	q := req.URL.Query()
+	names := make([]string, 0, len(q))
+	for k := range q {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v := append([]string(nil), q[k]...)
+		sort.Strings(v)
+		b.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(v, ","))
+	}
+	return b.String()
+}
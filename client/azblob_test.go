@@ -0,0 +1,380 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAzureKey = "dGVzdGtleQ==" // base64("testkey")
+
+// fakeBlobService is an in-memory Blob service for one container that
+// checks Shared Key signatures and honors If-Match and If-None-Match.
+type fakeBlobService struct {
+	signer  *AzureBlob
+	mu      sync.Mutex
+	blobs   map[string][]byte
+	etags   map[string]string
+	nextTag int
+	pageLen int
+}
+
+// newFakeBlobService serves a fakeBlobService signing with AZURE_STORAGE_KEY.
+func newFakeBlobService(t *testing.T, account string) *httptest.Server {
+	t.Helper()
+	signer, err := NewAzureBlob(account, "http://unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeBlobService{signer: signer, blobs: map[string][]byte{}, etags: map[string]string{}, pageLen: 2}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if want := "SharedKey " + f.signer.Account + ":" + f.signer.sign(r); r.Header.Get("Authorization") != want {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	// The path is /<account>/<container>[/<blob>], as on Azurite.
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(parts) < 2 || parts[1] != "releases" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 {
+		f.list(w, r)
+		return
+	}
+	name := parts[2]
+	etag, exists := f.etags[name]
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write(f.blobs[name])
+	case http.MethodPut:
+		if m := r.Header.Get("If-Match"); m != "" && m != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.Header().Set("x-ms-error-code", "BlobAlreadyExists")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.blobs[name], _ = io.ReadAll(r.Body)
+		f.nextTag++
+		f.etags[name] = fmt.Sprintf(`"0x%d"`, f.nextTag)
+		w.Header().Set("ETag", f.etags[name])
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, name)
+		delete(f.etags, name)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (f *fakeBlobService) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+	type entry struct {
+		name   string
+		prefix bool
+	}
+	var entries []entry
+	seen := map[string]bool{}
+	var names []string
+	for n := range f.blobs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if !strings.HasPrefix(n, prefix) {
+			continue
+		}
+		if i := strings.Index(n[len(prefix):], delim); delim != "" && i >= 0 {
+			p := n[:len(prefix)+i+len(delim)]
+			if !seen[p] {
+				seen[p] = true
+				entries = append(entries, entry{p, true})
+			}
+			continue
+		}
+		entries = append(entries, entry{n, false})
+	}
+
+	start, _ := strconv.Atoi(q.Get("marker"))
+	end := min(start+f.pageLen, len(entries))
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs>`)
+	for _, e := range entries[start:end] {
+		if e.prefix {
+			fmt.Fprintf(&b, `<BlobPrefix><Name>%s</Name></BlobPrefix>`, e.name)
+		} else {
+			fmt.Fprintf(&b, `<Blob><Name>%s</Name><Properties><Last-Modified>Sun, 01 Mar 2026 12:00:00 GMT</Last-Modified><Content-Length>%d</Content-Length></Properties></Blob>`, e.name, len(f.blobs[e.name]))
+		}
+	}
+	b.WriteString(`</Blobs><NextMarker>`)
+	if end < len(entries) {
+		b.WriteString(strconv.Itoa(end))
+	}
+	b.WriteString(`</NextMarker></EnumerationResults>`)
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func setAzureEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, k := range []string{"AZURE_STORAGE_CONNECTION_STRING", "AZURE_STORAGE_KEY", "AZURE_STORAGE_SAS_TOKEN"} {
+		t.Setenv(k, env[k])
+	}
+}
+
+func TestNewAzureBlob(t *testing.T) {
+	tests := []struct {
+		name         string
+		account      string
+		endpoint     string
+		env          map[string]string
+		wantEndpoint string
+		wantKey      bool
+		wantSAS      string
+		wantErr      bool
+	}{
+		{name: "anonymous", account: "acct", wantEndpoint: "https://acct.blob.core.windows.net"},
+		{name: "shared key", account: "acct", env: map[string]string{"AZURE_STORAGE_KEY": testAzureKey},
+			wantEndpoint: "https://acct.blob.core.windows.net", wantKey: true},
+		{name: "sas", account: "acct", endpoint: "https://blob.example.net/", env: map[string]string{"AZURE_STORAGE_SAS_TOKEN": "?sv=2021&sig=abc"},
+			wantEndpoint: "https://blob.example.net", wantSAS: "sig=abc&sv=2021"},
+		{name: "azurite", account: "devstoreaccount1", env: map[string]string{"AZURE_STORAGE_CONNECTION_STRING": "UseDevelopmentStorage=true"},
+			wantEndpoint: "http://127.0.0.1:10000/devstoreaccount1", wantKey: true},
+		{name: "connection string wins", account: "acct",
+			env: map[string]string{
+				"AZURE_STORAGE_CONNECTION_STRING": "DefaultEndpointsProtocol=https;AccountName=acct;AccountKey=" + testAzureKey + ";BlobEndpoint=https://acct.blob.core.chinacloudapi.cn/",
+				"AZURE_STORAGE_SAS_TOKEN":         "sig=ignored",
+			},
+			wantEndpoint: "https://acct.blob.core.chinacloudapi.cn", wantKey: true},
+		{name: "account mismatch", account: "other", env: map[string]string{"AZURE_STORAGE_CONNECTION_STRING": "AccountName=acct;AccountKey=" + testAzureKey}, wantErr: true},
+		{name: "bad key", account: "acct", env: map[string]string{"AZURE_STORAGE_KEY": "not base64!"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setAzureEnv(t, tt.env)
+			a, err := NewAzureBlob(tt.account, tt.endpoint)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Endpoint.String() != tt.wantEndpoint || (a.key != nil) != tt.wantKey || a.sas.Encode() != tt.wantSAS {
+				t.Errorf("got %s key=%v sas=%q", a.Endpoint, a.key != nil, a.sas.Encode())
+			}
+		})
+	}
+}
+
+func TestAzureBlobStringToSign(t *testing.T) {
+	setAzureEnv(t, map[string]string{"AZURE_STORAGE_KEY": testAzureKey})
+	a, err := NewAzureBlob("acct", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPut, "https://acct.blob.core.windows.net/releases/v1.0.0/app%20x.tar.gz?comp=list&restype=container", strings.NewReader("data"))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("If-Match", `"0x1"`)
+	req.Header.Set("x-ms-version", azureBlobAPIVersion)
+	req.Header.Set("x-ms-date", "Sun, 01 Mar 2026 12:00:00 GMT")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	want := "PUT\n\n\n4\n\napplication/octet-stream\n\n\n\"0x1\"\n\n\n\n" +
+		"x-ms-blob-type:BlockBlob\nx-ms-date:Sun, 01 Mar 2026 12:00:00 GMT\nx-ms-version:2021-08-06\n" +
+		"/acct/releases/v1.0.0/app%20x.tar.gz\ncomp:list\nrestype:container"
+	if got := a.stringToSign(req); got != want {
+		t.Errorf("stringToSign =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestAzureBlobSignVectors checks sign against signatures computed outside
+// this package: HMAC-SHA256 with the well-known Azurite key over the
+// string-to-sign written out by hand from the Shared Key documentation. The
+// fake service below verifies requests with sign, so it relies on these.
+func TestAzureBlobSignVectors(t *testing.T) {
+	const date = "Sun, 01 Mar 2026 12:00:00 GMT"
+	const marker = "2!88!MDAwMDIxIWFwcC92MS4xLjAvYXBwLnRhci5neiEwMDAwMjghOTk5OS0xMi0zMVQyMzo1OTo1OS45OTk5OTk5Wg--"
+	setAzureEnv(t, map[string]string{"AZURE_STORAGE_KEY": azuriteKey})
+	tests := []struct {
+		name    string
+		account string
+		method  string
+		url     string
+		body    string
+		header  map[string]string
+		want    string
+	}{
+		{
+			// GET .../releases?comp=list&delimiter=%2F&marker=2%2188%21...&prefix=app%2F&restype=container
+			// signs "GET\n...\n/myaccount/releases\ncomp:list\ndelimiter:/\nmarker:2!88!...\nprefix:app/\nrestype:container",
+			// with the query values decoded.
+			name:    "list blobs",
+			account: "myaccount",
+			method:  http.MethodGet,
+			url: "https://myaccount.blob.core.windows.net/releases?" + url.Values{
+				"restype": {"container"}, "comp": {"list"}, "prefix": {"app/"}, "delimiter": {"/"}, "marker": {marker},
+			}.Encode(),
+			want: "sctvqP3Tu8bES1mPcu8brJURiQMLYQOvUJYoQGQZ1/4=",
+		},
+		{
+			// The emulator has the account in the path too:
+			// "/devstoreaccount1/devstoreaccount1/releases/app/v1.0.0/app.tar.gz".
+			name:    "azurite get blob",
+			account: "devstoreaccount1",
+			method:  http.MethodGet,
+			url:     "http://127.0.0.1:10000/devstoreaccount1/releases/app/v1.0.0/app.tar.gz",
+			want:    "AsX3vAMjQ5xDhr62dLQ7QrBHhYtwbptbM/0ra81rEGE=",
+		},
+		{
+			name:    "put blob if absent",
+			account: "myaccount",
+			method:  http.MethodPut,
+			url:     "https://myaccount.blob.core.windows.net/releases/app/v1.0.0/app.tar.gz",
+			body:    "v1",
+			header:  map[string]string{"Content-Type": "application/octet-stream", "If-None-Match": "*", "x-ms-blob-type": "BlockBlob"},
+			want:    "Kda/SZt/3vMmv4saogIOCphdiPNGr+yGNW4+rs7wW30=",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAzureBlob(tt.account, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			req.Header.Set("x-ms-date", date)
+			req.Header.Set("x-ms-version", azureBlobAPIVersion)
+			if got := a.sign(req); got != tt.want {
+				t.Errorf("sign = %s, want %s\nstring to sign:\n%q", got, tt.want, a.stringToSign(req))
+			}
+		})
+	}
+}
+
+func TestAzureBlobOperations(t *testing.T) {
+	setAzureEnv(t, map[string]string{"AZURE_STORAGE_KEY": testAzureKey})
+	ts := newFakeBlobService(t, "devstoreaccount1")
+	a, err := NewAzureBlob("devstoreaccount1", ts.URL+"/devstoreaccount1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, _, err := a.Read(ctx, "releases", "app/v1.0.0/app.tar.gz"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Read missing: err = %v", err)
+	}
+	etag, err := a.PutIfAbsent(ctx, "releases", "app/v1.0.0/app.tar.gz", []byte("v1"))
+	if err != nil || etag == "" {
+		t.Fatalf("PutIfAbsent = %q, %v", etag, err)
+	}
+	if _, err := a.PutIfAbsent(ctx, "releases", "app/v1.0.0/app.tar.gz", []byte("v1")); !errors.Is(err, ErrBlobConditionNotMet) {
+		t.Errorf("PutIfAbsent existing: err = %v", err)
+	}
+	if _, err := a.Put(ctx, "releases", "app/v1.0.0/app.tar.gz", []byte("v1b"), `"stale"`); !errors.Is(err, ErrBlobConditionNotMet) {
+		t.Errorf("Put stale: err = %v", err)
+	}
+	if _, err := a.Put(ctx, "releases", "app/v1.0.0/app.tar.gz", []byte("v1b"), etag); err != nil {
+		t.Errorf("Put matching: %v", err)
+	}
+	data, _, err := a.Read(ctx, "releases", "app/v1.0.0/app.tar.gz")
+	if err != nil || string(data) != "v1b" {
+		t.Errorf("Read = %q, %v", data, err)
+	}
+
+	for _, n := range []string{"app/v1.1.0/app.tar.gz", "app/v1.2.0/app.tar.gz", "app/README"} {
+		if _, err := a.Put(ctx, "releases", n, nil, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blobs, prefixes, err := a.List(ctx, "releases", "app/", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 1 || blobs[0].Name != "app/README" || blobs[0].LastModified.IsZero() {
+		t.Errorf("blobs = %+v", blobs)
+	}
+	if strings.Join(prefixes, ",") != "app/v1.0.0/,app/v1.1.0/,app/v1.2.0/" {
+		t.Errorf("prefixes = %v, want all three pages", prefixes)
+	}
+
+	if err := a.Delete(ctx, "releases", "app/README"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if err := a.Delete(ctx, "releases", "app/README"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Delete missing: err = %v", err)
+	}
+
+	anonymous, _ := NewAzureBlob("devstoreaccount1", ts.URL+"/devstoreaccount1")
+	anonymous.key = nil
+	if _, _, err := anonymous.Read(ctx, "releases", "app/v1.0.0/app.tar.gz"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("unsigned Read: err = %v, want 403", err)
+	}
+}
+
+func TestAzureBlobGetTimeout(t *testing.T) {
+	setAzureEnv(t, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`<EnumerationResults></EnumerationResults>`))
+	}))
+	t.Cleanup(ts.Close)
+	a, err := NewAzureBlob("acct", ts.URL+"/acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.cl.Timeout = 50 * time.Millisecond
+	ctx := context.Background()
+
+	// A slow listing hits the API timeout, a slow download does not.
+	if _, _, err := a.List(ctx, "releases", "", ""); err == nil {
+		t.Error("List: want the API timeout")
+	}
+	if _, _, err := a.Read(ctx, "releases", "app.tar.gz"); err != nil {
+		t.Errorf("Read: %v, want no API timeout for downloads", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := a.Read(ctx, "releases", "app.tar.gz"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Read: err = %v, want the deadline of ctx", err)
+	}
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Gitea is a minimal client of the Gitea REST API (v1) covering the releases
+// endpoints dewy needs. Forgejo serves the same API.
+type Gitea struct {
+	BaseURL *url.URL
+	token   string
+	cl      *http.Client
+}
+
+// GiteaRelease is a release of a Gitea repository.
+type GiteaRelease struct {
+	ID          int64               `json:"id"`
+	TagName     string              `json:"tag_name"`
+	Name        string              `json:"name"`
+	Body        string              `json:"body"`
+	Draft       bool                `json:"draft"`
+	Prerelease  bool                `json:"prerelease"`
+	CreatedAt   *time.Time          `json:"created_at"`
+	PublishedAt *time.Time          `json:"published_at"`
+	Assets      []GiteaReleaseAsset `json:"assets"`
+}
+
+// GiteaReleaseAsset is a file attached to a release.
+type GiteaReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// giteaPageSize is the page size asked for; servers may cap it lower, so
+// pagination follows the Link header instead of counting.
+const giteaPageSize = 50
+
+// NewGitea creates a new Gitea client for the instance at host, reached
+// over scheme, "http" or "https". An empty scheme means https except on
+// localhost. The token is read from GITEA_TOKEN, then FORGEJO_TOKEN; without
+// one only public repositories can be read.
+func NewGitea(host, scheme string) (*Gitea, error) {
+	if host == "" {
+		return nil, fmt.Errorf("gitea host is required")
+	}
+	switch scheme {
+	case "":
+		scheme = "https"
+		if strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+			scheme = "http"
+		}
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("gitea scheme must be http or https: %s", scheme)
+	}
+	token := os.Getenv("GITEA_TOKEN")
+	if token == "" {
+		token = os.Getenv("FORGEJO_TOKEN")
+	}
+	return &Gitea{
+		BaseURL: &url.URL{Scheme: scheme, Host: host, Path: "/api/v1"},
+		token:   token,
+		cl:      &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// ListReleases returns all releases of owner/repo, drafts included when the
+// token may see them.
+func (g *Gitea) ListReleases(ctx context.Context, owner, repo string) ([]*GiteaRelease, error) {
+	var all []*GiteaRelease
+	for page := 1; ; page++ {
+		var releases []*GiteaRelease
+		q := url.Values{"limit": {strconv.Itoa(giteaPageSize)}, "page": {strconv.Itoa(page)}}
+		res, err := g.do(ctx, http.MethodGet, g.repoPath(owner, repo, "releases"), q, nil, "", &releases)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, releases...)
+		if len(releases) == 0 || !strings.Contains(res.Header.Get("Link"), `rel="next"`) {
+			return all, nil
+		}
+	}
+}
+
+// GetReleaseByTag returns the release of owner/repo for tag.
+func (g *Gitea) GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*GiteaRelease, error) {
+	var r GiteaRelease
+	if _, err := g.do(ctx, http.MethodGet, g.repoPath(owner, repo, "releases", "tags", tag), nil, nil, "", &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CreateReleaseAttachment uploads content as an asset named name to the
+// release with id.
+func (g *Gitea) CreateReleaseAttachment(ctx context.Context, owner, repo string, id int64, name string, content []byte) error {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("attachment", name)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(content); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	path := g.repoPath(owner, repo, "releases", strconv.FormatInt(id, 10), "assets")
+	_, err = g.do(ctx, http.MethodPost, path, url.Values{"name": {name}}, &buf, mw.FormDataContentType(), nil)
+	return err
+}
+
+// Download GETs the download URL of an asset. The token is only sent to the
+// Gitea instance itself.
+func (g *Gitea) Download(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Host == g.BaseURL.Host {
+		g.auth(req)
+	}
+	res, err := g.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", u, res.Status)
+	}
+	return res.Body, nil
+}
+
+func (g *Gitea) repoPath(owner, repo string, elem ...string) string {
+	p := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	for _, e := range elem {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+func (g *Gitea) auth(req *http.Request) {
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+}
+
+func (g *Gitea) do(ctx context.Context, method, path string, q url.Values, body io.Reader, contentType string, out any) (*http.Response, error) {
+	u := *g.BaseURL
+	u.RawPath = u.EscapedPath() + path
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	g.auth(req)
+
+	res, err := g.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("gitea %s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("gitea %s %s: %w", method, path, err)
+		}
+	}
+	return res, nil
+}
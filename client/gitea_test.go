@@ -0,0 +1,41 @@
+package client
+
+import "testing"
+
+func TestNewGitea(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		scheme      string
+		env         map[string]string
+		wantBaseURL string
+		wantToken   string
+	}{
+		{"https", "git.example.com", "", map[string]string{"GITEA_TOKEN": "gt"}, "https://git.example.com/api/v1", "gt"},
+		{"localhost", "localhost:3000", "", map[string]string{"FORGEJO_TOKEN": "ft"}, "http://localhost:3000/api/v1", "ft"},
+		{"gitea token wins", "127.0.0.1:3000", "", map[string]string{"GITEA_TOKEN": "gt", "FORGEJO_TOKEN": "ft"}, "http://127.0.0.1:3000/api/v1", "gt"},
+		{"http scheme", "gitea.internal:3000", "http", nil, "http://gitea.internal:3000/api/v1", ""},
+		{"https on localhost", "localhost:3443", "https", nil, "https://localhost:3443/api/v1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"GITEA_TOKEN", "FORGEJO_TOKEN"} {
+				t.Setenv(k, tt.env[k])
+			}
+			g, err := NewGitea(tt.host, tt.scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.BaseURL.String() != tt.wantBaseURL || g.token != tt.wantToken {
+				t.Errorf("got %s %q, want %s %q", g.BaseURL, g.token, tt.wantBaseURL, tt.wantToken)
+			}
+		})
+	}
+
+	if _, err := NewGitea("", ""); err == nil {
+		t.Error("expected an empty host to be rejected")
+	}
+	if _, err := NewGitea("git.example.com", "ftp"); err == nil {
+		t.Error("expected a scheme other than http or https to be rejected")
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+// GitLab is a minimal client of the GitLab REST API (v4) covering the
+// releases and deployments endpoints dewy needs.
+type GitLab struct {
+	BaseURL     *url.URL
+	token       string
+	tokenHeader string
+	cl          *http.Client
+}
+
+// GitLabRelease is a release of a GitLab project.
+type GitLabRelease struct {
+	TagName         string     `json:"tag_name"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	CreatedAt       *time.Time `json:"created_at"`
+	ReleasedAt      *time.Time `json:"released_at"`
+	UpcomingRelease bool       `json:"upcoming_release"`
+	Commit          struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Assets struct {
+		Links []GitLabReleaseLink `json:"links"`
+	} `json:"assets"`
+}
+
+// GitLabReleaseLink is an asset link of a release.
+type GitLabReleaseLink struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+}
+
+// GitLabDeployment is a deployment of a project to an environment.
+type GitLabDeployment struct {
+	Environment string `json:"environment"`
+	Ref         string `json:"ref"`
+	Tag         bool   `json:"tag"`
+	SHA         string `json:"sha"`
+	// Status is one of running, success, failed or canceled.
+	Status string `json:"status"`
+}
+
+// NewGitLab creates a new GitLab client for the API at apiURL, such as
+// https://git.example.com/api/v4; an instance URL without a path gets
+// /api/v4 appended. When apiURL is empty it is taken from GITLAB_API_URL,
+// then CI_API_V4_URL (set in GitLab CI jobs), defaulting to gitlab.com.
+// Authentication priority:
+//  1. Personal, project or group access token (GITLAB_TOKEN)
+//  2. CI job token (CI_JOB_TOKEN)
+//
+// Without a token only public projects can be read.
+func NewGitLab(apiURL string) (*GitLab, error) {
+	if apiURL == "" {
+		apiURL = os.Getenv("GITLAB_API_URL")
+	}
+	if apiURL == "" {
+		apiURL = os.Getenv("CI_API_V4_URL")
+	}
+	if apiURL == "" {
+		apiURL = defaultGitLabAPIURL
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(apiURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL: %w", err)
+	}
+	if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid API URL %q: must be an http or https URL", apiURL)
+	}
+	if baseURL.Path == "" {
+		baseURL.Path = "/api/v4"
+	}
+
+	g := &GitLab{BaseURL: baseURL}
+	g.cl = &http.Client{Timeout: 60 * time.Second, CheckRedirect: g.checkRedirect}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		g.token, g.tokenHeader = token, "PRIVATE-TOKEN"
+	} else if token := os.Getenv("CI_JOB_TOKEN"); token != "" {
+		g.token, g.tokenHeader = token, "JOB-TOKEN"
+	}
+	return g, nil
+}
+
+// checkRedirect drops the token when a redirect leaves the GitLab instance,
+// as asset links usually do. net/http only does so for Authorization.
+func (g *GitLab) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Host != g.BaseURL.Host && g.tokenHeader != "" {
+		req.Header.Del(g.tokenHeader)
+	}
+	return nil
+}
+
+// Host returns the host name of the GitLab instance.
+func (g *GitLab) Host() string {
+	return g.BaseURL.Host
+}
+
+// ListReleases returns all releases of project, a path such as
+// "group/subgroup/project" or a numeric ID.
+func (g *GitLab) ListReleases(ctx context.Context, project string) ([]*GitLabRelease, error) {
+	var all []*GitLabRelease
+	page := "1"
+	for page != "" {
+		var releases []*GitLabRelease
+		q := url.Values{"per_page": {"100"}, "page": {page}}
+		res, err := g.do(ctx, http.MethodGet, g.projectPath(project, "releases"), q, nil, &releases)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, releases...)
+		page = res.Header.Get("X-Next-Page")
+	}
+	return all, nil
+}
+
+// GetRelease returns the release of project for tag.
+func (g *GitLab) GetRelease(ctx context.Context, project, tag string) (*GitLabRelease, error) {
+	var r GitLabRelease
+	if _, err := g.do(ctx, http.MethodGet, g.projectPath(project, "releases", tag), nil, nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CreateDeployment records a deployment of project. Environments that do
+// not exist yet are created by GitLab.
+func (g *GitLab) CreateDeployment(ctx context.Context, project string, d *GitLabDeployment) error {
+	_, err := g.do(ctx, http.MethodPost, g.projectPath(project, "deployments"), nil, d, nil)
+	return err
+}
+
+// Download GETs an asset link. The token is only sent to the GitLab
+// instance itself, never to external hosts a link may point to.
+func (g *GitLab) Download(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Host == g.BaseURL.Host {
+		g.auth(req)
+	}
+	res, err := g.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", u, res.Status)
+	}
+	return res.Body, nil
+}
+
+// projectPath returns the escaped API path of a project resource. Project
+// paths keep their slashes encoded, as the API requires.
+func (g *GitLab) projectPath(project string, elem ...string) string {
+	p := "/projects/" + url.PathEscape(project)
+	for _, e := range elem {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+func (g *GitLab) auth(req *http.Request) {
+	if g.token != "" {
+		req.Header.Set(g.tokenHeader, g.token)
+	}
+}
+
+func (g *GitLab) do(ctx context.Context, method, path string, q url.Values, in, out any) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	u := *g.BaseURL
+	u.RawPath = u.EscapedPath() + path
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	g.auth(req)
+
+	res, err := g.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("gitlab %s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("gitlab %s %s: %w", method, path, err)
+		}
+	}
+	return res, nil
+}
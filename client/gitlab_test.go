@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewGitLab(t *testing.T) {
+	tests := []struct {
+		name        string
+		apiURL      string
+		env         map[string]string
+		wantBaseURL string
+		wantHeader  string
+	}{
+		{"defaults", "", nil, "https://gitlab.com/api/v4", ""},
+		{"self-hosted", "", map[string]string{"GITLAB_API_URL": "https://git.example.com/api/v4/"}, "https://git.example.com/api/v4", ""},
+		{"ci", "", map[string]string{"CI_API_V4_URL": "https://ci.example.com/api/v4", "CI_JOB_TOKEN": "job"}, "https://ci.example.com/api/v4", "JOB-TOKEN"},
+		{"token wins", "", map[string]string{"GITLAB_TOKEN": "glpat", "CI_JOB_TOKEN": "job"}, "https://gitlab.com/api/v4", "PRIVATE-TOKEN"},
+		{"given wins", "https://git.example.com/api/v4", map[string]string{"GITLAB_API_URL": "https://other.example.com/api/v4"}, "https://git.example.com/api/v4", ""},
+		{"instance", "http://git.example.com:8080", nil, "http://git.example.com:8080/api/v4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"GITLAB_API_URL", "CI_API_V4_URL", "GITLAB_TOKEN", "CI_JOB_TOKEN"} {
+				t.Setenv(k, tt.env[k])
+			}
+			g, err := NewGitLab(tt.apiURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.BaseURL.String() != tt.wantBaseURL || g.tokenHeader != tt.wantHeader {
+				t.Errorf("got %s %q, want %s %q", g.BaseURL, g.tokenHeader, tt.wantBaseURL, tt.wantHeader)
+			}
+		})
+	}
+
+	if _, err := NewGitLab("git.example.com"); err == nil {
+		t.Error("want an error for an API URL without a scheme")
+	}
+}
+
+func TestGitLabListReleases(t *testing.T) {
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("PRIVATE-TOKEN"))
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fapp/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			_, _ = w.Write([]byte(`[{"tag_name": "v1.1.0"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"tag_name": "v1.0.0"}]`))
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("GITLAB_API_URL", ts.URL+"/api/v4")
+	t.Setenv("GITLAB_TOKEN", "glpat")
+
+	g, err := NewGitLab("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releases, err := g.ListReleases(context.Background(), "group/sub/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 2 || releases[0].TagName != "v1.1.0" || releases[1].TagName != "v1.0.0" {
+		t.Errorf("releases = %+v", releases)
+	}
+	if len(tokens) != 2 || tokens[0] != "glpat" || tokens[1] != "glpat" {
+		t.Errorf("tokens = %v", tokens)
+	}
+
+	if _, err := g.ListReleases(context.Background(), "group/missing"); err == nil {
+		t.Error("expected an error for a missing project")
+	}
+}
+
+func TestGitLabDownload_TokenStaysOnInstance(t *testing.T) {
+	var leaked string
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("PRIVATE-TOKEN")
+		_, _ = w.Write([]byte("artifact"))
+	}))
+	t.Cleanup(external.Close)
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, external.URL+"/app.tar.gz", http.StatusFound)
+	}))
+	t.Cleanup(instance.Close)
+	t.Setenv("GITLAB_API_URL", instance.URL+"/api/v4")
+	t.Setenv("GITLAB_TOKEN", "glpat")
+
+	g, err := NewGitLab("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := g.Download(context.Background(), instance.URL+"/group/app/-/releases/v1.0.0/downloads/app.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, _ := io.ReadAll(rc)
+	if string(b) != "artifact" {
+		t.Errorf("got %q", b)
+	}
+	if leaked != "" {
+		t.Errorf("token sent to %s", external.URL)
+	}
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"net/http"
+	"os"
+)
+
+// HTTPAuthFragment marks an http(s) artifact URL whose host may receive the
+// credentials of the http(s) registry. The registry sets it on artifacts
+// served from the host of its index, so a third-party URL in the index does
+// not get them. The fragment is never sent to the server.
+const HTTPAuthFragment = "dewy-auth"
+
+// HasHTTPAuth reports whether credentials for the http(s) registry and
+// artifact backends are configured.
+func HasHTTPAuth() bool {
+	return os.Getenv("DEWY_HTTP_USERNAME") != "" || os.Getenv("DEWY_HTTP_TOKEN") != ""
+}
+
+// SetHTTPAuth adds the credentials of the http(s) registry and artifact
+// backends to req. Callers only use it for hosts the user pointed dewy at.
+// Basic auth from DEWY_HTTP_USERNAME and DEWY_HTTP_PASSWORD takes precedence
+// over a bearer token from DEWY_HTTP_TOKEN.
+func SetHTTPAuth(req *http.Request) {
+	if user := os.Getenv("DEWY_HTTP_USERNAME"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("DEWY_HTTP_PASSWORD"))
+		return
+	}
+	if token := os.Getenv("DEWY_HTTP_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
@@ -61,7 +61,8 @@ type CacheConfig struct {
 	Expiration int
 	// URL selects a cache backend by scheme.
 	// Examples: "" (default file), "file:///path/to/cache",
-	// "s3://<region>/<bucket>/<prefix>", "gs://<bucket>/<prefix>".
+	// "s3://<region>/<bucket>/<prefix>", "gs://<bucket>/<prefix>",
+	// "azblob://<account>/<container>/<prefix>".
 	URL string
 }
 
@@ -81,18 +82,35 @@ type ContainerConfig struct {
 
 // Config struct.
 type Config struct {
-	Command          Command
-	Registry         string
-	Notifier         string
-	Port             int // Port for HTTP server (used by both server and container commands)
-	AdminPort        int // Port for admin API (container command only, default: 17539)
-	Cache            CacheConfig
-	Starter          starter.Config
-	Container        *ContainerConfig
-	BeforeDeployHook string
-	AfterDeployHook  string
-	Slot             string // Deployment slot for blue/green deployment (e.g., "blue", "green")
-	CalVer           string // CalVer format for version identification (e.g., "YYYY.0M.MICRO")
+	Command            Command
+	Registry           string
+	Notifier           string
+	Port               int              // Port for HTTP server (used by both server and container commands)
+	AdminPort          int              // Port for admin API (container command only, default: 17539)
+	AdminAuth          *AdminAuthConfig // Admin API token and TLS settings (nil = open on localhost)
+	AdminSocket        string           // Unix socket path for the admin API, or "auto" ("" = TCP only)
+	Cache              CacheConfig
+	Starter            starter.Config
+	Container          *ContainerConfig
+	BeforeDeployHook   string
+	AfterDeployHook    string
+	PreDownloadHook    string             // Runs before the artifact is fetched (or the image pulled)
+	PostExtractHook    string             // Runs once the release is extracted, before current moves (server/assets only)
+	BeforeRestartHook  string             // Runs before the server is (re)started (server only)
+	AfterRestartHook   string             // Runs after the server is (re)started (server only)
+	OnFailureHook      string             // Runs when a deploy fails, with DEWY_ERROR set
+	OnRollbackHook     string             // Runs after dewy restores the previous release (server/assets only)
+	BlockingHooks      []string           // Hook stages (e.g. "before-deploy") whose non-zero exit aborts the deploy; others are advisory
+	HookTimeout        time.Duration      // Per-hook timeout (0 = defaultHookTimeout)
+	Webhooks           []Webhook          // HTTP hooks, run after the shell hook of the same stage
+	TemplateDir        string             // Directory of Go templates rendered into each new release (server/assets only)
+	EnvFile            string             // Env file given to the managed server process only (server only)
+	HealthCheck        *HealthCheckConfig // Readiness probe after a server (re)start (server only; nil = disabled)
+	CaptureLogs        bool               // Capture app output into logs/ under the root and serve it on /api/logs (server/container)
+	CrashLoopThreshold int                // Crashes within CrashLoopWindow that count as a crash loop (0 = default)
+	CrashLoopWindow    time.Duration      // Window for crash-loop detection (0 = default)
+	Slot               string             // Deployment slot for blue/green deployment (e.g., "blue", "green")
+	CalVer             string             // CalVer format for version identification (e.g., "YYYY.0M.MICRO")
 	*Info
 }
 
@@ -75,17 +75,32 @@ func (r *Runtime) Deploy(ctx context.Context, opts RollingDeployOptions, updater
 			slog.Int("port_mappings", len(result.MappedPorts)))
 	}
 
-	// Remove old containers one by one
-	removedCount := 0
-	for i, oldContainerID := range existingContainers {
-		r.logger.Info("Removing old container",
-			slog.Int("index", i+1),
-			slog.Int("total", len(existingContainers)),
-			slog.String("container", oldContainerID))
+	// Remove old containers after they drained
+	removedCount := r.retire(ctx, existingContainers, opts.PortMappings, updater)
+
+	r.logger.Info("Container deployment completed",
+		slog.Int("new_containers", len(results)),
+		slog.Int("removed_containers", removedCount))
+
+	return &DeployReport{
+		Results:      results,
+		RemovedCount: removedCount,
+	}, nil
+}
 
-		// Remove from proxy backends
-		for _, mapping := range opts.PortMappings {
-			oldPort, err := r.GetMappedPort(ctx, oldContainerID, mapping.ContainerPort)
+// retire takes old containers out of the proxy backends, waits the drain
+// time so that requests they are serving can finish, then stops and removes
+// them. Failures are logged: a leftover container must not fail the deploy
+// that replaced it. It returns the number of containers retired.
+func (r *Runtime) retire(ctx context.Context, containerIDs []string, mappings []PortMapping, updater BackendUpdater) int {
+	if len(containerIDs) == 0 {
+		return 0
+	}
+
+	// Remove from proxy backends
+	for _, containerID := range containerIDs {
+		for _, mapping := range mappings {
+			oldPort, err := r.GetMappedPort(ctx, containerID, mapping.ContainerPort)
 			if err == nil {
 				if err := updater.RemoveBackend("localhost", oldPort, mapping.ProxyPort); err != nil {
 					r.logger.Warn("Failed to remove old backend from proxy",
@@ -95,28 +110,111 @@ func (r *Runtime) Deploy(ctx context.Context, opts RollingDeployOptions, updater
 				}
 			}
 		}
+	}
+
+	if r.drainTime > 0 {
+		r.logger.Info("Draining old containers",
+			slog.Int("count", len(containerIDs)),
+			slog.Duration("drain_time", r.drainTime))
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.drainTime):
+		}
+	}
 
-		// Stop and remove old container
-		if err := r.Stop(ctx, oldContainerID, defaultStopTimeoutOld); err != nil {
+	// Stop and remove old containers one by one
+	for i, containerID := range containerIDs {
+		r.logger.Info("Removing old container",
+			slog.Int("index", i+1),
+			slog.Int("total", len(containerIDs)),
+			slog.String("container", containerID))
+
+		if err := r.Stop(ctx, containerID, defaultStopTimeoutOld); err != nil {
 			r.logger.Error("Failed to stop old container",
-				slog.String("container", oldContainerID),
+				slog.String("container", containerID),
 				slog.String("error", err.Error()))
 		}
-		if err := r.Remove(ctx, oldContainerID); err != nil {
+		if err := r.Remove(ctx, containerID); err != nil {
 			r.logger.Error("Failed to remove old container",
-				slog.String("container", oldContainerID),
+				slog.String("container", containerID),
 				slog.String("error", err.Error()))
 		}
-		removedCount++
 	}
+	return len(containerIDs)
+}
 
-	r.logger.Info("Container deployment completed",
+// Scale changes the number of running replicas to opts.Replicas without
+// touching the replicas that stay. Missing replica indexes below the target
+// are started from opts.ImageRef and health-checked like a deploy; replicas
+// at or above the target are taken out of the proxy and removed. If a new
+// replica fails, the ones started by this call are rolled back.
+func (r *Runtime) Scale(ctx context.Context, opts RollingDeployOptions, updater BackendUpdater) (*DeployReport, error) {
+	if updater == nil {
+		updater = noopBackendUpdater{}
+	}
+	replicas := opts.Replicas
+	if replicas <= 0 {
+		replicas = 1
+	}
+
+	statuses, err := r.InspectManaged(ctx, opts.AppName)
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[int]bool)
+	var extra []string
+	for _, st := range statuses {
+		if st.State != "running" {
+			continue
+		}
+		n, err := strconv.Atoi(st.Replica)
+		if err != nil {
+			// Containers without a replica label cannot be placed; leave
+			// them to the next deploy.
+			continue
+		}
+		if n >= replicas {
+			extra = append(extra, st.ID)
+			continue
+		}
+		running[n] = true
+	}
+
+	r.logger.Info("Scaling containers",
+		slog.Int("replicas", replicas),
+		slog.Int("running", len(running)+len(extra)))
+
+	results := make([]DeployResult, 0, replicas)
+	for i := range replicas {
+		if running[i] {
+			continue
+		}
+		result, err := r.startAndCheck(ctx, opts, i)
+		if err != nil {
+			r.logger.Error("Failed to start container, rolling back",
+				slog.Int("replica", i),
+				slog.String("error", err.Error()))
+			r.rollback(ctx, results, updater)
+			return nil, err
+		}
+		for proxyPort, mappedPort := range result.MappedPorts {
+			if err := updater.AddBackend("localhost", mappedPort, proxyPort); err != nil {
+				r.rollback(ctx, append(results, result), updater)
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	r.retire(ctx, extra, opts.PortMappings, updater)
+
+	r.logger.Info("Container scaling completed",
 		slog.Int("new_containers", len(results)),
-		slog.Int("removed_containers", removedCount))
+		slog.Int("removed_containers", len(extra)))
 
 	return &DeployReport{
 		Results:      results,
-		RemovedCount: removedCount,
+		RemovedCount: len(extra),
 	}, nil
 }
 
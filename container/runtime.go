@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -298,3 +299,19 @@ func (r *Runtime) Remove(ctx context.Context, containerID string) error {
 	r.logger.Info("Removing container", slog.String("container", containerID))
 	return r.execCommand(ctx, "rm", containerID)
 }
+
+// Logs follows the output of a container from since (all of it when zero),
+// writing stdout and stderr to w until the container stops or ctx is
+// canceled.
+func (r *Runtime) Logs(ctx context.Context, containerID string, since time.Time, w io.Writer) error {
+	args := []string{"logs", "--follow"}
+	if !since.IsZero() {
+		args = append(args, "--since", since.UTC().Format(time.RFC3339Nano))
+	}
+	args = append(args, containerID)
+
+	r.logger.Debug("Following container logs",
+		slog.String("container", containerID),
+		slog.Time("since", since))
+	return r.runner.Stream(ctx, w, w, r.cmd, args...)
+}
@@ -1,9 +1,11 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -277,3 +279,89 @@ func contains(s []string, v string) bool {
 	}
 	return false
 }
+
+func TestLogsStreamsThroughRunner(t *testing.T) {
+	rt, runner := newFakeRuntime(t)
+	runner.SetOutput("docker", []byte("listening on :8080\n"))
+
+	var buf bytes.Buffer
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := rt.Logs(context.Background(), "abc123", since, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "listening on :8080\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+	calls := runner.Calls()
+	want := []string{"logs", "--follow", "--since", "2026-01-02T03:04:05Z", "abc123"}
+	if len(calls) != 1 || !slices.Equal(calls[0].Args, want) {
+		t.Errorf("calls = %+v, want docker %v", calls, want)
+	}
+}
+
+func TestScale(t *testing.T) {
+	// Replica 0 runs, replica 1 exited and replica 2 runs above the target.
+	const inspect = `[
+  {"Id": "r0", "State": {"Status": "running"}, "Config": {"Image": "app:v1", "Labels": {"dewy.replica": "0", "dewy.version": "v1"}}},
+  {"Id": "r1", "State": {"Status": "exited"}, "Config": {"Image": "app:v1", "Labels": {"dewy.replica": "1", "dewy.version": "v1"}}},
+  {"Id": "r2", "State": {"Status": "running"}, "Config": {"Image": "app:v1", "Labels": {"dewy.replica": "2", "dewy.version": "v1"}}}
+]`
+	rt, runner := newFakeRuntime(t)
+	rt.drainTime = 100 * time.Millisecond
+	var stoppedAt time.Time
+	runner.SetOutputFunc("docker", func(args []string) ([]byte, error) {
+		switch args[0] {
+		case "ps":
+			return []byte("r0\nr1\nr2\n"), nil
+		case "inspect":
+			return []byte(inspect), nil
+		case "run":
+			return []byte("new1\n"), nil
+		case "port":
+			return []byte("127.0.0.1:32768\n"), nil
+		case "stop":
+			stoppedAt = time.Now()
+		}
+		return nil, nil
+	})
+
+	updater := &mockBackendUpdater{}
+	start := time.Now()
+	report, err := rt.Scale(context.Background(), RollingDeployOptions{
+		ImageRef:     "app:v1",
+		AppName:      "app",
+		Version:      "v1",
+		Replicas:     2,
+		PortMappings: []PortMapping{{ProxyPort: 8080, ContainerPort: 80}},
+	}, updater)
+	if err != nil {
+		t.Fatalf("Scale: %v", err)
+	}
+	// The removed replica leaves the proxy first and is only stopped after
+	// the drain time.
+	if len(updater.removes) != 1 || updater.removes[0].MappedPort != 32768 {
+		t.Errorf("removed backends %+v, want the replica's port 32768", updater.removes)
+	}
+	if stoppedAt.Sub(start) < rt.drainTime {
+		t.Errorf("stopped %s after the scale began, want after the %s drain", stoppedAt.Sub(start), rt.drainTime)
+	}
+	if len(report.Results) != 1 || report.Results[0].ReplicaIndex != 1 {
+		t.Errorf("started %+v, want only replica 1", report.Results)
+	}
+	if report.RemovedCount != 1 {
+		t.Errorf("removed %d, want 1", report.RemovedCount)
+	}
+
+	var stopped []string
+	for _, c := range runner.Calls() {
+		if len(c.Args) > 0 && c.Args[0] == "rm" {
+			stopped = append(stopped, c.Args[len(c.Args)-1])
+		}
+		if len(c.Args) > 0 && c.Args[0] == "run" && !contains(c.Args, "dewy.replica=1") {
+			t.Errorf("run args %v, want replica 1 label", c.Args)
+		}
+	}
+	if !slices.Equal(stopped, []string{"r2"}) {
+		t.Errorf("removed containers %v, want [r2]", stopped)
+	}
+}
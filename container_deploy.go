@@ -25,34 +25,18 @@ func (d *Dewy) deployContainer(ctx context.Context, res *registry.CurrentRespons
 		return 0, fmt.Errorf("container runtime is nil")
 	}
 
-	// Extract image reference from artifact URL
-	// Format: img://registry/repo:tag
-	imageRef := strings.TrimPrefix(res.ArtifactURL, "img://")
-	appName := d.appName()
-
-	// Resolve port mappings (auto-detect ContainerPort==0 from image EXPOSE).
-	resolvedMappings, err := runtime.ResolvePortMappings(ctx, imageRef, d.config.Container.PortMappings)
+	opts, err := d.rolloutOptions(ctx, res, runtime)
 	if err != nil {
-		return 0, fmt.Errorf("failed to resolve port mappings: %w", err)
+		return 0, err
 	}
-
-	// Create health check function (telemetry-aware, stays in dewy package)
-	healthCheck := d.createHealthCheckFunc(runtime, resolvedMappings)
+	appName := opts.AppName
 
 	// Deploy via container runtime, with the dewy proxy as the BackendUpdater.
-	report, err := runtime.Deploy(ctx, container.RollingDeployOptions{
-		ImageRef:     imageRef,
-		AppName:      appName,
-		Version:      res.Tag,
-		Replicas:     d.config.Container.Replicas,
-		PortMappings: resolvedMappings,
-		Command:      d.config.Container.Command,
-		ExtraArgs:    d.config.Container.ExtraArgs,
-		HealthCheck:  healthCheck,
-	}, (*proxyBackendUpdater)(d))
+	report, err := runtime.Deploy(ctx, opts, (*proxyBackendUpdater)(d))
 	if err != nil {
 		return 0, err
 	}
+	d.emitReplicaChanges(ctx, res.Tag, report)
 
 	// Reap containers that crashed on their own since the last deploy. The
 	// rolling update above only removes the running containers it replaced;
@@ -71,6 +55,42 @@ func (d *Dewy) deployContainer(ctx context.Context, res *registry.CurrentRespons
 	return len(report.Results), nil
 }
 
+// emitReplicaChanges publishes the replicas a rollout started and removed.
+func (d *Dewy) emitReplicaChanges(ctx context.Context, tag string, report *container.DeployReport) {
+	for _, r := range report.Results {
+		d.publish(ctx, Event{Type: EventReplicaAdded, Tag: tag, Replica: r.ReplicaIndex, Replicas: 1})
+	}
+	if report.RemovedCount > 0 {
+		d.publish(ctx, Event{Type: EventReplicaRemoved, Tag: tag, Replicas: report.RemovedCount})
+	}
+}
+
+// rolloutOptions builds the options for starting replicas of res: the image
+// from its artifact URL, resolved port mappings and the health check.
+func (d *Dewy) rolloutOptions(ctx context.Context, res *registry.CurrentResponse, runtime *container.Runtime) (container.RollingDeployOptions, error) {
+	// Extract image reference from artifact URL
+	// Format: img://registry/repo:tag
+	imageRef := strings.TrimPrefix(res.ArtifactURL, "img://")
+
+	// Resolve port mappings (auto-detect ContainerPort==0 from image EXPOSE).
+	resolvedMappings, err := runtime.ResolvePortMappings(ctx, imageRef, d.config.Container.PortMappings)
+	if err != nil {
+		return container.RollingDeployOptions{}, fmt.Errorf("failed to resolve port mappings: %w", err)
+	}
+
+	return container.RollingDeployOptions{
+		ImageRef:     imageRef,
+		AppName:      d.appName(),
+		Version:      res.Tag,
+		Replicas:     d.config.Container.Replicas,
+		PortMappings: resolvedMappings,
+		Command:      d.config.Container.Command,
+		ExtraArgs:    d.config.Container.ExtraArgs,
+		// Create health check function (telemetry-aware, stays in dewy package)
+		HealthCheck: d.createHealthCheckFunc(runtime, resolvedMappings),
+	}, nil
+}
+
 // createHealthCheckFunc creates a health check function based on configuration.
 // Health check is performed on the first port mapping.
 func (d *Dewy) createHealthCheckFunc(rt *container.Runtime, resolvedMappings []container.PortMapping) container.HealthCheckFunc {
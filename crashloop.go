@@ -0,0 +1,150 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"time"
+)
+
+// crashState tracks exits of the managed server so that dewy backs off
+// restarting it and can tell a crash loop from a one-off failure. It is
+// guarded by the Dewy mutex.
+//
+// A crash here is the current worker of the supervisor exiting, whatever its
+// exit status, or a new worker exiting before it counts as started. Workers
+// replaced by a restart or stopped on shutdown are not crashes.
+type crashState struct {
+	startedAt   time.Time   // when the server was last started
+	recent      []time.Time // crashes within the crash-loop window
+	consecutive int         // crashes since the server last stayed up
+	lastExit    int         // exit code of the last crash (-1 if unknown)
+	lastError   string
+	lastAt      time.Time
+	nextStart   time.Time // restarts are held off until then
+	looping     bool      // crash-loop notification sent for this streak
+}
+
+// restartBackoff returns the delay before restarting after the nth
+// consecutive crash: the base delay doubling per crash, capped.
+func restartBackoff(n int) time.Duration {
+	backoff := defaultRestartBackoff
+	for i := 1; i < n && backoff < defaultRestartBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, defaultRestartBackoffMax)
+}
+
+// exitCode extracts the process exit code from err, or -1 when err does not
+// carry one.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func (d *Dewy) crashLoopThreshold() int {
+	if d.config.CrashLoopThreshold > 0 {
+		return d.config.CrashLoopThreshold
+	}
+	return defaultCrashLoopThreshold
+}
+
+func (d *Dewy) crashLoopWindow() time.Duration {
+	if d.config.CrashLoopWindow > 0 {
+		return d.config.CrashLoopWindow
+	}
+	return defaultCrashLoopWindow
+}
+
+// serverStarted notes a (re)start of the managed server. The caller must
+// hold the Dewy lock.
+func (d *Dewy) serverStarted() {
+	d.crashes.startedAt = time.Now()
+}
+
+// serverCrashed records that the managed server exited with err: it marks the
+// server as not running, schedules the next restart with exponential
+// backoff, and publishes EventCrashed, plus EventCrashLoop once the number
+// of crashes within the window reaches the crash-loop threshold.
+func (d *Dewy) serverCrashed(ctx context.Context, err error) {
+	now := time.Now()
+	code := exitCode(err)
+	window := d.crashLoopWindow()
+	threshold := d.crashLoopThreshold()
+
+	d.Lock()
+	d.isServerRunning = false
+	c := &d.crashes
+	// A server that stayed up past the backoff cap ends the previous streak.
+	if now.Sub(c.startedAt) > defaultRestartBackoffMax {
+		c.consecutive = 0
+		c.looping = false
+	}
+	c.consecutive++
+	c.lastExit = code
+	c.lastError = err.Error()
+	c.lastAt = now
+	c.recent = append(c.recent, now)
+	for len(c.recent) > 0 && now.Sub(c.recent[0]) > window {
+		c.recent = c.recent[1:]
+	}
+	backoff := restartBackoff(c.consecutive)
+	c.nextStart = now.Add(backoff)
+	consecutive := c.consecutive
+	inWindow := len(c.recent)
+	notify := inWindow >= threshold && !c.looping
+	if notify {
+		c.looping = true
+	}
+	version := d.cVer
+	d.Unlock()
+
+	d.logger.Error("Server crashed",
+		slog.String("error", err.Error()),
+		slog.Int("exit_code", code),
+		slog.Int("consecutive", consecutive),
+		slog.Int("crashes_in_window", inWindow),
+		slog.Duration("backoff", backoff))
+	d.publish(ctx, Event{Type: EventCrashed, Tag: version, ExitCode: code, Err: err})
+
+	if notify {
+		d.publish(ctx, Event{Type: EventCrashLoop, Tag: version, ExitCode: code, Crashes: inWindow, Window: window})
+	}
+}
+
+// restartHeldOff reports how long the next restart of a crashed server must
+// still wait. The caller must hold the Dewy lock (read is enough).
+func (d *Dewy) restartHeldOff() time.Duration {
+	return max(time.Until(d.crashes.nextStart), 0)
+}
+
+// crashLoopStatus summarizes the crash state for /api/status. The caller
+// must hold the Dewy lock (read is enough).
+func (d *Dewy) crashLoopStatus() map[string]any {
+	c := d.crashes
+	window := d.crashLoopWindow()
+	inWindow := 0
+	for _, t := range c.recent {
+		if time.Since(t) <= window {
+			inWindow++
+		}
+	}
+	status := map[string]any{
+		"crash_loop":          inWindow >= d.crashLoopThreshold(),
+		"crashes_in_window":   inWindow,
+		"consecutive_crashes": c.consecutive,
+	}
+	if !c.lastAt.IsZero() {
+		status["last_crash_at"] = c.lastAt
+		status["last_exit_code"] = c.lastExit
+		status["last_error"] = c.lastError
+	}
+	if !d.isServerRunning && time.Now().Before(c.nextStart) {
+		status["next_restart_at"] = c.nextStart
+	}
+	return status
+}
@@ -0,0 +1,212 @@
+package dewy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, defaultRestartBackoff},
+		{2, 2 * defaultRestartBackoff},
+		{3, 4 * defaultRestartBackoff},
+		{100, defaultRestartBackoffMax},
+	}
+	for _, tt := range tests {
+		if got := restartBackoff(tt.n); got != tt.want {
+			t.Errorf("restartBackoff(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	if got := exitCode(err); got != 3 {
+		t.Errorf("exitCode = %d, want 3", got)
+	}
+	if got := exitCode(errors.New("listen tcp4 :80: bind: permission denied")); got != -1 {
+		t.Errorf("exitCode = %d, want -1", got)
+	}
+}
+
+func TestServerCrashed_CrashLoop(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+	d.config.CrashLoopThreshold = 3
+	notify := &mockNotify{}
+	d.notifier = notify
+	d.cVer = "v1.0.0"
+
+	for i := range 4 {
+		d.Lock()
+		d.isServerRunning = true
+		d.serverStarted()
+		d.Unlock()
+		d.serverCrashed(context.Background(), errors.New("boom"))
+
+		d.RLock()
+		running := d.isServerRunning
+		holdoff := d.restartHeldOff()
+		d.RUnlock()
+		if running {
+			t.Fatal("server still marked running after crash")
+		}
+		if want := restartBackoff(i + 1); holdoff <= 0 || holdoff > want {
+			t.Errorf("crash %d: holdoff = %s, want (0, %s]", i+1, holdoff, want)
+		}
+	}
+
+	var loops int
+	for _, m := range notify.GetMessages() {
+		if strings.Contains(m, "crash looping") {
+			loops++
+		}
+	}
+	if loops != 1 {
+		t.Errorf("crash loop notified %d times, want once per streak: %v", loops, notify.GetMessages())
+	}
+
+	d.RLock()
+	status := d.crashLoopStatus()
+	d.RUnlock()
+	if status["crash_loop"] != true || status["consecutive_crashes"] != 4 || status["last_exit_code"] != -1 {
+		t.Errorf("status = %v", status)
+	}
+	if _, ok := status["next_restart_at"]; !ok {
+		t.Error("status lacks next_restart_at during backoff")
+	}
+}
+
+func TestStartServer_WorkerExitIsCrash(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+	d.config.Starter = StarterConfig{
+		command: "sh",
+		args:    []string{"-c", "sleep 1.5; exit 3"},
+		ports:   []string{"127.0.0.1:0"},
+	}
+	d.notifier = &mockNotify{}
+	t.Cleanup(func() { d.supervisor.stop(time.Second) })
+
+	if err := d.startServer(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.RLock()
+		running, lastExit := d.isServerRunning, d.crashes.lastExit
+		d.RUnlock()
+		if !running {
+			if lastExit != 3 {
+				t.Errorf("last exit code = %d, want 3", lastExit)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker exit was not recorded as a crash")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestResolveCacheState_CrashBackoffSkips(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+	res := &registry.CurrentResponse{Tag: "v1.0.0", ArtifactURL: "ghr://linyows/dewy/tag/v1.0.0/app.tar.gz"}
+	key := d.cachekeyName(res)
+	if err := d.cache.Write(key, []byte("artifact")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.cache.Write(currentkeyName, []byte(key)); err != nil {
+		t.Fatal(err)
+	}
+	d.crashes.nextStart = time.Now().Add(time.Minute)
+
+	st, err := d.resolveCacheState(context.Background(), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.skip {
+		t.Error("restart of a crashed server should wait for the backoff")
+	}
+
+	d.crashes.nextStart = time.Now().Add(-time.Second)
+	st, err = d.resolveCacheState(context.Background(), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.skip {
+		t.Error("restart should proceed once the backoff has elapsed")
+	}
+}
+
+func TestHandleGetStatus_Crashes(t *testing.T) {
+	d := newAdminTestDewy(t)
+	d.config.Command = SERVER
+	d.crashes.consecutive = 2
+	d.crashes.recent = []time.Time{time.Now(), time.Now()}
+	d.crashes.lastAt = time.Now()
+	d.crashes.lastExit = 137
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	w := httptest.NewRecorder()
+	d.handleGetStatus(w, req)
+
+	var body struct {
+		Crashes struct {
+			CrashLoop          bool `json:"crash_loop"`
+			ConsecutiveCrashes int  `json:"consecutive_crashes"`
+			LastExitCode       int  `json:"last_exit_code"`
+		} `json:"crashes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Crashes.CrashLoop || body.Crashes.ConsecutiveCrashes != 2 || body.Crashes.LastExitCode != 137 {
+		t.Errorf("crashes = %+v", body.Crashes)
+	}
+}
+
+func TestRestartServer_Unlocked(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+	d.config.Starter = StarterConfig{
+		command: "sh",
+		args:    []string{"-c", loopWorker},
+		ports:   []string{"127.0.0.1:0"},
+	}
+	d.notifier = &mockNotify{}
+	t.Cleanup(func() { d.supervisor.stop(5 * time.Second) })
+
+	if err := d.startServer(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- d.restartServer() }()
+
+	// The new worker's startup wait takes a second; status must not stall on it.
+	time.Sleep(200 * time.Millisecond)
+	if !d.TryRLock() {
+		t.Error("Dewy lock held while the supervisor waits for the new worker")
+	} else {
+		d.RUnlock()
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if d.supervisor.currentGeneration() != 2 {
+		t.Errorf("generation = %d, want 2", d.supervisor.currentGeneration())
+	}
+}
@@ -25,6 +25,64 @@ const (
 	// defaultHealthCheckDelay is the back-off between probe attempts.
 	defaultHealthCheckDelay = 2 * time.Second
 
+	// defaultServerHealthTimeout is how long a restarted server worker has
+	// to pass its readiness probe when --health-timeout is not given.
+	defaultServerHealthTimeout = 30 * time.Second
+
+	// defaultRestartBackoff is the delay before restarting a crashed server;
+	// it doubles with every consecutive crash up to defaultRestartBackoffMax.
+	// A server that stays up longer than the cap resets the streak.
+	defaultRestartBackoff    = 10 * time.Second
+	defaultRestartBackoffMax = 5 * time.Minute
+
+	// defaultWorkerStartupWait is how long a new server worker must stay up
+	// before it counts as started, when the starter config has no interval.
+	defaultWorkerStartupWait = time.Second
+
+	// defaultWorkerStopTimeout is how long dewy waits for server workers to
+	// exit after signalling them on shutdown.
+	defaultWorkerStopTimeout = 30 * time.Second
+
+	// defaultCrashLoopThreshold crashes within defaultCrashLoopWindow count
+	// as a crash loop, which is notified once per streak.
+	defaultCrashLoopThreshold = 5
+	defaultCrashLoopWindow    = 10 * time.Minute
+
+	// defaultAppLogMaxSize is the size at which a captured application log
+	// is rotated; defaultAppLogBackups rotated files are kept beside it.
+	defaultAppLogMaxSize = 10 << 20
+	defaultAppLogBackups = 3
+
+	// defaultLogTailLines is how many lines /api/logs (and `dewy logs`)
+	// return before following.
+	defaultLogTailLines = 100
+
+	// defaultHookTimeout bounds a single deploy hook when --hook-timeout is
+	// not given. A hung hook used to block the tick forever.
+	defaultHookTimeout = 5 * time.Minute
+
+	// defaultWebhookRetryDelay is the first back-off between webhook
+	// attempts; it doubles after every failed attempt.
+	defaultWebhookRetryDelay = time.Second
+
+	// webhookResponseLimit caps how much of a webhook response body is kept
+	// in the hook result (and therefore in notifications).
+	webhookResponseLimit = 4 << 10
+
+	// defaultEventBacklog is how many recent deployment events /api/events
+	// keeps for clients resuming with Last-Event-ID.
+	defaultEventBacklog = 256
+
+	// defaultEventKeepAlive is the interval of the comment lines that keep an
+	// idle /api/events stream open through proxies.
+	defaultEventKeepAlive = 15 * time.Second
+
+	// defaultWatchBackoff is the delay before reopening a registry watch
+	// stream that dropped; it doubles with every failed attempt up to
+	// defaultWatchBackoffMax and resets once the stream delivers again.
+	defaultWatchBackoff    = time.Second
+	defaultWatchBackoffMax = time.Minute
+
 	// defaultAdminReadHeaderTimeout caps how long the admin HTTP server
 	// waits for request headers; mitigates Slowloris.
 	defaultAdminReadHeaderTimeout = 5 * time.Second
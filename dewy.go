@@ -2,6 +2,7 @@ package dewy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -50,6 +51,7 @@ type Dewy struct {
 	artifact         artifact.Artifact
 	cache            cache.Cache
 	isServerRunning  bool
+	supervisor       *supervisor // Workers of the managed server (server only, nil until started)
 	disableReport    bool
 	root             string
 	job              *scheduler.Job
@@ -58,8 +60,19 @@ type Dewy struct {
 	tcpProxies       map[int]*tcpProxy // TCP proxies keyed by proxy port
 	proxyMutex       sync.RWMutex
 	adminServer      *http.Server // Admin API server for CLI communication
+	adminAuth        *adminAuth   // Admin API credentials (nil until the admin API starts)
+	tickMu           sync.Mutex   // Serializes deploy ticks, restarts and admin-triggered deploys
+	replicasFlag     int          // Container.Replicas as given on the command line, before a persisted scale
 	containerRuntime *container.Runtime
-	cVer             string // Current deployed version (tag)
+	cVer             string       // Current deployed version (tag)
+	envKeys          []string     // Keys of the managed environment given to the server worker
+	crashes          crashState   // Managed server exits, for restart backoff (server only)
+	appLogs          *appLogs     // Captured application output (nil unless Config.CaptureLogs)
+	lastPoll         *pollStatus  // Outcome of the latest deploy tick, for /api/status
+	deployedAt       time.Time    // When this process last deployed successfully
+	events           *eventStream // Deployment events for /api/events
+	bus              *eventBus    // Event subscribers, created with the built-in ones on first use
+	busOnce          sync.Once
 	telemetry        *telemetry.Provider
 	sync.RWMutex
 }
@@ -94,13 +107,21 @@ func New(c Config, log *logging.Logger) (*Dewy, error) {
 	}
 	c.Registry = fmt.Sprintf("%s://%s", su[0], u.String())
 
-	return &Dewy{
+	d := &Dewy{
 		config:          c,
 		cache:           kv,
 		isServerRunning: false,
 		root:            wd,
 		logger:          log,
-	}, nil
+		events:          newEventStream(),
+	}
+	if c.CaptureLogs {
+		d.appLogs = newAppLogs(filepath.Join(wd, appLogsDir))
+	}
+	if c.Command == CONTAINER && c.Container != nil {
+		d.loadScale()
+	}
+	return d, nil
 }
 
 // SetTelemetry sets the telemetry provider.
@@ -122,22 +143,10 @@ func (d *Dewy) Start(i int) {
 	if err != nil {
 		d.logger.Error("Registry failure", slog.String("error", err.Error()))
 	}
-
-	// Wrap the registry with a shared result cache when the cache backend
-	// supports atomic writes and the operator opted in via ?registry-ttl=...
-	// on the cache URL.
-	if d.registry != nil {
-		if ttl := d.cache.RegistryTTL(); ttl > 0 {
-			if ac, ok := d.cache.(cache.AtomicCache); ok {
-				d.registry = registry.NewCached(d.registry, d.config.Registry, ac, ttl, d.logger)
-				d.logger.Info("Registry result cache enabled",
-					slog.Duration("ttl", ttl))
-			} else {
-				d.logger.Warn("registry-ttl set but cache backend does not support atomic writes; ignoring",
-					slog.Duration("ttl", ttl))
-			}
-		}
-	}
+	// Keep hold of a streaming registry before the result cache hides it;
+	// watchRegistry stores what it pushes into that cache.
+	watcher, _ := d.registry.(registry.Watcher)
+	d.cacheRegistry()
 
 	d.notifier, err = notifier.New(ctx, d.config.Notifier, d.logger.Slog())
 	if err != nil {
@@ -175,10 +184,11 @@ func (d *Dewy) Start(i int) {
 					slog.String("error", err.Error()))
 			}
 		}
-	} else if d.telemetryOn() {
-		// Server/assets mode has no admin API of its own, but when telemetry
-		// is enabled we still need to serve /metrics for Prometheus scraping of
-		// the deployment and restart counters.
+	} else if d.telemetryOn() || d.adminAPIRequested() {
+		// Server and assets modes have no admin API of their own, but when
+		// telemetry is enabled we still need to serve /metrics for
+		// Prometheus scraping of the deployment and restart counters, and an
+		// operator may ask for /api/status and friends with the admin flags.
 		if err := d.startAdminAPI(ctx); err != nil {
 			d.logger.Error("Admin API startup failed", slog.String("error", err.Error()))
 			d.notifier.SendError(ctx, err)
@@ -187,26 +197,41 @@ func (d *Dewy) Start(i int) {
 	}
 
 	d.job, err = scheduler.Every(i).Seconds().Run(func() {
-		var e error
-		if d.config.Command == CONTAINER {
-			e = d.RunContainer()
-		} else {
-			e = d.Run()
-		}
-		if e != nil {
-			d.logger.Error("Dewy run failure", slog.String("error", e.Error()))
-			d.notifier.SendError(context.Background(), e)
-		} else {
-			d.notifier.ResetErrorCount()
-		}
+		d.tick(false)
 	})
 	if err != nil {
 		d.logger.Error("Scheduler failure", slog.String("error", err.Error()))
 	}
 
+	if watcher != nil {
+		go d.watchRegistry(ctx, watcher)
+	}
+
 	d.waitSigs(ctx)
 }
 
+// cacheRegistry wraps the registry with a shared result cache when the cache
+// backend supports atomic writes and the operator opted in via
+// ?registry-ttl=... on the cache URL.
+func (d *Dewy) cacheRegistry() {
+	if d.registry == nil {
+		return
+	}
+	ttl := d.cache.RegistryTTL()
+	if ttl <= 0 {
+		return
+	}
+	ac, ok := d.cache.(cache.AtomicCache)
+	if !ok {
+		d.logger.Warn("registry-ttl set but cache backend does not support atomic writes; ignoring",
+			slog.Duration("ttl", ttl))
+		return
+	}
+	d.registry = registry.NewCached(d.registry, d.config.Registry, ac, ttl, d.logger)
+	d.logger.Info("Registry result cache enabled",
+		slog.Duration("ttl", ttl))
+}
+
 func (d *Dewy) waitSigs(ctx context.Context) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -218,20 +243,16 @@ func (d *Dewy) waitSigs(ctx context.Context) {
 			continue
 
 		case syscall.SIGUSR1:
-			// restartServer only signals this process (SIGHUP to self), so it
-			// "succeeds" even in assets mode or before the managed process has
-			// started — but nothing actually restarts. Only count a signal
-			// restart when there is a running managed server to restart, so the
-			// metric does not report phantom restarts.
-			d.RLock()
-			hasServer := d.config.Command == SERVER && d.isServerRunning
-			d.RUnlock()
-			if err := d.restartServer(); err != nil {
+			// Only a running managed server is restarted, so the metric does
+			// not report phantom restarts.
+			err := d.restartManagedServer(ctx, "signal")
+			if errors.Is(err, errNoServer) {
+				d.logger.Debug("Restart skipped", slog.String("reason", err.Error()))
+				continue
+			}
+			if err != nil {
 				d.logger.Error("Restart failure", slog.String("error", err.Error()))
 			} else {
-				if hasServer {
-					d.recordServerRestart(ctx, "signal")
-				}
 				msg := fmt.Sprintf("Restarted receiving by `%s` signal", "SIGUSR1")
 				d.logger.Info("Restart notification", slog.String("message", msg))
 				d.notifier.Send(ctx, msg)
@@ -252,9 +273,19 @@ func (d *Dewy) waitSigs(ctx context.Context) {
 				}
 			}
 
+			// Stop the managed server's workers and release its listeners
+			if d.config.Command == SERVER {
+				d.RLock()
+				s := d.supervisor
+				d.RUnlock()
+				if s != nil {
+					s.stop(defaultWorkerStopTimeout)
+				}
+			}
+
 			// The admin API may run in any mode (container always, server/assets
-			// when telemetry is enabled); stopAdminAPI is a no-op if it never
-			// started.
+			// when telemetry is enabled or asked for); stopAdminAPI is a no-op
+			// if it never started.
 			if err := d.stopAdminAPI(ctx); err != nil {
 				d.logger.Error("Failed to stop admin API", slog.String("error", err.Error()))
 			}
@@ -279,78 +310,180 @@ func (d *Dewy) waitSigs(ctx context.Context) {
 // cachekeyName is "tag--artifact"
 // example: v1.2.3--testapp_linux_amd64.tar.gz
 func (d *Dewy) cachekeyName(res *registry.CurrentResponse) string {
-	u := strings.SplitN(res.ArtifactURL, "?", 2)
-	return fmt.Sprintf("%s--%s", res.Tag, filepath.Base(u[0]))
+	u, _, _ := strings.Cut(res.ArtifactURL, "#")
+	u, _, _ = strings.Cut(u, "?")
+	return fmt.Sprintf("%s--%s", res.Tag, filepath.Base(u))
 }
 
 // Run is the per-tick deploy state machine for SERVER and ASSETS commands.
 // It is intentionally short: each phase lives as a method on Dewy in
 // lifecycle.go and can be exercised in isolation.
 func (d *Dewy) Run() error {
+	_, err := d.runTick(false)
+	return err
+}
+
+// runTick is Run with the outcome spelled out for POST /api/deploy. With
+// force, a tag that is already deployed is deployed again from the cache.
+func (d *Dewy) runTick(force bool) (tickResult, error) {
+	d.tickMu.Lock()
+	defer d.tickMu.Unlock()
+
 	ctx, cancel := d.makeRunContext()
 	defer cancel()
 
+	r := tickResult{Outcome: outcomeSkipped, Forced: force}
 	res, err := d.resolveCurrent(ctx)
 	if err != nil || res == nil {
-		return err
+		return r.finish(err)
 	}
+	r.Tag = res.Tag
 
 	st, err := d.resolveCacheState(ctx, res)
 	if err != nil {
-		return err
+		return r.finish(err)
+	}
+	if force {
+		if st, err = d.forceCacheState(st); err != nil {
+			return r.finish(err)
+		}
 	}
 	if st.skip {
-		return nil
+		return r.finish(nil)
 	}
 
 	// Past the skip check a real deploy is happening; time it and record the
 	// outcome. Skipped ticks above are not deployments and must not be counted.
-	start := time.Now()
-	err = d.runDeploy(ctx, res, st)
-	d.recordDeployment(ctx, time.Since(start), err)
-	return err
+	hc := d.newHookContext(res)
+	r.PreviousTag = hc.PreviousTag
+	d.publish(ctx, Event{Type: EventVersionDetected, Tag: res.Tag, PreviousTag: hc.PreviousTag, Forced: force})
+	err = d.runDeploy(ctx, res, st, hc)
+	r.Duration = jsonDuration(d.finishDeploy(ctx, res, hc, force, 0, err))
+	r.Outcome = outcomeDeployed
+	return r.finish(err)
+}
+
+// finishDeploy ends a deploy that got past the skip check: on failure it runs
+// the on-failure hook and reports the failed phase to the registry, and it
+// publishes the outcome with the time since hc.Started, which it also
+// returns for the tick result.
+func (d *Dewy) finishDeploy(ctx context.Context, res *registry.CurrentResponse, hc hookContext, force bool, replicas int, err error) time.Duration {
+	dur := time.Since(hc.Started)
+	if err != nil {
+		hc.Error = err.Error()
+		_ = d.runHook(ctx, hookOnFailure, hc)
+		d.reportDeploymentFailure(ctx, res, hc, err)
+		d.publish(ctx, Event{Type: EventFailed, Tag: hc.Tag, PreviousTag: hc.PreviousTag, Forced: force, Duration: dur, Err: err})
+	} else {
+		d.publish(ctx, Event{Type: EventDeployed, Tag: hc.Tag, PreviousTag: hc.PreviousTag, Forced: force, Replicas: replicas, Duration: dur})
+	}
+	return dur
 }
 
 // runDeploy runs the download/apply/promote phases of a server or assets
 // deploy. Split out of Run so the deploy proper can be timed as a unit.
-func (d *Dewy) runDeploy(ctx context.Context, res *registry.CurrentResponse, st cacheState) error {
+func (d *Dewy) runDeploy(ctx context.Context, res *registry.CurrentResponse, st cacheState, hc hookContext) error {
+	if !st.foundInCache {
+		if err := d.runHook(ctx, hookPreDownload, hc); err != nil {
+			return err
+		}
+	}
 	if err := d.downloadAndCache(ctx, res, st); err != nil {
-		return err
+		return failedIn(phaseDownload, err)
 	}
-	if err := d.applyDeployment(ctx, res, st.key); err != nil {
+	if err := d.applyDeployment(ctx, res, st.key, hc); err != nil {
 		return err
 	}
-	return d.promoteAndReport(ctx, res)
+	return d.promoteAndReport(ctx, res, hc)
 }
 
 // RunContainer runs the container deployment process.
 // RunContainer is the per-tick deploy state machine for the CONTAINER command.
 // Like Run, each phase lives as a method on Dewy in lifecycle.go.
 func (d *Dewy) RunContainer() error {
+	_, err := d.runContainerTick(false)
+	return err
+}
+
+// runContainerTick is RunContainer with the outcome spelled out for POST
+// /api/deploy. With force, an image that is already running is rolled out
+// again.
+func (d *Dewy) runContainerTick(force bool) (tickResult, error) {
+	d.tickMu.Lock()
+	defer d.tickMu.Unlock()
+
 	ctx, cancel := d.makeRunContext()
 	defer cancel()
 
+	r := tickResult{Outcome: outcomeSkipped, Forced: force}
 	res, err := d.resolveContainerCurrent(ctx)
 	if err != nil || res == nil {
-		return err
+		return r.finish(err)
 	}
+	r.Tag = res.Tag
 
 	st, err := d.resolveContainerState(ctx, res)
 	if err != nil {
-		return err
+		return r.finish(err)
 	}
-	if st.skip {
-		return nil
+	// Pick up replicas started (or restarted) since the last tick.
+	d.followContainerLogs(ctx)
+	if st.skip && !force {
+		return r.finish(nil)
+	}
+
+	hc := d.containerHookContext(res)
+	r.PreviousTag = hc.PreviousTag
+	d.publish(ctx, Event{Type: EventVersionDetected, Tag: res.Tag, PreviousTag: hc.PreviousTag, Forced: force})
+	r.Outcome = outcomeDeployed
+	deployedCount, err := d.runContainerDeploy(ctx, res, st, hc)
+	r.Duration = jsonDuration(d.finishDeploy(ctx, res, hc, force, deployedCount, err))
+	return r.finish(err)
+}
+
+// tick runs one deploy cycle for the configured command, logging a failure
+// and publishing the outcome as EventChecked, which the notifier turns into
+// an error notification. The scheduler and POST /api/deploy both go through
+// it.
+func (d *Dewy) tick(force bool) tickResult {
+	var r tickResult
+	var err error
+	if d.config.Command == CONTAINER {
+		r, err = d.runContainerTick(force)
+	} else {
+		r, err = d.runTick(force)
+	}
+	if err != nil {
+		d.logger.Error("Dewy run failure", slog.String("error", err.Error()))
+	}
+	d.publish(context.Background(), Event{
+		Type:        EventChecked,
+		Tag:         r.Tag,
+		PreviousTag: r.PreviousTag,
+		Outcome:     r.Outcome,
+		Forced:      r.Forced,
+		Duration:    time.Duration(r.Duration),
+		Err:         err,
+	})
+	return r
+}
+
+// runContainerDeploy runs the pull/apply/promote phases of a container
+// deploy, mirroring runDeploy for the server/assets path. It returns the
+// number of replicas deployed.
+func (d *Dewy) runContainerDeploy(ctx context.Context, res *registry.CurrentResponse, st containerState, hc hookContext) (int, error) {
+	if err := d.runHook(ctx, hookPreDownload, hc); err != nil {
+		return 0, err
 	}
 
 	if err := d.pullContainerImage(ctx, res, st); err != nil {
-		return err
+		return 0, failedIn(phaseDownload, err)
 	}
 
-	deployedCount, err := d.applyContainerDeployment(ctx, res, st)
+	deployedCount, err := d.applyContainerDeployment(ctx, res, st, hc)
 	if err != nil {
-		return err
+		return deployedCount, err
 	}
 
-	return d.promoteContainerAndReport(ctx, res, deployedCount, st.imageRef)
+	return deployedCount, d.promoteContainerAndReport(ctx, res, deployedCount, st.imageRef, hc)
 }
@@ -55,8 +55,8 @@ func TestNew(t *testing.T) {
 	}
 
 	opts := []cmp.Option{
-		cmp.AllowUnexported(Dewy{}, cache.File{}),
-		cmpopts.IgnoreFields(Dewy{}, "RWMutex", "logger", "tcpProxies", "proxyMutex", "containerRuntime"),
+		cmp.AllowUnexported(Dewy{}, cache.File{}, crashState{}),
+		cmpopts.IgnoreFields(Dewy{}, "RWMutex", "tickMu", "logger", "tcpProxies", "proxyMutex", "containerRuntime", "events", "bus", "busOnce"),
 		cmpopts.IgnoreFields(cache.File{}, "mutex", "logger"),
 	}
 	if diff := cmp.Diff(dewy, expect, opts...); diff != "" {
@@ -219,6 +219,7 @@ func TestRun(t *testing.T) {
 	c.Cache = CacheConfig{
 		Type:       FILE,
 		Expiration: 10,
+		URL:        "file://" + t.TempDir(),
 	}
 	dewy, err := New(c, testLogger())
 	if err != nil {
@@ -287,6 +288,7 @@ func TestDeployHook(t *testing.T) {
 			c.Cache = CacheConfig{
 				Type:       FILE,
 				Expiration: 10,
+				URL:        "file://" + t.TempDir(),
 			}
 			dewy, err := New(c, testLogger())
 			if err != nil {
@@ -1026,6 +1028,7 @@ func TestHookResultNotification(t *testing.T) {
 			fileKvs := &cache.File{}
 			fileKvs.SetLogger(testLogger().Logger)
 			fileKvs.Default()
+			fileKvs.SetDir(t.TempDir())
 			dewy.cache = fileKvs
 
 			mockArt := &mockArtifact{
@@ -1118,6 +1121,7 @@ func TestHookStdoutStderrTrimming(t *testing.T) {
 			fileKvs := &cache.File{}
 			fileKvs.SetLogger(testLogger().Logger)
 			fileKvs.Default()
+			fileKvs.SetDir(t.TempDir())
 			dewy.cache = fileKvs
 
 			mockArt := &mockArtifact{
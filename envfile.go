@@ -0,0 +1,190 @@
+package dewy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// releaseEnvFile is the optional env file an artifact may ship at its root.
+const releaseEnvFile = ".env"
+
+// redactedValue replaces secret values whenever an environment is logged.
+const redactedValue = "[REDACTED]"
+
+var (
+	envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// secretKeyMarkers are substrings that mark an env key as secret. The
+	// match is case-insensitive and deliberately broad: over-redacting a log
+	// line costs nothing, leaking a credential does.
+	secretKeyMarkers = []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "PRIVATE", "AUTH"}
+)
+
+// parseEnvFile reads dotenv-style KEY=VALUE lines. Blank lines and lines
+// starting with # are ignored, an optional "export " prefix is accepted,
+// single-quoted values are taken literally, and double-quoted values expand
+// \n, \t, \" and \\ escapes.
+func parseEnvFile(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing '='", n)
+		}
+		k = strings.TrimSpace(k)
+		if !envKeyPattern.MatchString(k) {
+			return nil, fmt.Errorf("line %d: invalid key %q", n, k)
+		}
+		v, err := unquoteEnvValue(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		env[k] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// unquoteEnvValue strips matching quotes from v. Unquoted values lose any
+// trailing " #comment".
+func unquoteEnvValue(v string) (string, error) {
+	if v == "" {
+		return v, nil
+	}
+	switch v[0] {
+	case '\'':
+		if len(v) < 2 || v[len(v)-1] != '\'' {
+			return "", errors.New("unterminated single quote")
+		}
+		return v[1 : len(v)-1], nil
+	case '"':
+		if len(v) < 2 || v[len(v)-1] != '"' {
+			return "", errors.New("unterminated double quote")
+		}
+		r := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return r.Replace(v[1 : len(v)-1]), nil
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v, nil
+}
+
+// isSecretEnvKey reports whether the value of key must not be logged.
+func isSecretEnvKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, m := range secretKeyMarkers {
+		if strings.Contains(upper, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactEnv returns a copy of env safe to log: values of secret-looking keys
+// are replaced with redactedValue.
+func redactEnv(env map[string]string) map[string]string {
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		if isSecretEnvKey(k) {
+			v = redactedValue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// readEnvFile parses the env file at p. A missing file yields (nil, nil) when
+// optional is set, so a release without a .env is not an error.
+func readEnvFile(p string, optional bool) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	env, err := parseEnvFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env file %s: %w", p, err)
+	}
+	return env, nil
+}
+
+// loadManagedEnv merges the release's .env with the configured env file. The
+// operator's file wins so per-host values can override defaults shipped in
+// the artifact.
+func (d *Dewy) loadManagedEnv() (map[string]string, error) {
+	env, err := readEnvFile(filepath.Join(d.root, symlinkDir, releaseEnvFile), true)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		env = make(map[string]string)
+	}
+
+	if p := d.config.EnvFile; p != "" {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(d.root, p)
+		}
+		appEnv, err := readEnvFile(p, false)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range appEnv {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+// workerEnv (re)loads the managed environment for the next server worker
+// as KEY=VALUE entries, sorted by key. It only reaches the worker: dewy's
+// own environment, which hooks inherit, is left alone. The caller must hold
+// d's write lock.
+func (d *Dewy) workerEnv() ([]string, error) {
+	env, err := d.loadManagedEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	d.envKeys = keys
+
+	entries := make([]string, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, k+"="+env[k])
+	}
+
+	if len(keys) > 0 {
+		d.logger.Info("Loaded managed environment", slog.Any("keys", keys))
+		d.logger.Debug("Managed environment", slog.Any("env", redactEnv(env)))
+	}
+	return entries, nil
+}
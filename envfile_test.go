@@ -0,0 +1,157 @@
+package dewy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	in := `# comment
+DB_HOST=db.internal
+export PORT=8080
+
+QUOTED="a b\nc"
+LITERAL='x\ny'
+TRAILING=value # comment
+EMPTY=
+`
+	got, err := parseEnvFile(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"DB_HOST":  "db.internal",
+		"PORT":     "8080",
+		"QUOTED":   "a b\nc",
+		"LITERAL":  `x\ny`,
+		"TRAILING": "value",
+		"EMPTY":    "",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseEnvFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"missing equals", "FOO\n"},
+		{"invalid key", "1FOO=bar\n"},
+		{"unterminated quote", "FOO=\"bar\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseEnvFile(strings.NewReader(tt.in)); err == nil {
+				t.Errorf("expected error for %q", tt.in)
+			}
+		})
+	}
+}
+
+func TestRedactEnv(t *testing.T) {
+	got := redactEnv(map[string]string{
+		"DB_HOST":     "db.internal",
+		"DB_PASSWORD": "hunter2",
+		"API_TOKEN":   "abc",
+		"aws_secret":  "xyz",
+	})
+	want := map[string]string{
+		"DB_HOST":     "db.internal",
+		"DB_PASSWORD": redactedValue,
+		"API_TOKEN":   redactedValue,
+		"aws_secret":  redactedValue,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWorkerEnv(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = SERVER
+
+	release := filepath.Join(d.root, releasesDir, "r1")
+	if err := os.MkdirAll(release, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(release, filepath.Join(d.root, symlinkDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(release, releaseEnvFile),
+		[]byte("DEWY_TEST_A=release\nDEWY_TEST_B=release\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	appEnv := filepath.Join(d.root, "app.env")
+	if err := os.WriteFile(appEnv, []byte("DEWY_TEST_B=app\nDEWY_TEST_C=app\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d.config.EnvFile = appEnv
+	t.Setenv("DEWY_TEST_C", "original")
+
+	env, err := d.workerEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DEWY_TEST_A=release", "DEWY_TEST_B=app", "DEWY_TEST_C=app"}
+	if diff := cmp.Diff(want, env); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"DEWY_TEST_A", "DEWY_TEST_B", "DEWY_TEST_C"}, d.envKeys); diff != "" {
+		t.Error(diff)
+	}
+	// The managed environment is for the worker only, not dewy or its hooks.
+	if _, ok := os.LookupEnv("DEWY_TEST_A"); ok {
+		t.Error("DEWY_TEST_A leaked into dewy's environment")
+	}
+	if got := os.Getenv("DEWY_TEST_C"); got != "original" {
+		t.Errorf("DEWY_TEST_C = %q, want dewy's own value kept", got)
+	}
+
+	// Reload after the app env file drops a key.
+	if err := os.WriteFile(appEnv, []byte("DEWY_TEST_B=app2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env, err = d.workerEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"DEWY_TEST_A=release", "DEWY_TEST_B=app2"}, env); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWorkerEnv_MissingAppFile(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.EnvFile = filepath.Join(d.root, "missing.env")
+	if _, err := d.workerEnv(); err == nil {
+		t.Error("expected error for a missing --env-file")
+	}
+}
+
+func TestHandleGetStatus_EnvKeys(t *testing.T) {
+	d := newAdminTestDewy(t)
+	d.envKeys = []string{"DB_HOST", "DB_PASSWORD"}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	w := httptest.NewRecorder()
+	d.handleGetStatus(w, req)
+
+	var body struct {
+		EnvKeys []string `json:"env_keys"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(d.envKeys, body.EnvKeys); diff != "" {
+		t.Error(diff)
+	}
+}
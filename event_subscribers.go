@@ -0,0 +1,143 @@
+package dewy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// The built-in subscribers are defined types over Dewy, like
+// proxyBackendUpdater: each gets its own HandleEvent while sharing the
+// instance, so they cost no allocation and need no wiring beyond eventBus.
+
+// historySubscriber keeps the latest poll and deploy for /api/status.
+type historySubscriber Dewy
+
+func (s *historySubscriber) HandleEvent(_ context.Context, e Event) {
+	d := (*Dewy)(s)
+	switch e.Type {
+	case EventDeployed:
+		d.Lock()
+		d.deployedAt = e.Time
+		d.Unlock()
+	case EventChecked:
+		r := tickResult{Outcome: e.Outcome, Tag: e.Tag, PreviousTag: e.PreviousTag, Forced: e.Forced, Duration: jsonDuration(e.Duration)}
+		if e.Err != nil {
+			r.Error = e.Err.Error()
+		}
+		d.recordPoll(r, e.Time)
+	}
+}
+
+// notifySubscriber turns events into notifier messages.
+type notifySubscriber Dewy
+
+func (s *notifySubscriber) HandleEvent(ctx context.Context, e Event) {
+	d := (*Dewy)(s)
+	if d.notifier == nil {
+		return
+	}
+
+	switch e.Type {
+	case EventDownloadFinished:
+		if d.config.Command == CONTAINER {
+			msg := fmt.Sprintf("Pulled image for `%s`", e.Tag)
+			d.logger.Info("Pull notification", slog.String("message", msg))
+			d.notifier.Send(ctx, msg)
+			return
+		}
+		msg := fmt.Sprintf("Downloaded artifact for `%s`", e.Tag)
+		d.logger.Info("Download notification", slog.String("message", msg))
+		d.notifier.Send(ctx, msg)
+
+	case EventExtracted:
+		d.notifier.OnDeploy(e.Path)
+
+	case EventHookRan:
+		if e.HookResult != nil {
+			d.notifier.SendHookResult(ctx, hookStage(e.Hook).label(), e.HookResult)
+		}
+
+	case EventStarted:
+		msg := fmt.Sprintf("Server started for `%s`", e.Tag) + d.withoutPort()
+		d.logger.Info("Start notification", slog.String("message", msg))
+		d.notifier.SendImportant(ctx, msg)
+
+	case EventRestarted:
+		switch {
+		case d.config.Command == CONTAINER:
+			msg := fmt.Sprintf("Containers restarted: `%d` replicas of `%s`", e.Replicas, e.Tag)
+			d.logger.Info("Restart notification", slog.String("message", msg))
+			d.notifier.Send(ctx, msg)
+		case e.Reason == "deploy":
+			msg := fmt.Sprintf("Server restarted for `%s`", e.Tag) + d.withoutPort()
+			d.logger.Info("Restart notification", slog.String("message", msg))
+			d.notifier.SendImportant(ctx, msg)
+		case e.Reason == "crash":
+			msg := fmt.Sprintf("Server restarted for `%s` after crash", e.Tag) + d.withoutPort()
+			d.logger.Info("Start notification", slog.String("message", msg))
+			d.notifier.SendImportant(ctx, msg)
+		case e.Reason == "api":
+			msg := fmt.Sprintf("Server restarted for `%s` via admin API", e.Tag)
+			d.logger.Info("Restart notification", slog.String("message", msg))
+			d.notifier.Send(ctx, msg)
+		}
+
+	case EventRolledBack:
+		d.notifier.OnDeploy(e.Path)
+		msg := fmt.Sprintf("Rolled back to `%s` after failed deploy of `%s`", e.PreviousTag, e.Tag)
+		d.logger.Warn("Rollback notification", slog.String("message", msg), slog.String("error", e.Err.Error()))
+		d.notifier.SendImportant(ctx, msg)
+
+	case EventScaled:
+		msg := fmt.Sprintf("Scaled `%s` from `%d` to `%d` replicas", e.Tag, e.PreviousReplicas, e.Replicas)
+		d.logger.Info("Scale notification", slog.String("message", msg))
+		d.notifier.Send(ctx, msg)
+
+	case EventCrashLoop:
+		msg := fmt.Sprintf("Server for `%s` is crash looping: %d crashes in %s (last exit code %d), restarting with backoff",
+			e.Tag, e.Crashes, e.Window, e.ExitCode)
+		d.logger.Warn("Crash loop notification", slog.String("message", msg))
+		d.notifier.SendImportant(ctx, msg)
+
+	case EventChecked:
+		if e.Err != nil {
+			d.notifier.SendError(ctx, e.Err)
+		} else {
+			d.notifier.ResetErrorCount()
+		}
+	}
+}
+
+// withoutPort is appended to server (re)start messages when the server
+// listens on no port.
+func (d *Dewy) withoutPort() string {
+	if len(d.config.Starter.Ports()) == 0 {
+		return " without port"
+	}
+	return ""
+}
+
+// telemetrySubscriber records deployment metrics. Container deploys record
+// theirs in applyContainerDeployment, timing the rollout alone.
+type telemetrySubscriber Dewy
+
+func (s *telemetrySubscriber) HandleEvent(ctx context.Context, e Event) {
+	d := (*Dewy)(s)
+	switch e.Type {
+	case EventDeployed:
+		if d.config.Command != CONTAINER {
+			d.recordDeployment(ctx, e.Duration, nil)
+		}
+	case EventFailed:
+		if d.config.Command != CONTAINER {
+			d.recordDeployment(ctx, e.Duration, e.Err)
+		}
+	case EventRestarted:
+		if d.config.Command == SERVER {
+			d.recordServerRestart(ctx, e.Reason)
+		}
+	case EventCrashed:
+		d.recordServerCrash(ctx, e.ExitCode)
+	}
+}
@@ -0,0 +1,88 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNotifySubscriber(t *testing.T) {
+	tests := []struct {
+		name    string
+		command Command
+		event   Event
+		want    []string
+	}{
+		{
+			name:    "download",
+			command: ASSETS,
+			event:   Event{Type: EventDownloadFinished, Tag: "v1.0.0", Cached: true},
+			want:    []string{"Downloaded artifact for `v1.0.0`"},
+		},
+		{
+			name:    "image pull",
+			command: CONTAINER,
+			event:   Event{Type: EventDownloadFinished, Tag: "v1.0.0"},
+			want:    []string{"Pulled image for `v1.0.0`"},
+		},
+		{
+			name:    "assets deployed is silent",
+			command: ASSETS,
+			event:   Event{Type: EventDeployed, Tag: "v1.0.0"},
+		},
+		{
+			// promoteContainerAndReport sends the success notification.
+			name:    "container deployed is silent",
+			command: CONTAINER,
+			event:   Event{Type: EventDeployed, Tag: "v1.0.0", Replicas: 2},
+		},
+		{
+			name:    "scaled",
+			command: CONTAINER,
+			event:   Event{Type: EventScaled, Tag: "v1.0.0", Replicas: 4, PreviousReplicas: 2},
+			want:    []string{"Scaled `v1.0.0` from `2` to `4` replicas"},
+		},
+		{
+			name:    "rolled back",
+			command: ASSETS,
+			event:   Event{Type: EventRolledBack, Tag: "v2.0.0", PreviousTag: "v1.0.0", Err: errors.New("boom")},
+			want:    []string{"Rolled back to `v1.0.0` after failed deploy of `v2.0.0`"},
+		},
+		{
+			name:    "poll failure",
+			command: ASSETS,
+			event:   Event{Type: EventChecked, Outcome: outcomeFailed, Err: errors.New("registry down")},
+			want:    []string{"Error occurred (count: 1): registry down"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newAdminTestDewy(t)
+			d.config.Command = tt.command
+			d.config.Container.Replicas = 3
+			notify := &mockNotify{}
+			d.notifier = notify
+
+			d.publish(context.Background(), tt.event)
+			if got := notify.GetMessages(); !slices.Equal(got, tt.want) {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistorySubscriber(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	ctx := context.Background()
+
+	d.publish(ctx, Event{Type: EventDeployed, Tag: "v1.0.0"})
+	d.publish(ctx, Event{Type: EventChecked, Tag: "v1.0.0", Outcome: outcomeDeployed, Forced: true})
+
+	if d.deployedAt.IsZero() {
+		t.Error("deployed_at not recorded")
+	}
+	if p := d.lastPoll; p == nil || p.Outcome != outcomeDeployed || !p.Forced || p.At.IsZero() {
+		t.Errorf("last poll = %+v", d.lastPoll)
+	}
+}
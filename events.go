@@ -0,0 +1,348 @@
+package dewy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/linyows/dewy/notifier"
+)
+
+// EventType identifies a deployment lifecycle event.
+type EventType string
+
+// Deployment lifecycle events, in roughly the order a deploy produces them.
+const (
+	EventVersionDetected  EventType = "version_detected"  // a tag that is not deployed yet was found; a deploy starts
+	EventDownloadStarted  EventType = "download_started"  // the artifact or image is being fetched
+	EventDownloadFinished EventType = "download_finished" // the artifact is available (Cached: it was already staged)
+	EventExtracted        EventType = "extracted"         // the release directory is ready, before it goes live
+	EventHookRan          EventType = "hook_ran"          // a shell hook or webhook finished
+	EventStarted          EventType = "started"           // the managed server was started
+	EventRestarted        EventType = "restarted"         // the server or the containers were restarted
+	EventReplicaAdded     EventType = "replica_added"     // a container replica passed its health check
+	EventReplicaRemoved   EventType = "replica_removed"   // container replicas were taken down
+	EventDeployed         EventType = "deployed"          // a deploy finished successfully
+	EventFailed           EventType = "failed"            // a deploy that had started failed
+	EventRolledBack       EventType = "rolled_back"       // a failed deploy was rolled back to the previous release
+	EventScaled           EventType = "scaled"            // the container replica count was changed
+	EventCrashed          EventType = "crashed"           // the managed server exited on its own
+	EventCrashLoop        EventType = "crash_loop"        // the crash-loop threshold was reached
+	EventChecked          EventType = "checked"           // a registry poll finished, whatever its outcome
+)
+
+// Event is a deployment lifecycle event. Fields that do not apply to its
+// type are left zero.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Command string // server, assets or container
+
+	Tag         string
+	PreviousTag string
+	Outcome     string // EventChecked: deployed, skipped or failed
+	Forced      bool   // EventChecked, EventDeployed: redeploy of the current tag
+
+	Hook       string               // EventHookRan: stage, e.g. post-extract
+	HookResult *notifier.HookResult // EventHookRan: nil when the hook could not run
+
+	Replica          int // EventReplicaAdded: replica index
+	Replicas         int // replicas added, removed, restarted or deployed; EventScaled: the new count
+	PreviousReplicas int // EventScaled
+
+	Path   string // EventExtracted, EventRolledBack: release directory now in use
+	Reason string // EventRestarted: deploy, crash, rollback, signal or api
+	Cached bool   // EventDownloadFinished
+
+	ExitCode int           // EventCrashed, EventCrashLoop
+	Crashes  int           // EventCrashLoop: crashes within Window
+	Window   time.Duration // EventCrashLoop
+
+	Duration time.Duration
+	Err      error
+}
+
+// Subscriber receives deployment events. HandleEvent runs synchronously, in
+// registration order, on the goroutine that published the event. That is
+// usually a deploy, which holds the lock serializing deploys, restarts and
+// admin actions, but never the Dewy lock itself. HandleEvent must return
+// quickly and must not call back into admin actions, which would wait for
+// that lock. Hand slow work to a goroutine of your own.
+type Subscriber interface {
+	HandleEvent(ctx context.Context, e Event)
+}
+
+// SubscriberFunc adapts an ordinary function to Subscriber.
+type SubscriberFunc func(ctx context.Context, e Event)
+
+// HandleEvent calls f(ctx, e).
+func (f SubscriberFunc) HandleEvent(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+// eventBus delivers events to its subscribers in registration order.
+type eventBus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   []busSubscription
+	logger *slog.Logger
+}
+
+type busSubscription struct {
+	id int
+	s  Subscriber
+}
+
+func (b *eventBus) subscribe(s Subscriber) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, busSubscription{id: id, s: s})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.subs {
+			if sub.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// publish hands e to every subscriber. A subscriber that panics is logged
+// and skipped; it must not take the deploy down with it.
+func (b *eventBus) publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil && b.logger != nil {
+					b.logger.Error("Event subscriber panic",
+						slog.String("event", string(e.Type)),
+						slog.Any("panic", r))
+				}
+			}()
+			sub.s.HandleEvent(ctx, e)
+		}()
+	}
+}
+
+// eventBus returns the bus, creating it with the built-in subscribers on
+// first use: history (for /api/status), the notifier, telemetry and the
+// /api/events stream.
+func (d *Dewy) eventBus() *eventBus {
+	d.busOnce.Do(func() {
+		d.bus = &eventBus{}
+		if d.logger != nil {
+			d.bus.logger = d.logger.Slog()
+		}
+		d.bus.subscribe((*historySubscriber)(d))
+		d.bus.subscribe((*notifySubscriber)(d))
+		d.bus.subscribe((*telemetrySubscriber)(d))
+		d.bus.subscribe((*streamSubscriber)(d))
+	})
+	return d.bus
+}
+
+// Subscribe registers s for every deployment event published from now on,
+// after the built-in subscribers. It returns a function that removes s.
+func (d *Dewy) Subscribe(s Subscriber) (unsubscribe func()) {
+	return d.eventBus().subscribe(s)
+}
+
+// publish stamps e and delivers it to the subscribers. The caller must not
+// hold the Dewy lock: the history subscriber takes it.
+func (d *Dewy) publish(ctx context.Context, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Command = d.config.Command.String()
+	d.eventBus().publish(ctx, e)
+}
+
+// streamEvent is the wire form of an Event on GET /api/events.
+type streamEvent struct {
+	ID          uint64    `json:"id"`
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	Tag         string    `json:"tag,omitempty"`
+	PreviousTag string    `json:"previous_tag,omitempty"`
+	Hook        string    `json:"hook,omitempty"`
+	Replica     string    `json:"replica,omitempty"`
+	Replicas    int       `json:"replicas,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Duration    string    `json:"duration,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// newStreamEvent converts e to its wire form (without an ID yet).
+func newStreamEvent(e Event) streamEvent {
+	se := streamEvent{
+		Type:        string(e.Type),
+		Time:        e.Time,
+		Tag:         e.Tag,
+		PreviousTag: e.PreviousTag,
+		Hook:        e.Hook,
+		Replicas:    e.Replicas,
+		Path:        e.Path,
+		Reason:      e.Reason,
+	}
+	if e.Type == EventReplicaAdded {
+		se.Replica = strconv.Itoa(e.Replica)
+	}
+	if e.Duration > 0 {
+		se.Duration = e.Duration.Round(time.Millisecond).String()
+	}
+	if e.Err != nil {
+		se.Error = e.Err.Error()
+	}
+	return se
+}
+
+// eventStream numbers events, keeps the most recent ones for clients that
+// reconnect, and fans them out to the /api/events subscribers.
+type eventStream struct {
+	mu      sync.Mutex
+	seq     uint64
+	backlog []streamEvent
+	subs    map[chan streamEvent]struct{}
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{subs: make(map[chan streamEvent]struct{})}
+}
+
+// publish stamps e and hands it to every stream client. A client that falls
+// behind misses events rather than stalling the deploy. A nil stream drops
+// everything.
+func (s *eventStream) publish(e streamEvent) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	e.ID = s.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.backlog = append(s.backlog, e)
+	if len(s.backlog) > defaultEventBacklog {
+		s.backlog = s.backlog[len(s.backlog)-defaultEventBacklog:]
+	}
+	for ch := range s.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// subscribe returns the retained events after lastID, a channel receiving
+// every event from now on, and a function that ends the subscription.
+func (s *eventStream) subscribe(lastID uint64) ([]streamEvent, <-chan streamEvent, func()) {
+	ch := make(chan streamEvent, 64)
+	s.mu.Lock()
+	var missed []streamEvent
+	for _, e := range s.backlog {
+		if e.ID > lastID {
+			missed = append(missed, e)
+		}
+	}
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	return missed, ch, func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}
+}
+
+// streamSubscriber feeds the /api/events stream. Poll results are left out:
+// they arrive every interval and the outcome that matters is already there
+// as deployed or failed.
+type streamSubscriber Dewy
+
+func (s *streamSubscriber) HandleEvent(_ context.Context, e Event) {
+	if e.Type == EventChecked {
+		return
+	}
+	s.events.publish(newStreamEvent(e))
+}
+
+// writeEvent writes e in text/event-stream framing.
+func writeEvent(w http.ResponseWriter, e streamEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, b)
+	return err
+}
+
+// handleGetEvents handles GET /api/events endpoint: a server-sent event
+// stream of deployment events. A client resuming with Last-Event-ID first
+// gets the retained events it missed.
+func (d *Dewy) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok || d.events == nil {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	var lastID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	missed, ch, cancel := d.events.subscribe(lastID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(defaultEventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			if err := writeEvent(w, e); err != nil {
+				d.logger.Debug("Event stream closed", slog.String("error", err.Error()))
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,161 @@
+package dewy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStream_Backlog(t *testing.T) {
+	s := newEventStream()
+	for range defaultEventBacklog + 2 {
+		s.publish(streamEvent{Type: string(EventHookRan)})
+	}
+
+	missed, _, cancel := s.subscribe(0)
+	cancel()
+	if len(missed) != defaultEventBacklog || missed[0].ID != 3 {
+		t.Errorf("backlog = %d events from id %d, want %d from 3", len(missed), missed[0].ID, defaultEventBacklog)
+	}
+
+	missed, ch, cancel := s.subscribe(defaultEventBacklog + 1)
+	defer cancel()
+	if len(missed) != 1 || missed[0].ID != defaultEventBacklog+2 {
+		t.Errorf("resumed backlog = %+v, want the last event only", missed)
+	}
+	s.publish(streamEvent{Type: string(EventDeployed), Tag: "v1.0.0"})
+	if e := <-ch; e.Type != string(EventDeployed) || e.Time.IsZero() {
+		t.Errorf("live event = %+v", e)
+	}
+
+	var nilStream *eventStream
+	nilStream.publish(streamEvent{Type: string(EventFailed)})
+}
+
+func TestHandleGetEvents(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.events = newEventStream()
+	ctx := context.Background()
+	d.publish(ctx, Event{Type: EventVersionDetected, Tag: "v1.0.0"})
+	d.publish(ctx, Event{Type: EventChecked, Tag: "v1.0.0"})
+	d.publish(ctx, Event{Type: EventDeployed, Tag: "v1.0.0"})
+
+	srv := httptest.NewServer(http.HandlerFunc(d.handleGetEvents))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	got := make(chan streamEvent, 4)
+	go func() {
+		_ = readEvents(resp.Body, func(data []byte) {
+			var e streamEvent
+			if err := json.Unmarshal(data, &e); err == nil {
+				got <- e
+			}
+		})
+	}()
+
+	// The poll result is not streamed, so deployed is the second event.
+	if e := <-got; e.ID != 2 || e.Type != string(EventDeployed) {
+		t.Errorf("replayed event = %+v, want id 2 deployed", e)
+	}
+	d.publish(ctx, Event{Type: EventRestarted, Reason: "api"})
+	select {
+	case e := <-got:
+		if e.ID != 3 || e.Type != string(EventRestarted) || e.Reason != "api" {
+			t.Errorf("live event = %+v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("live event was not streamed")
+	}
+}
+
+func TestTick_EmitsLifecycleEvents(t *testing.T) {
+	d, _, _ := newHookRunDewy(t)
+	d.events = newEventStream()
+	d.config.AfterDeployHook = "true"
+
+	if r := d.tick(false); r.Outcome != outcomeDeployed {
+		t.Fatalf("tick = %+v", r)
+	}
+	events, _, cancel := d.events.subscribe(0)
+	cancel()
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []string{"version_detected", "download_started", "download_finished", "extracted", "hook_ran", "deployed"}
+	if !slices.Equal(types, want) {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+	if last := events[len(events)-1]; last.Tag != "v2.0.0" || last.PreviousTag != "v1.0.0" {
+		t.Errorf("deployed event = %+v", last)
+	}
+}
+
+func TestReadEventsAndFormat(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"id: 7\nevent: failed\ndata: {\"id\":7,\"type\":\"failed\",\"time\":\"2026-03-01T12:00:00Z\",\"tag\":\"v1.1.0\",\"error\":\"pull failed\"}\n\n"
+	var lines []string
+	err := readEvents(bufio.NewReader(strings.NewReader(stream)), func(data []byte) {
+		var e streamEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, formatEvent(e))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("got %d events, want 1", len(lines))
+	}
+	if !strings.Contains(lines[0], "failed") || !strings.HasSuffix(lines[0], `tag=v1.1.0 error="pull failed"`) {
+		t.Errorf("line = %q", lines[0])
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	ctx := context.Background()
+
+	var got []string
+	unsubscribe := d.Subscribe(SubscriberFunc(func(_ context.Context, e Event) {
+		got = append(got, "first:"+string(e.Type))
+	}))
+	d.Subscribe(SubscriberFunc(func(context.Context, Event) {
+		panic("broken subscriber")
+	}))
+	d.Subscribe(SubscriberFunc(func(_ context.Context, e Event) {
+		if e.Time.IsZero() || e.Command != "assets" {
+			t.Errorf("event not stamped: %+v", e)
+		}
+		got = append(got, "last:"+string(e.Type))
+	}))
+
+	d.publish(ctx, Event{Type: EventDeployed, Tag: "v1.0.0"})
+	unsubscribe()
+	d.publish(ctx, Event{Type: EventFailed, Tag: "v1.1.0"})
+
+	want := []string{"first:deployed", "last:deployed", "last:failed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("delivered = %v, want %v", got, want)
+	}
+}
@@ -2,21 +2,194 @@ package dewy
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cli/safeexec"
 	"github.com/linyows/dewy/notifier"
 )
 
+// hookStage identifies the point in the deploy lifecycle at which a hook
+// runs. The string value is what operators pass to --blocking-hook.
+type hookStage string
+
+const (
+	hookPreDownload   hookStage = "pre-download"
+	hookBeforeDeploy  hookStage = "before-deploy"
+	hookPostExtract   hookStage = "post-extract"
+	hookAfterDeploy   hookStage = "after-deploy"
+	hookBeforeRestart hookStage = "before-restart"
+	hookAfterRestart  hookStage = "after-restart"
+	hookOnFailure     hookStage = "on-failure"
+	hookOnRollback    hookStage = "on-rollback"
+)
+
+// hookStages lists every stage in lifecycle order.
+var hookStages = []hookStage{
+	hookPreDownload,
+	hookBeforeDeploy,
+	hookPostExtract,
+	hookAfterDeploy,
+	hookBeforeRestart,
+	hookAfterRestart,
+	hookOnFailure,
+	hookOnRollback,
+}
+
+// label is the human-readable stage name used as the notifier hook type,
+// e.g. "Before Deploy".
+func (s hookStage) label() string {
+	words := strings.Split(string(s), "-")
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// validHookStage reports whether name is a known hook stage.
+func validHookStage(name string) bool {
+	return slices.Contains(hookStages, hookStage(name))
+}
+
+// hookCommand returns the configured shell command for stage ("" if none).
+func (d *Dewy) hookCommand(s hookStage) string {
+	switch s {
+	case hookPreDownload:
+		return d.config.PreDownloadHook
+	case hookBeforeDeploy:
+		return d.config.BeforeDeployHook
+	case hookPostExtract:
+		return d.config.PostExtractHook
+	case hookAfterDeploy:
+		return d.config.AfterDeployHook
+	case hookBeforeRestart:
+		return d.config.BeforeRestartHook
+	case hookAfterRestart:
+		return d.config.AfterRestartHook
+	case hookOnFailure:
+		return d.config.OnFailureHook
+	case hookOnRollback:
+		return d.config.OnRollbackHook
+	}
+	return ""
+}
+
+// hookBlocking reports whether a failure of the stage's hook aborts the
+// deploy. Hooks are advisory unless listed in Config.BlockingHooks.
+func (d *Dewy) hookBlocking(s hookStage) bool {
+	return slices.Contains(d.config.BlockingHooks, string(s))
+}
+
+// runHook runs the shell hook and then the webhooks configured for stage,
+// sends each result through the notifier, and logs failures. The first error
+// is returned only for blocking stages, and stops the remaining hooks of the
+// stage; advisory failures never change the outcome of the deploy.
+func (d *Dewy) runHook(ctx context.Context, s hookStage, hc hookContext) error {
+	result, err := d.execHook(ctx, d.hookCommand(s), hc)
+	d.emitHookRan(ctx, s, hc, result, err)
+	if err := d.hookOutcome(s, err); err != nil {
+		return failedIn(phaseHook, err)
+	}
+	for _, w := range d.webhooksFor(s) {
+		result, err := d.execWebhook(ctx, w, s, hc)
+		d.emitHookRan(ctx, s, hc, result, err)
+		if err := d.hookOutcome(s, err); err != nil {
+			return failedIn(phaseHook, err)
+		}
+	}
+	return nil
+}
+
+// emitHookRan publishes a hook run as EventHookRan. Stages without a
+// hook configured produce no result and no event.
+func (d *Dewy) emitHookRan(ctx context.Context, s hookStage, hc hookContext, result *notifier.HookResult, err error) {
+	if result == nil && err == nil {
+		return
+	}
+	e := Event{Type: EventHookRan, Tag: hc.Tag, Hook: string(s), HookResult: result, Err: err}
+	if result != nil {
+		e.Duration = result.Duration
+	}
+	d.publish(ctx, e)
+}
+
+// hookOutcome logs a failed hook run and decides whether its failure
+// propagates. The result itself reaches the notifier as EventHookRan.
+func (d *Dewy) hookOutcome(s hookStage, err error) error {
+	if err == nil {
+		return nil
+	}
+	blocking := d.hookBlocking(s)
+	d.logger.Error(s.label()+" hook failure",
+		slog.String("error", err.Error()),
+		slog.Bool("blocking", blocking))
+	if !blocking {
+		return nil
+	}
+	return fmt.Errorf("%s hook failed: %w", s, err)
+}
+
+// hookContext describes the deploy a hook runs for. It is exported to the
+// hook process as DEWY_* environment variables so scripts can tell which
+// version is being deployed without parsing dewy's logs.
+type hookContext struct {
+	Tag                string
+	PreviousTag        string
+	ReleaseDir         string
+	PreviousReleaseDir string // release current pointed at before the swap; rollback target
+	ArtifactURL        string
+	Slot               string
+	Replicas           int       // desired replica count (container command only)
+	Error              string    // failure that triggered on-failure / on-rollback hooks
+	Started            time.Time // when the deploy began; reported to the registry, not passed to hooks
+}
+
+// environ returns the DEWY_* variables for hc, in a stable order.
+func (hc hookContext) environ(cmd Command) []string {
+	env := []string{
+		"DEWY_TAG=" + hc.Tag,
+		"DEWY_PREVIOUS_TAG=" + hc.PreviousTag,
+		"DEWY_RELEASE_DIR=" + hc.ReleaseDir,
+		"DEWY_PREVIOUS_RELEASE_DIR=" + hc.PreviousReleaseDir,
+		"DEWY_COMMAND=" + cmd.String(),
+		"DEWY_ARTIFACT_URL=" + hc.ArtifactURL,
+		"DEWY_SLOT=" + hc.Slot,
+	}
+	if cmd == CONTAINER {
+		env = append(env, "DEWY_REPLICAS="+strconv.Itoa(hc.Replicas))
+	}
+	if hc.Error != "" {
+		env = append(env, "DEWY_ERROR="+hc.Error)
+	}
+	return env
+}
+
+// hookTimeout returns the per-hook timeout, falling back to the default
+// when the operator did not set one.
+func (d *Dewy) hookTimeout() time.Duration {
+	if d.config.HookTimeout > 0 {
+		return d.config.HookTimeout
+	}
+	return defaultHookTimeout
+}
+
 // execHook runs cmd as a shell command in d.root and returns a HookResult
 // describing the run. A blank cmd is a no-op (returns nil, nil) so callers
 // can pass d.config.BeforeDeployHook / AfterDeployHook unconditionally.
-func (d *Dewy) execHook(cmd string) (*notifier.HookResult, error) {
+//
+// The hook is bounded by the hook timeout and by ctx (the tick context), and
+// runs in its own process group so that on timeout or cancellation the whole
+// tree it spawned is killed, not just the sh parent.
+func (d *Dewy) execHook(ctx context.Context, cmd string, hc hookContext) (*notifier.HookResult, error) {
 	if cmd == "" {
 		return nil, nil
 	}
@@ -27,13 +200,24 @@ func (d *Dewy) execHook(cmd string) (*notifier.HookResult, error) {
 		return nil, err
 	}
 
+	timeout := d.hookTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	stdout := new(bytes.Buffer)
 	stderr := new(bytes.Buffer)
-	c := exec.Command(sh, "-c", cmd)
+	c := exec.CommandContext(ctx, sh, "-c", cmd)
 	c.Dir = d.root
-	c.Env = os.Environ()
+	c.Env = append(os.Environ(), hc.environ(d.config.Command)...)
 	c.Stdout = stdout
 	c.Stderr = stderr
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+	// Grandchildren may hold stdout/stderr open after the group kill races
+	// their exit; do not let them wedge Wait.
+	c.WaitDelay = time.Second
 
 	result := &notifier.HookResult{
 		Command: cmd,
@@ -52,12 +236,20 @@ func (d *Dewy) execHook(cmd string) (*notifier.HookResult, error) {
 			result.ExitCode = 1
 		}
 
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("hook timed out after %s: %w", timeout, err)
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = fmt.Errorf("hook canceled: %w", err)
+		}
+
 		d.logger.Info("Execute hook failed",
 			slog.String("command", cmd),
 			slog.String("stdout", result.Stdout),
 			slog.String("stderr", result.Stderr),
 			slog.Int("exit_code", result.ExitCode),
-			slog.Duration("duration", result.Duration))
+			slog.Duration("duration", result.Duration),
+			slog.String("error", err.Error()))
 
 		return result, err
 	}
@@ -0,0 +1,218 @@
+package dewy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+)
+
+func TestExecHook_Environment(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	hc := hookContext{
+		Tag:         "v1.2.0",
+		PreviousTag: "v1.1.0",
+		ReleaseDir:  "/srv/app/releases/20260101T000000Z",
+		ArtifactURL: "ghr://linyows/dewy/tag/v1.2.0/app.tar.gz",
+		Slot:        "blue",
+	}
+
+	res, err := d.execHook(context.Background(),
+		`echo "$DEWY_TAG|$DEWY_PREVIOUS_TAG|$DEWY_RELEASE_DIR|$DEWY_COMMAND|$DEWY_ARTIFACT_URL|$DEWY_SLOT"`, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "v1.2.0|v1.1.0|/srv/app/releases/20260101T000000Z|assets|ghr://linyows/dewy/tag/v1.2.0/app.tar.gz|blue"
+	if res.Stdout != want {
+		t.Errorf("stdout = %q, want %q", res.Stdout, want)
+	}
+}
+
+func TestHookContext_ContainerReplicas(t *testing.T) {
+	env := hookContext{Tag: "v1", Replicas: 3}.environ(CONTAINER)
+	if !strings.Contains(strings.Join(env, "\n"), "DEWY_REPLICAS=3") {
+		t.Errorf("container hook env = %v, want DEWY_REPLICAS=3", env)
+	}
+	env = hookContext{Tag: "v1"}.environ(SERVER)
+	if strings.Contains(strings.Join(env, "\n"), "DEWY_REPLICAS") {
+		t.Errorf("server hook env = %v, want no DEWY_REPLICAS", env)
+	}
+}
+
+// A hook that outlives its timeout must be killed together with the
+// processes it spawned, and the failure must say it timed out.
+func TestExecHook_TimeoutKillsProcessGroup(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.HookTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	res, err := d.execHook(context.Background(), "sleep 30 & sleep 30", hookContext{})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("hook ran for %s, want it killed near the timeout", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want timeout error", err)
+	}
+	if res == nil || res.Success {
+		t.Errorf("result = %+v, want failed result", res)
+	}
+}
+
+func TestExecHook_CanceledByTickContext(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, err := d.execHook(ctx, "sleep 30", hookContext{})
+	if err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("err = %v, want cancellation error", err)
+	}
+}
+
+func TestHookStageLabel(t *testing.T) {
+	tests := map[hookStage]string{
+		hookBeforeDeploy: "Before Deploy",
+		hookPreDownload:  "Pre Download",
+		hookOnRollback:   "On Rollback",
+	}
+	for stage, want := range tests {
+		if got := stage.label(); got != want {
+			t.Errorf("%s.label() = %q, want %q", stage, got, want)
+		}
+	}
+	if !validHookStage("post-extract") || validHookStage("post-deploy") {
+		t.Error("validHookStage does not match the declared stages")
+	}
+}
+
+// newHookRunDewy returns an assets-mode Dewy that has already deployed
+// v1.0.0 and is about to deploy v2.0.0.
+func newHookRunDewy(t *testing.T) (*Dewy, *mockNotify, string) {
+	t.Helper()
+	artifact := "ghr://linyows/dewy/tag/v1.2.3/artifact.zip"
+	d := newPhaseTestDewy(t)
+	notify := &mockNotify{}
+	d.notifier = notify
+	d.registry = &mockRegistry{url: artifact, tag: "v1.0.0"}
+	d.artifact = &mockArtifact{binary: "dewy", url: artifact}
+	if err := d.Run(); err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	prev := d.currentRelease()
+
+	// Release directories are second-resolution timestamps; make sure the
+	// next deploy lands in a fresh one.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	d.registry = &mockRegistry{url: artifact, tag: "v2.0.0"}
+	d.artifact = &mockArtifact{binary: "dewy", url: artifact}
+	return d, notify, prev
+}
+
+func TestRun_BlockingBeforeDeployHookAborts(t *testing.T) {
+	d, _, prev := newHookRunDewy(t)
+	d.config.BeforeDeployHook = "exit 3"
+	d.config.BlockingHooks = []string{"before-deploy"}
+	d.config.OnFailureHook = "echo $DEWY_ERROR > failure"
+	var report *registry.ReportRequest
+	d.registry.(*mockRegistry).reportFunc = func(_ context.Context, req *registry.ReportRequest) error {
+		report = req
+		return nil
+	}
+
+	if err := d.Run(); err == nil {
+		t.Fatal("expected blocking before-deploy hook to fail the deploy")
+	}
+	if report == nil || report.Phase != phaseHook || report.Tag != "v2.0.0" || report.Err == nil {
+		t.Errorf("report = %+v, want a failed hook for v2.0.0", report)
+	}
+	if got := d.currentRelease(); got != prev {
+		t.Errorf("current = %s, want %s kept", got, prev)
+	}
+	b, err := os.ReadFile(filepath.Join(d.root, "failure"))
+	if err != nil {
+		t.Fatalf("on-failure hook did not run: %v", err)
+	}
+	if !strings.Contains(string(b), "before-deploy hook failed") {
+		t.Errorf("DEWY_ERROR = %q", b)
+	}
+}
+
+func TestRun_AdvisoryHookDoesNotAbort(t *testing.T) {
+	d, _, prev := newHookRunDewy(t)
+	d.config.PostExtractHook = "exit 1"
+
+	if err := d.Run(); err != nil {
+		t.Fatalf("advisory hook failure must not fail the deploy: %v", err)
+	}
+	if got := d.currentRelease(); got == prev {
+		t.Error("current did not move to the new release")
+	}
+}
+
+func TestRun_BlockingPostExtractHookDiscardsRelease(t *testing.T) {
+	d, notify, prev := newHookRunDewy(t)
+	d.config.PostExtractHook = `test "$DEWY_TAG" = "never"`
+	d.config.BlockingHooks = []string{"post-extract"}
+
+	if err := d.Run(); err == nil {
+		t.Fatal("expected blocking post-extract hook to fail the deploy")
+	}
+	if got := d.currentRelease(); got != prev {
+		t.Errorf("current = %s, want %s kept", got, prev)
+	}
+	entries, err := os.ReadDir(filepath.Join(d.root, releasesDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("releases = %d, want the rejected release discarded", len(entries))
+	}
+	var sawPostExtract bool
+	for _, m := range notify.GetMessages() {
+		if strings.Contains(m, "Post Extract Hook") {
+			sawPostExtract = true
+		}
+	}
+	if !sawPostExtract {
+		t.Errorf("post-extract hook result not notified: %v", notify.GetMessages())
+	}
+}
+
+func TestRun_BlockingAfterDeployHookRollsBack(t *testing.T) {
+	d, notify, prev := newHookRunDewy(t)
+	d.config.AfterDeployHook = "exit 1"
+	d.config.BlockingHooks = []string{"after-deploy"}
+	d.config.OnRollbackHook = `echo "$DEWY_TAG $DEWY_PREVIOUS_TAG $DEWY_PREVIOUS_RELEASE_DIR" > rollback`
+
+	err := d.Run()
+	if err == nil || !strings.Contains(err.Error(), "rolled back to v1.0.0") {
+		t.Fatalf("err = %v, want rollback error", err)
+	}
+	if got := d.currentRelease(); got != prev {
+		t.Errorf("current = %s, want rolled back to %s", got, prev)
+	}
+	if d.cVer != "v1.0.0" {
+		t.Errorf("cVer = %q, want v1.0.0", d.cVer)
+	}
+	b, err := os.ReadFile(filepath.Join(d.root, "rollback"))
+	if err != nil {
+		t.Fatalf("on-rollback hook did not run: %v", err)
+	}
+	if want := "v2.0.0 v1.0.0 " + prev + "\n"; string(b) != want {
+		t.Errorf("on-rollback env = %q, want %q", b, want)
+	}
+	var sawRollback bool
+	for _, m := range notify.GetMessages() {
+		if strings.Contains(m, "Rolled back to `v1.0.0`") {
+			sawRollback = true
+		}
+	}
+	if !sawRollback {
+		t.Errorf("rollback not notified: %v", notify.GetMessages())
+	}
+}
@@ -4,9 +4,18 @@
 package scheme
 
 const (
-	GHR  = "ghr"  // GitHub Releases
-	S3   = "s3"   // Amazon S3
-	GS   = "gs"   // Google Cloud Storage
-	GRPC = "grpc" // gRPC registry endpoint
-	OCI  = "img"  // OCI / container image registry
+	GHR     = "ghr"     // GitHub Releases
+	GLR     = "glr"     // GitLab Releases
+	Gitea   = "gitea"   // Gitea Releases
+	Forgejo = "forgejo" // Forgejo Releases (same API as Gitea)
+	S3      = "s3"      // Amazon S3
+	GS      = "gs"      // Google Cloud Storage
+	AzBlob  = "azblob"  // Azure Blob Storage
+	GRPC    = "grpc"    // gRPC registry endpoint
+	OCI     = "img"     // OCI / container image registry
+	ORAS    = "oras"    // OCI artifacts (ORAS) holding archives
+	File    = "file"    // local filesystem
+
+	HTTP  = "http"  // plain web server (artifact download)
+	HTTPS = "https" // plain web server over TLS (artifact download)
 )
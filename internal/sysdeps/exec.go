@@ -2,6 +2,7 @@ package sysdeps
 
 import (
 	"context"
+	"io"
 	"os/exec"
 )
 
@@ -13,6 +14,9 @@ import (
 type CommandRunner interface {
 	Run(ctx context.Context, name string, args ...string) error
 	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	// Stream runs the command with its stdout and stderr connected to the
+	// given writers, returning when it exits or ctx is canceled.
+	Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) error
 	LookPath(name string) (string, error)
 }
 
@@ -29,6 +33,13 @@ func (realCommandRunner) Output(ctx context.Context, name string, args ...string
 	return exec.CommandContext(ctx, name, args...).Output()
 }
 
+func (realCommandRunner) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
 func (realCommandRunner) LookPath(name string) (string, error) {
 	return exec.LookPath(name)
 }
@@ -3,6 +3,7 @@ package fake
 import (
 	"context"
 	"errors"
+	"io"
 	"sync"
 
 	"github.com/linyows/dewy/internal/sysdeps"
@@ -108,6 +109,22 @@ func (c *CommandRunner) Output(ctx context.Context, name string, args ...string)
 	return c.respond(name, args)
 }
 
+// Stream writes the registered output for the command to stdout and returns
+// its error; stderr is left untouched.
+func (c *CommandRunner) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) error {
+	c.record(name, args)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	out, err := c.respond(name, args)
+	if len(out) > 0 {
+		if _, werr := stdout.Write(out); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
 func (c *CommandRunner) LookPath(name string) (string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
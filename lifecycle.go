@@ -3,9 +3,13 @@ package dewy
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 	"time"
 
@@ -70,7 +74,8 @@ type cacheState struct {
 //   - SERVER + cached version matches + server is running -> skip
 //   - ASSETS + cached version matches -> skip
 //   - SERVER + cached version matches + server NOT running (crashed/booting) ->
-//     fall through to redeploy from cache (foundInCache stays true).
+//     fall through to redeploy from cache (foundInCache stays true), unless
+//     the crash backoff is still running, in which case skip.
 func (d *Dewy) resolveCacheState(_ context.Context, res *registry.CurrentResponse) (cacheState, error) {
 	st := cacheState{key: d.cachekeyName(res)}
 
@@ -91,12 +96,19 @@ func (d *Dewy) resolveCacheState(_ context.Context, res *registry.CurrentRespons
 			case SERVER:
 				d.RLock()
 				running := d.isServerRunning
+				holdoff := d.restartHeldOff()
 				d.RUnlock()
 				if running {
 					d.logger.Debug("Deploy skipped")
 					st.skip = true
 					return st, nil
 				}
+				// Server crashed: back off before starting the same version again.
+				if holdoff > 0 {
+					d.logger.Debug("Restart skipped: crash backoff", slog.Duration("remaining", holdoff))
+					st.skip = true
+					return st, nil
+				}
 				// Server is down: fall through to redeploy from cache.
 			case ASSETS:
 				d.logger.Debug("Deploy skipped")
@@ -122,10 +134,26 @@ func (d *Dewy) resolveCacheState(_ context.Context, res *registry.CurrentRespons
 	return st, nil
 }
 
+// forceCacheState turns a tick skipped because its tag is already deployed
+// into a redeploy of the cached artifact, staging it locally as
+// resolveCacheState would have.
+func (d *Dewy) forceCacheState(st cacheState) (cacheState, error) {
+	if !st.skip {
+		return st, nil
+	}
+	st.skip = false
+	if _, err := d.cache.Read(st.key); err != nil {
+		return st, fmt.Errorf("failed to load cached artifact: %w", err)
+	}
+	return st, nil
+}
+
 // downloadAndCache fetches the artifact bytes from upstream and writes them
-// to the cache. No-op when the artifact is already staged locally.
+// to the cache. No download happens when the artifact is already staged
+// locally; EventDownloadFinished is published either way.
 func (d *Dewy) downloadAndCache(ctx context.Context, res *registry.CurrentResponse, st cacheState) error {
 	if st.foundInCache {
+		d.publish(ctx, Event{Type: EventDownloadFinished, Tag: res.Tag, Cached: true})
 		return nil
 	}
 
@@ -137,11 +165,16 @@ func (d *Dewy) downloadAndCache(ctx context.Context, res *registry.CurrentRespon
 		}
 		d.artifact = a
 	}
+	start := time.Now()
+	d.publish(ctx, Event{Type: EventDownloadStarted, Tag: res.Tag})
 	err := d.artifact.Download(ctx, &limitedWriter{W: buf, N: MaxArtifactSize})
 	d.artifact = nil
 	if err != nil {
 		return fmt.Errorf("failed artifact.Download: %w", err)
 	}
+	if err := verifyArtifact(res, buf.Bytes()); err != nil {
+		return err
+	}
 
 	if err := d.cache.Write(st.key, buf.Bytes()); err != nil {
 		return fmt.Errorf("failed cache.Write cachekeyName: %w", err)
@@ -150,101 +183,202 @@ func (d *Dewy) downloadAndCache(ctx context.Context, res *registry.CurrentRespon
 		return fmt.Errorf("failed cache.Write currentkeyName: %w", err)
 	}
 	d.logger.Info("Cached artifact", slog.String("cache_key", st.key))
+	d.publish(ctx, Event{Type: EventDownloadFinished, Tag: res.Tag, Duration: time.Since(start)})
 	return nil
 }
 
-// applyDeployment sends the "downloaded" notification and runs the deploy
-// lifecycle (before-hook + extract + symlink swap + after-hook lives inside
-// d.deploy).
-func (d *Dewy) applyDeployment(ctx context.Context, res *registry.CurrentResponse, key string) error {
-	msg := fmt.Sprintf("Downloaded artifact for `%s`", res.Tag)
-	d.logger.Info("Download notification", slog.String("message", msg))
-	d.notifier.Send(ctx, msg)
+// verifyArtifact checks a downloaded artifact against the size and checksum
+// the registry announced, if any. Only sha256 digests are supported.
+func verifyArtifact(res *registry.CurrentResponse, data []byte) error {
+	if res.Size > 0 && int64(len(data)) != res.Size {
+		return fmt.Errorf("artifact size mismatch: got %d bytes, want %d", len(data), res.Size)
+	}
+	if res.Checksum == "" {
+		return nil
+	}
+	algo, want, ok := strings.Cut(res.Checksum, ":")
+	if !ok || algo != "sha256" {
+		return fmt.Errorf("unsupported artifact checksum: %s", res.Checksum)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("artifact checksum mismatch: got sha256:%s, want %s", got, res.Checksum)
+	}
+	return nil
+}
 
-	return d.deploy(key)
+// applyDeployment runs the deploy lifecycle (before-hook + extract + template
+// rendering + post-extract hook + symlink swap + after-hook lives inside
+// d.deploy).
+func (d *Dewy) applyDeployment(ctx context.Context, res *registry.CurrentResponse, key string, hc hookContext) error {
+	return d.deploy(ctx, res, key, hc)
 }
 
 // promoteAndReport finalizes a server/assets deploy: saves the version,
-// (re)starts the server for SERVER mode, reports to the registry, and prunes
-// old releases. Errors from Report and keepReleases are logged but do not
-// cause the run to fail, matching the original behavior.
-func (d *Dewy) promoteAndReport(ctx context.Context, res *registry.CurrentResponse) error {
+// (re)starts the server for SERVER mode between the restart hooks, reports
+// to the registry, and prunes old releases. A blocking restart hook failure
+// rolls back to the previous release, as does a new worker that fails its
+// health check. Errors
+// from Report and keepReleases are logged but do not cause the run to fail,
+// matching the original behavior.
+func (d *Dewy) promoteAndReport(ctx context.Context, res *registry.CurrentResponse, hc hookContext) error {
 	d.Lock()
 	d.cVer = res.Tag
 	d.Unlock()
 
 	if d.config.Command == SERVER {
+		if err := d.runHook(ctx, hookBeforeRestart, hc); err != nil {
+			// The server was not touched yet; only current needs to move back.
+			return d.rollback(ctx, hc, err, false)
+		}
 		if err := d.startOrRestartServer(ctx); err != nil {
-			return err
+			return failedIn(phasePromote, err)
+		}
+		if err := d.verifyServerHealth(ctx); err != nil {
+			return d.rollback(ctx, hc, failedIn(phaseHealthCheck, err), true)
+		}
+		if err := d.runHook(ctx, hookAfterRestart, hc); err != nil {
+			return d.rollback(ctx, hc, err, true)
 		}
 	}
 
-	d.reportDeployment(ctx, res)
+	d.reportDeployment(ctx, res, hc)
 
 	d.logger.Info("Keep releases", slog.Int("count", keepReleases))
 	if err := d.keepReleases(); err != nil {
 		d.logger.Error("Keep releases failure", slog.String("error", err.Error()))
 	}
+	d.pruneAppLogs()
 
 	return nil
 }
 
 // startOrRestartServer brings the local server process up: starts it if it
-// is down, restarts it if it is already running. Notifications are sent on
+// is down, restarts it if it is already running. Events are published on
 // success only — the caller surfaces the error.
 func (d *Dewy) startOrRestartServer(ctx context.Context) error {
 	d.RLock()
 	running := d.isServerRunning
+	crashed := d.crashes.consecutive > 0
 	d.RUnlock()
 
+	e := Event{Type: EventRestarted, Reason: "deploy"}
 	var err error
 	if running {
 		err = d.restartServer()
-		if err == nil {
-			d.recordServerRestart(ctx, "deploy")
-			msg := fmt.Sprintf("Server restarted for `%s`", d.cVer)
-			if len(d.config.Starter.Ports()) == 0 {
-				msg += " without port"
-			}
-			d.logger.Info("Restart notification", slog.String("message", msg))
-			d.notifier.SendImportant(ctx, msg)
-		}
 	} else {
 		err = d.startServer()
-		if err == nil {
-			msg := fmt.Sprintf("Server started for `%s`", d.cVer)
-			if len(d.config.Starter.Ports()) == 0 {
-				msg += " without port"
-			}
-			d.logger.Info("Start notification", slog.String("message", msg))
-			d.notifier.SendImportant(ctx, msg)
+		e.Type, e.Reason = EventStarted, ""
+		if crashed {
+			e.Type, e.Reason = EventRestarted, "crash"
 		}
 	}
 	if err != nil {
 		d.logger.Error("Server failure", slog.String("error", err.Error()))
 		return err
 	}
+	e.Tag = d.currentVersion()
+	d.publish(ctx, e)
 	return nil
 }
 
+// Deploy phases, as reported to the registry in ReportRequest.Phase.
+const (
+	phaseDownload    = "download"     // fetching the artifact or pulling the image
+	phaseExtract     = "extract"      // unpacking the release directory
+	phaseRender      = "render"       // rendering templates into the release
+	phaseHook        = "hook"         // a blocking hook or webhook
+	phasePromote     = "promote"      // taking the release live: symlink swap, server start, rollout
+	phaseHealthCheck = "health-check" // verifying the new server worker
+)
+
+// phaseError is a deploy error tagged with the phase it happened in.
+type phaseError struct {
+	phase string
+	err   error
+}
+
+func (e *phaseError) Error() string { return e.err.Error() }
+func (e *phaseError) Unwrap() error { return e.err }
+
+// failedIn tags err with phase. A nil err stays nil, and an error already
+// tagged keeps its innermost phase.
+func failedIn(phase string, err error) error {
+	var pe *phaseError
+	if err == nil || errors.As(err, &pe) {
+		return err
+	}
+	return &phaseError{phase: phase, err: err}
+}
+
+// deployPhase returns the phase err was tagged with, or "" if none.
+func deployPhase(err error) string {
+	var pe *phaseError
+	if errors.As(err, &pe) {
+		return pe.phase
+	}
+	return ""
+}
+
 // reportDeployment reports the deployment to the registry unless the dewy
 // instance has report shipping disabled. Errors are logged but not returned.
-func (d *Dewy) reportDeployment(ctx context.Context, res *registry.CurrentResponse) {
+func (d *Dewy) reportDeployment(ctx context.Context, res *registry.CurrentResponse, hc hookContext) {
+	d.shipReport(ctx, res, hc, phasePromote, nil)
+}
+
+// reportDeploymentFailure reports a failed deploy, so the registry records
+// it as failed in the phase deployErr was tagged with.
+func (d *Dewy) reportDeploymentFailure(ctx context.Context, res *registry.CurrentResponse, hc hookContext, deployErr error) {
+	d.shipReport(ctx, res, hc, deployPhase(deployErr), deployErr)
+}
+
+// instanceID tells apart dewy processes reporting from the same host. It
+// changes with every start.
+var instanceID = rand.Text()
+
+func (d *Dewy) shipReport(ctx context.Context, res *registry.CurrentResponse, hc hookContext, phase string, deployErr error) {
 	if d.disableReport {
 		return
 	}
 	d.logger.Debug("Report shipping")
-	if err := d.registry.Report(ctx, &registry.ReportRequest{
-		ID:      res.ID,
-		Tag:     res.Tag,
-		Command: d.config.Command.String(),
-	}); err != nil {
+	hostname, _ := os.Hostname()
+	req := &registry.ReportRequest{
+		ID:          res.ID,
+		Tag:         res.Tag,
+		Command:     d.config.Command.String(),
+		Err:         deployErr,
+		Hostname:    hostname,
+		InstanceID:  instanceID,
+		PreviousTag: hc.PreviousTag,
+		Phase:       phase,
+	}
+	if !hc.Started.IsZero() {
+		req.Duration = time.Since(hc.Started)
+	}
+	if err := d.registry.Report(ctx, req); err != nil {
 		d.logger.Error("Report shipping failure", slog.String("error", err.Error()))
 	}
 }
 
 // ----- container path phases ----------------------------------------------------
 
+// containerHookContext builds the hook context for a container deploy.
+// Container deploys have no release directory.
+func (d *Dewy) containerHookContext(res *registry.CurrentResponse) hookContext {
+	replicas := d.config.Container.Replicas
+	if replicas <= 0 {
+		replicas = 1
+	}
+	return hookContext{
+		Tag:         res.Tag,
+		PreviousTag: d.currentVersion(),
+		ArtifactURL: res.ArtifactURL,
+		Slot:        res.Slot,
+		Replicas:    replicas,
+		Started:     time.Now(),
+	}
+}
+
 // resolveContainerCurrent fetches the latest image and applies slot
 // filtering. Unlike resolveCurrent, the container path does not apply the
 // artifact-not-found grace period: OCI registries do not surface
@@ -323,32 +457,28 @@ func (d *Dewy) pullContainerImage(ctx context.Context, res *registry.CurrentResp
 		d.artifact = a
 	}
 
+	start := time.Now()
+	d.publish(ctx, Event{Type: EventDownloadStarted, Tag: res.Tag})
 	buf := new(bytes.Buffer)
 	err := d.artifact.Download(ctx, buf)
 	d.artifact = nil
 	if err != nil {
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
-
-	msg := fmt.Sprintf("Pulled image for `%s`", res.Tag)
-	d.logger.Info("Pull notification", slog.String("message", msg))
-	d.notifier.Send(ctx, msg)
+	d.publish(ctx, Event{Type: EventDownloadFinished, Tag: res.Tag, Duration: time.Since(start)})
 	return nil
 }
 
 // applyContainerDeployment runs the before-hook and the rolling deployment,
 // records telemetry, and returns the number of replicas successfully
-// deployed. The runtime is the one resolveContainerState already created
+// deployed. A blocking before-hook failure aborts before any container is
+// touched. The runtime is the one resolveContainerState already created
 // (and pullContainerImage already used); deployContainer reuses it rather
 // than creating a duplicate. The after-hook runs in promoteContainerAndReport
 // so it only fires once the deploy is considered final.
-func (d *Dewy) applyContainerDeployment(ctx context.Context, res *registry.CurrentResponse, st containerState) (int, error) {
-	beforeResult, beforeErr := d.execHook(d.config.BeforeDeployHook)
-	if beforeResult != nil {
-		d.notifier.SendHookResult(ctx, "Before Deploy", beforeResult)
-	}
-	if beforeErr != nil {
-		d.logger.Error("Before deploy hook failure", slog.String("error", beforeErr.Error()))
+func (d *Dewy) applyContainerDeployment(ctx context.Context, res *registry.CurrentResponse, st containerState, hc hookContext) (int, error) {
+	if err := d.runHook(ctx, hookBeforeDeploy, hc); err != nil {
+		return 0, err
 	}
 
 	deployStart := time.Now()
@@ -358,7 +488,7 @@ func (d *Dewy) applyContainerDeployment(ctx context.Context, res *registry.Curre
 		d.logger.Error("Container deployment failed",
 			slog.Int("deployed", deployedCount),
 			slog.String("error", err.Error()))
-		return deployedCount, err
+		return deployedCount, failedIn(phasePromote, err)
 	}
 	return deployedCount, nil
 }
@@ -366,29 +496,24 @@ func (d *Dewy) applyContainerDeployment(ctx context.Context, res *registry.Curre
 // promoteContainerAndReport finalizes a container deploy: saves cVer, runs
 // the after-hook, reports to the registry, sends the success notification,
 // and prunes old images. Failures of the post-deploy steps are logged but
-// not returned, matching the original behavior.
-func (d *Dewy) promoteContainerAndReport(ctx context.Context, res *registry.CurrentResponse, deployedCount int, imageRef string) error {
+// not returned, matching the original behavior. The exception is a blocking
+// after-hook: the new replicas already serve traffic, so there is nothing to
+// roll back to, but the failure is surfaced as a failed deploy.
+func (d *Dewy) promoteContainerAndReport(ctx context.Context, res *registry.CurrentResponse, deployedCount int, imageRef string, hc hookContext) error {
 	d.Lock()
 	d.cVer = res.Tag
 	d.Unlock()
 
-	afterResult, afterErr := d.execHook(d.config.AfterDeployHook)
-	if afterResult != nil {
-		d.notifier.SendHookResult(ctx, "After Deploy", afterResult)
-	}
-	if afterErr != nil {
-		d.logger.Error("After deploy hook failure", slog.String("error", afterErr.Error()))
+	if err := d.runHook(ctx, hookAfterDeploy, hc); err != nil {
+		return err
 	}
 
-	d.reportDeployment(ctx, res)
+	d.reportDeployment(ctx, res, hc)
 
-	totalReplicas := d.config.Container.Replicas
-	if totalReplicas <= 0 {
-		totalReplicas = 1
-	}
-	msg := fmt.Sprintf("Container deployed successfully: `%d/%d` replicas of `%s`", deployedCount, totalReplicas, d.cVer)
+	totalReplicas := max(d.config.Container.Replicas, 1)
+	msg := fmt.Sprintf("Container deployed successfully: `%d/%d` replicas of `%s`", deployedCount, totalReplicas, res.Tag)
 	d.logger.Info("Container deployed successfully",
-		slog.String("version", d.cVer),
+		slog.String("version", res.Tag),
 		slog.Int("replicas", deployedCount),
 		slog.Int("total", totalReplicas))
 	d.notifier.SendImportant(ctx, msg)
@@ -397,5 +522,8 @@ func (d *Dewy) promoteContainerAndReport(ctx context.Context, res *registry.Curr
 	if err := d.cleanupOldImages(ctx, imageRef); err != nil {
 		d.logger.Error("Keep images failure", slog.String("error", err.Error()))
 	}
+
+	d.followContainerLogs(ctx)
+	d.pruneAppLogs()
 	return nil
 }
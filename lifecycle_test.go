@@ -2,7 +2,13 @@ package dewy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -246,7 +252,7 @@ func TestPromoteAndReport_DisableReport(t *testing.T) {
 	d.notifier = notify
 
 	res := &registry.CurrentResponse{ID: "id-1", Tag: "v1.0.0"}
-	if err := d.promoteAndReport(context.Background(), res); err != nil {
+	if err := d.promoteAndReport(context.Background(), res, hookContext{}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 	if reportCalled {
@@ -271,7 +277,7 @@ func TestPromoteAndReport_ReportEnabled(t *testing.T) {
 	d.notifier = &mockNotify{}
 
 	res := &registry.CurrentResponse{ID: "id-2", Tag: "v2.0.0"}
-	if err := d.promoteAndReport(context.Background(), res); err != nil {
+	if err := d.promoteAndReport(context.Background(), res, hookContext{}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 	if got == nil {
@@ -280,6 +286,36 @@ func TestPromoteAndReport_ReportEnabled(t *testing.T) {
 	if got.ID != "id-2" || got.Tag != "v2.0.0" || got.Command != "assets" {
 		t.Errorf("ReportRequest = %+v, want {id-2, v2.0.0, assets}", got)
 	}
+	if got.Phase != "promote" || got.Err != nil {
+		t.Errorf("ReportRequest = %+v, want a successful promote", got)
+	}
+}
+
+func TestPromoteContainerAndReport_Notifies(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	d.config.Command = CONTAINER
+	d.config.Container = &ContainerConfig{Replicas: 3}
+	var reported bool
+	d.registry = &mockRegistry{
+		reportFunc: func(ctx context.Context, _ *registry.ReportRequest) error {
+			reported = true
+			return nil
+		},
+	}
+	notify := &mockNotify{}
+	d.notifier = notify
+
+	res := &registry.CurrentResponse{ID: "id-3", Tag: "v3.0.0"}
+	if err := d.promoteContainerAndReport(context.Background(), res, 2, "app:v3.0.0", hookContext{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.cVer != "v3.0.0" || !reported {
+		t.Errorf("cVer = %q, reported = %v, want v3.0.0 reported", d.cVer, reported)
+	}
+	want := []string{"Container deployed successfully: `2/3` replicas of `v3.0.0`"}
+	if got := notify.GetMessages(); !slices.Equal(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
 }
 
 func TestReportDeployment_DisableReport(t *testing.T) {
@@ -292,8 +328,76 @@ func TestReportDeployment_DisableReport(t *testing.T) {
 			return nil
 		},
 	}
-	d.reportDeployment(context.Background(), &registry.CurrentResponse{Tag: "v1"})
+	d.reportDeployment(context.Background(), &registry.CurrentResponse{Tag: "v1"}, hookContext{})
 	if called {
 		t.Error("Report should not be called when disabled")
 	}
 }
+
+func TestVerifyArtifact(t *testing.T) {
+	data := []byte("artifact")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name    string
+		res     registry.CurrentResponse
+		wantErr string
+	}{
+		{"unannounced", registry.CurrentResponse{}, ""},
+		{"match", registry.CurrentResponse{Checksum: "sha256:" + digest, Size: int64(len(data))}, ""},
+		{"upper-case digest", registry.CurrentResponse{Checksum: "sha256:" + strings.ToUpper(digest)}, ""},
+		{"checksum mismatch", registry.CurrentResponse{Checksum: "sha256:" + strings.Repeat("0", 64)}, "checksum mismatch"},
+		{"size mismatch", registry.CurrentResponse{Checksum: "sha256:" + digest, Size: 3}, "size mismatch"},
+		{"unsupported", registry.CurrentResponse{Checksum: "md5:abc"}, "unsupported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyArtifact(&tt.res, data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun_FileRegistry(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "v1.0.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "v1.0.0", "app.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &mockArtifact{binary: "dewy", url: "file v1.0.0"}
+	if err := a.Download(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d.registry, err = registry.New(context.Background(), "file://"+filepath.ToSlash(dir)+"?artifact=app.zip", d.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(d.root, symlinkDir, "dewy"))
+	if err != nil || string(b) != "file v1.0.0" {
+		t.Errorf("deployed binary = %q, %v", b, err)
+	}
+	records, _ := filepath.Glob(filepath.Join(dir, ".dewy-reports", "v1.0.0", "shipped_to_*_assets_at_*.txt"))
+	if len(records) != 1 {
+		t.Errorf("report records = %v, want 1", records)
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "logs", "app.log")
+	r, err := NewRotatingFile(p, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		p:        "fourth\n",
+		p + ".1": "third\n",
+		p + ".2": "second\n",
+	}
+	for path, content := range want {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), b, content)
+		}
+	}
+	if _, err := os.Stat(p + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, stat .3: %v", err)
+	}
+}
+
+func TestRotatingFile_AppendsToExisting(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(p, []byte("12345678"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRotatingFile(p, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	b, _ := os.ReadFile(p + ".1")
+	if string(b) != "12345678" {
+		t.Errorf("existing content should count toward the limit; backup = %q", b)
+	}
+	if _, err := r.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("write after close: err = %v, want os.ErrClosed", err)
+	}
+}
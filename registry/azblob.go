@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	azblobFormat string = "azblob://<account>/<container>/<prefix>"
+)
+
+// AzBlob is a registry on Azure Blob Storage with the S3 and GS layout: one
+// virtual directory per version under prefix, holding the artifacts.
+type AzBlob struct {
+	Account    string `schema:"-"`
+	Container  string `schema:"-"`
+	Prefix     string `schema:"-"`
+	Endpoint   string `schema:"endpoint"`
+	Artifact   string `schema:"artifact"`
+	PreRelease bool   `schema:"pre-release"`
+	CalVer     string `schema:"calver"`
+	cl         *client.AzureBlob
+	logger     *logging.Logger
+}
+
+// NewAzBlob returns AzBlob. Credentials are resolved by client.NewAzureBlob;
+// endpoint points at Azurite or a sovereign cloud.
+func NewAzBlob(ctx context.Context, u string, log *logging.Logger) (*AzBlob, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	splitted := strings.SplitN(strings.TrimPrefix(ur.Path, "/"), "/", 2)
+	a := &AzBlob{
+		Account:   ur.Host,
+		Container: splitted[0],
+	}
+	if len(splitted) > 1 {
+		a.Prefix = strings.TrimPrefix(addTrailingSlash(splitted[1]), "/")
+	}
+	if err := decoder.Decode(a, ur.Query()); err != nil {
+		return nil, err
+	}
+
+	if a.Account == "" {
+		return nil, fmt.Errorf("account is required: %s", azblobFormat)
+	}
+	if a.Container == "" {
+		return nil, fmt.Errorf("container is required: %s", azblobFormat)
+	}
+
+	a.cl, err = client.NewAzureBlob(a.Account, a.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger = log
+	return a, nil
+}
+
+// Current returns current artifact.
+func (a *AzBlob) Current(ctx context.Context) (*CurrentResponse, error) {
+	prefix, version, err := a.LatestVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, _, err := a.cl.List(ctx, a.Container, prefix, "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	var names []string
+	blobMap := make(map[string]client.Blob)
+	for _, b := range blobs {
+		name := strings.TrimPrefix(b.Name, prefix)
+		names = append(names, name)
+		blobMap[name] = b
+	}
+
+	artifactName, found := "", false
+	if a.Artifact != "" {
+		_, found = blobMap[a.Artifact]
+		artifactName = a.Artifact
+	} else {
+		artifactName, found = MatchArtifactByPlatform(names)
+	}
+	if !found {
+		// The oldest blob of the version stands in for when it was created.
+		var releaseTime *time.Time
+		for _, b := range blobs {
+			if t := b.LastModified; releaseTime == nil || t.Before(*releaseTime) {
+				releaseTime = &t
+			}
+		}
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: prefix + artifactName,
+			ReleaseTime:  releaseTime,
+			Message:      fmt.Sprintf("artifact not found: %s%s", prefix, artifactName),
+		}
+	}
+
+	b := blobMap[artifactName]
+	a.logger.Debug("Fetched blob", slog.String("name", b.Name))
+
+	return &CurrentResponse{
+		ID:          time.Now().Format(ISO8601),
+		Tag:         version.String(),
+		ArtifactURL: a.buildArtifactURL(b.Name),
+		CreatedAt:   &b.LastModified,
+		Slot:        version.GetBuildMetadata(),
+		Size:        b.Size,
+	}, nil
+}
+
+func (a *AzBlob) buildArtifactURL(name string) string {
+	var qstr string
+	if a.Endpoint != "" {
+		qstr = "?" + url.Values{"endpoint": {a.Endpoint}}.Encode()
+	}
+	return fmt.Sprintf("%s://%s/%s/%s%s", scheme.AzBlob, a.Account, a.Container, name, qstr)
+}
+
+// LatestVersion returns the prefix and version of the latest release.
+func (a *AzBlob) LatestVersion(ctx context.Context) (string, Version, error) {
+	_, prefixes, err := a.cl.List(ctx, a.Container, a.Prefix, "/")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	var tags []string
+	for _, p := range prefixes {
+		tags = append(tags, strings.TrimSuffix(strings.TrimPrefix(p, a.Prefix), "/"))
+	}
+
+	var version Version
+	var tag string
+	if a.CalVer != "" {
+		version, tag, err = FindLatestCalVer(tags, a.CalVer, a.PreRelease)
+	} else {
+		version, tag, err = FindLatestSemVer(tags, a.PreRelease)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return a.Prefix + tag + "/", version, nil
+}
+
+// Report report shipping.
+func (a *AzBlob) Report(ctx context.Context, req *ReportRequest) error {
+	if req.Err != nil {
+		return req.Err
+	}
+
+	now := time.Now().UTC().Format(iso8601Nano)
+	hostname := req.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	info := fmt.Sprintf("shipped to %s %s at %s", strings.ToLower(hostname), req.Command, now)
+	filename := fmt.Sprintf("%s.txt", strings.ReplaceAll(info, " ", "_"))
+	_, err := a.cl.Put(ctx, a.Container, fmt.Sprintf("%s%s/%s", a.Prefix, req.Tag, filename), nil, "")
+
+	return err
+}
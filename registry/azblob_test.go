@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newBlobListingServer fakes the list and put operations of an anonymous
+// Blob container holding names, and records the blobs put to it.
+func newBlobListingServer(t *testing.T, names ...string) (endpoint string, puts *[]string) {
+	t.Helper()
+	puts = new([]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			*puts = append(*puts, strings.TrimPrefix(r.URL.Path, "/acct/releases/"))
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.URL.Path != "/acct/releases" || r.URL.Query().Get("comp") != "list" {
+			http.NotFound(w, r)
+			return
+		}
+		prefix := r.URL.Query().Get("prefix")
+		var b strings.Builder
+		b.WriteString(`<EnumerationResults><Blobs>`)
+		seen := map[string]bool{}
+		for _, n := range names {
+			if !strings.HasPrefix(n, prefix) {
+				continue
+			}
+			if dir, _, ok := strings.Cut(n[len(prefix):], "/"); ok {
+				if !seen[dir] {
+					seen[dir] = true
+					fmt.Fprintf(&b, `<BlobPrefix><Name>%s%s/</Name></BlobPrefix>`, prefix, dir)
+				}
+				continue
+			}
+			fmt.Fprintf(&b, `<Blob><Name>%s</Name><Properties><Last-Modified>Sun, 01 Mar 2026 12:00:00 GMT</Last-Modified><Content-Length>42</Content-Length></Properties></Blob>`, n)
+		}
+		b.WriteString(`</Blobs><NextMarker/></EnumerationResults>`)
+		_, _ = w.Write([]byte(b.String()))
+	}))
+	t.Cleanup(ts.Close)
+	for _, k := range []string{"AZURE_STORAGE_CONNECTION_STRING", "AZURE_STORAGE_KEY", "AZURE_STORAGE_SAS_TOKEN"} {
+		t.Setenv(k, "")
+	}
+	return ts.URL + "/acct", puts
+}
+
+func TestNewAzBlob(t *testing.T) {
+	ctx := context.Background()
+	a, err := NewAzBlob(ctx, "azblob://acct/releases/team/app?pre-release=true&endpoint=http://127.0.0.1:10000/acct", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Account != "acct" || a.Container != "releases" || a.Prefix != "team/app/" || !a.PreRelease || a.Endpoint != "http://127.0.0.1:10000/acct" {
+		t.Errorf("got %+v", a)
+	}
+	for _, u := range []string{"azblob:///releases", "azblob://acct"} {
+		if _, err := NewAzBlob(ctx, u, testLogger()); err == nil {
+			t.Errorf("NewAzBlob(%q) succeeded", u)
+		}
+	}
+}
+
+func TestAzBlobCurrentAndReport(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	endpoint, puts := newBlobListingServer(t,
+		"app/v1.0.0/app_linux_amd64.tar.gz",
+		"app/v1.1.0/app_darwin_arm64.tar.gz",
+		"app/v1.1.0/app_linux_amd64.tar.gz",
+		"app/v1.2.0-rc.1/app_linux_amd64.tar.gz",
+		"app/v1.3.0/app_darwin_arm64.tar.gz",
+	)
+	ctx := context.Background()
+
+	a, err := NewAzBlob(ctx, "azblob://acct/releases/app?endpoint="+endpoint, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.Current(ctx)
+	var notFound *ArtifactNotFoundError
+	if !errors.As(err, &notFound) || notFound.ReleaseTime == nil {
+		t.Fatalf("err = %v, want ArtifactNotFoundError for v1.3.0", err)
+	}
+
+	a.Artifact = "app_darwin_arm64.tar.gz"
+	res, err := a.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.3.0" || res.Size != 42 || res.CreatedAt == nil || !strings.HasPrefix(res.ArtifactURL, "azblob://acct/releases/app/v1.3.0/app_darwin_arm64.tar.gz?endpoint=") {
+		t.Errorf("got %+v", res)
+	}
+
+	if err := a.Report(ctx, &ReportRequest{Tag: "v1.3.0", Command: "server", Hostname: "Web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*puts) != 1 || !strings.HasPrefix((*puts)[0], "app/v1.3.0/shipped_to_web-1_server_at_") {
+		t.Errorf("puts = %v", *puts)
+	}
+}
@@ -220,6 +220,24 @@ func (c *Cached) Current(ctx context.Context) (*CurrentResponse, error) {
 	}
 }
 
+// Store publishes res, pushed by the upstream registry, as a fresh cache
+// entry, so that Current here and on the peers returns it instead of a
+// response cached before the push. It overrides a peer's refresh in flight.
+func (c *Cached) Store(res *CurrentResponse) error {
+	entry := &cachedEntry{Response: res}
+	for range 3 {
+		_, version, err := c.readEntry()
+		if err != nil && !cache.IsNotFound(err) && version == "" {
+			return err
+		}
+		entry.FetchedAt = c.clock.Now()
+		if _, err = c.writeEntry(entry, version); err == nil || !cache.IsConflict(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("store pushed registry result: %w", cache.ErrConflict)
+}
+
 // sleepCtx waits d using the injected clock, returning early on ctx cancel.
 func (c *Cached) sleepCtx(ctx context.Context, d time.Duration) error {
 	t := c.clock.NewTimer(d)
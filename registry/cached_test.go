@@ -191,6 +191,34 @@ func TestCachedRefreshesAfterTTL(t *testing.T) {
 	}
 }
 
+func TestCachedStorePushed(t *testing.T) {
+	c, upstream, fakeCache := newCachedForTest(t, time.Hour)
+	if _, err := c.Current(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := &CurrentResponse{ID: "id", Tag: "v2.0.0", ArtifactURL: "https://example.com/v2.0.0.tar.gz"}
+	if err := c.Store(pushed); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	// This instance and a peer sharing the cache both see the push within
+	// the TTL, without another upstream call.
+	peer := NewCached(upstream, "ghr://test/scope", fakeCache, time.Hour, testLogger())
+	for _, r := range []*Cached{c, peer} {
+		res, err := r.Current(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Tag != "v2.0.0" {
+			t.Errorf("tag = %q, want the pushed v2.0.0", res.Tag)
+		}
+	}
+	if got := upstream.Calls(); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
+
 func TestCachedSharedAcrossInstances(t *testing.T) {
 	// Two Cached instances share one fake cache; only one of them should
 	// hit upstream per TTL window.
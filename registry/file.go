@@ -0,0 +1,183 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	fileFormat string = "file:///<dir>"
+	// fileReportsDir is the directory under Dir that Report writes to, kept
+	// apart from the version directories so records are never served or
+	// copied along with the artifacts.
+	fileReportsDir = ".dewy-reports"
+)
+
+// File is a registry on the local filesystem, laid out like the S3 and GS
+// registries: one subdirectory per version holding the artifacts. It needs no
+// network, which suits air-gapped hosts and end-to-end tests.
+type File struct {
+	Dir        string `schema:"-"`
+	Artifact   string `schema:"artifact"`
+	PreRelease bool   `schema:"pre-release"`
+	CalVer     string `schema:"calver"`
+	logger     *logging.Logger
+}
+
+// NewFile returns File.
+func NewFile(ctx context.Context, u string, log *logging.Logger) (*File, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if ur.Host != "" && ur.Host != "localhost" {
+		return nil, fmt.Errorf("host is not supported: %s", fileFormat)
+	}
+	if ur.Path == "" {
+		return nil, fmt.Errorf("directory is required: %s", fileFormat)
+	}
+
+	f := &File{
+		Dir:    filepath.FromSlash(ur.Path),
+		logger: log,
+	}
+	if err := decoder.Decode(f, ur.Query()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Current returns current artifact.
+func (f *File) Current(ctx context.Context) (*CurrentResponse, error) {
+	tag, version, err := f.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(f.Dir, tag))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+
+	name, found := "", false
+	if f.Artifact != "" {
+		for _, n := range names {
+			if n == f.Artifact {
+				name, found = n, true
+				break
+			}
+		}
+	} else {
+		name, found = MatchArtifactByPlatform(names)
+	}
+	if !found {
+		var releaseTime *time.Time
+		if info, err := os.Stat(filepath.Join(f.Dir, tag)); err == nil {
+			t := info.ModTime()
+			releaseTime = &t
+		}
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: tag + "/" + f.Artifact,
+			ReleaseTime:  releaseTime,
+			Message:      fmt.Sprintf("artifact not found in %s", filepath.Join(f.Dir, tag)),
+		}
+	}
+
+	path := filepath.Join(f.Dir, tag, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := info.ModTime()
+	f.logger.Debug("Fetched file artifact", slog.String("path", path))
+
+	return &CurrentResponse{
+		ID:          time.Now().Format(ISO8601),
+		Tag:         version.String(),
+		ArtifactURL: (&url.URL{Scheme: scheme.File, Path: filepath.ToSlash(path)}).String(),
+		CreatedAt:   &createdAt,
+		Slot:        version.GetBuildMetadata(),
+		Size:        info.Size(),
+	}, nil
+}
+
+// LatestVersion returns the directory name and version of the latest release.
+func (f *File) LatestVersion() (string, Version, error) {
+	entries, err := os.ReadDir(f.Dir)
+	if err != nil {
+		return "", nil, err
+	}
+	var tags []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			tags = append(tags, e.Name())
+		}
+	}
+
+	var version Version
+	var tag string
+	if f.CalVer != "" {
+		version, tag, err = FindLatestCalVer(tags, f.CalVer, f.PreRelease)
+	} else {
+		version, tag, err = FindLatestSemVer(tags, f.PreRelease)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return tag, version, nil
+}
+
+// Report writes a record of the deployment to .dewy-reports/<tag>/ under Dir,
+// named like the objects the S3 and GS registries put.
+func (f *File) Report(ctx context.Context, req *ReportRequest) error {
+	if req.Err != nil {
+		return req.Err
+	}
+
+	now := time.Now().UTC()
+	hostname := req.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	info := fmt.Sprintf("shipped to %s %s at %s", strings.ToLower(hostname), req.Command, now.Format(iso8601Nano))
+	filename := fmt.Sprintf("%s.txt", strings.ReplaceAll(info, " ", "_"))
+
+	r := Report{
+		Time:        now,
+		ID:          req.ID,
+		Tag:         req.Tag,
+		Command:     req.Command,
+		Hostname:    hostname,
+		InstanceID:  req.InstanceID,
+		PreviousTag: req.PreviousTag,
+		Phase:       req.Phase,
+	}
+	if req.Duration > 0 {
+		r.Duration = req.Duration.String()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(f.Dir, fileReportsDir, req.Tag)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, filename), append(b, '\n'), 0644)
+}
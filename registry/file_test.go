@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFile(t *testing.T) {
+	ctx := context.Background()
+	f, err := NewFile(ctx, "file:///srv/releases/?artifact=app.zip&pre-release=true", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Dir != filepath.FromSlash("/srv/releases/") || f.Artifact != "app.zip" || !f.PreRelease {
+		t.Errorf("got %+v", f)
+	}
+	for _, u := range []string{"file://", "file://host/srv/releases"} {
+		if _, err := NewFile(ctx, u, testLogger()); err == nil {
+			t.Errorf("NewFile(%q) succeeded", u)
+		}
+	}
+}
+
+func TestFileCurrentAndReport(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_linux_amd64.tar.gz")
+	writeRelease(t, dir, "v1.1.0", "app_darwin_arm64.tar.gz", "app_linux_amd64.tar.gz")
+	writeRelease(t, dir, "v1.2.0-rc.1", "app_linux_amd64.tar.gz")
+	ctx := context.Background()
+
+	f, err := NewFile(ctx, "file://"+dir, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "file://" + filepath.ToSlash(filepath.Join(dir, "v1.1.0", "app_linux_amd64.tar.gz"))
+	if res.Tag != "v1.1.0" || res.ArtifactURL != want || res.CreatedAt == nil || res.Size != int64(len("v1.1.0/app_linux_amd64.tar.gz")) {
+		t.Errorf("got %+v, want %s", res, want)
+	}
+
+	err = f.Report(ctx, &ReportRequest{Tag: "v1.1.0", Command: "server", Hostname: "Web-1",
+		PreviousTag: "v1.0.0", Duration: 3 * time.Second, Phase: "promote"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, _ := filepath.Glob(filepath.Join(dir, ".dewy-reports", "v1.1.0", "shipped_to_web-1_server_at_*.txt"))
+	if len(records) != 1 {
+		t.Fatalf("records = %v", records)
+	}
+	if inVersion, _ := filepath.Glob(filepath.Join(dir, "v1.1.0", "shipped_to_*")); len(inVersion) != 0 {
+		t.Errorf("records in the version directory: %v", inVersion)
+	}
+	b, err := os.ReadFile(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Hostname != "Web-1" || r.PreviousTag != "v1.0.0" || r.Duration != "3s" || r.Phase != "promote" {
+		t.Errorf("record = %+v", r)
+	}
+
+	// The record must not be mistaken for an artifact on the next poll.
+	if res, err := f.Current(ctx); err != nil || res.ArtifactURL != want {
+		t.Errorf("after report got %+v, %v", res, err)
+	}
+
+	failed := errors.New("health check failed")
+	if err := f.Report(ctx, &ReportRequest{Tag: "v1.1.0", Err: failed}); err != failed {
+		t.Errorf("err = %v, want the deploy error", err)
+	}
+}
+
+func TestFileCurrentNotFound(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_darwin_arm64.tar.gz")
+
+	f, err := NewFile(context.Background(), "file://"+dir, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Current(context.Background())
+	var notFound *ArtifactNotFoundError
+	if !errors.As(err, &notFound) || notFound.ReleaseTime == nil || !strings.Contains(err.Error(), "v1.0.0") {
+		t.Errorf("err = %v, want ArtifactNotFoundError for v1.0.0", err)
+	}
+}
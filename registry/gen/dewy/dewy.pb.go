@@ -9,6 +9,7 @@ package dewy
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -87,10 +88,15 @@ func (x *CurrentRequest) GetArifactName() string {
 // CurrentResponse is the response to get the current artifact.
 type CurrentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                      // id uniquely identifies the response.
-	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`                                    // tag uniquely identifies the artifact concerned.
-	ArtifactUrl   string                 `protobuf:"bytes,3,opt,name=artifact_url,json=artifactUrl,proto3" json:"artifact_url,omitempty"` // artifact_url is the URL to download the artifact.
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3,oneof" json:"created_at,omitempty"` // created_at is the creation time of the release.
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                                                                   // id uniquely identifies the response.
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`                                                                                 // tag uniquely identifies the artifact concerned.
+	ArtifactUrl   string                 `protobuf:"bytes,3,opt,name=artifact_url,json=artifactUrl,proto3" json:"artifact_url,omitempty"`                                              // artifact_url is the URL to download the artifact.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3,oneof" json:"created_at,omitempty"`                                              // created_at is the creation time of the release.
+	Slot          *string                `protobuf:"bytes,5,opt,name=slot,proto3,oneof" json:"slot,omitempty"`                                                                         // slot is the deployment slot. If unset, it is taken from the tag's build metadata.
+	Checksum      *string                `protobuf:"bytes,6,opt,name=checksum,proto3,oneof" json:"checksum,omitempty"`                                                                 // checksum is the digest of the artifact, as "sha256:<hex>".
+	Size          *int64                 `protobuf:"varint,7,opt,name=size,proto3,oneof" json:"size,omitempty"`                                                                        // size is the size of the artifact in bytes.
+	Labels        map[string]string      `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // labels are arbitrary metadata of the release.
+	ReleaseNotes  *string                `protobuf:"bytes,9,opt,name=release_notes,json=releaseNotes,proto3,oneof" json:"release_notes,omitempty"`                                     // release_notes describes the release.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -153,13 +159,53 @@ func (x *CurrentResponse) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *CurrentResponse) GetSlot() string {
+	if x != nil && x.Slot != nil {
+		return *x.Slot
+	}
+	return ""
+}
+
+func (x *CurrentResponse) GetChecksum() string {
+	if x != nil && x.Checksum != nil {
+		return *x.Checksum
+	}
+	return ""
+}
+
+func (x *CurrentResponse) GetSize() int64 {
+	if x != nil && x.Size != nil {
+		return *x.Size
+	}
+	return 0
+}
+
+func (x *CurrentResponse) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *CurrentResponse) GetReleaseNotes() string {
+	if x != nil && x.ReleaseNotes != nil {
+		return *x.ReleaseNotes
+	}
+	return ""
+}
+
 // ReportRequest is the request to report the result of deploying the artifact.
 type ReportRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`           // id is the ID of the response.
-	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`         // tag is the current tag of deployed artifact.
-	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"` // command is the command that was used for deployment (server or assets).
-	Err           *string                `protobuf:"bytes,4,opt,name=err,proto3,oneof" json:"err,omitempty"`   // err is the error that occurred during deployment. If Err is nil, the deployment is considered successful.
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                            // id is the ID of the response.
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`                                          // tag is the current tag of deployed artifact.
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`                                  // command is the command that was used for deployment (server or assets).
+	Err           *string                `protobuf:"bytes,4,opt,name=err,proto3,oneof" json:"err,omitempty"`                                    // err is the error that occurred during deployment. If Err is nil, the deployment is considered successful.
+	Hostname      *string                `protobuf:"bytes,5,opt,name=hostname,proto3,oneof" json:"hostname,omitempty"`                          // hostname is the host the artifact was deployed to.
+	InstanceId    *string                `protobuf:"bytes,6,opt,name=instance_id,json=instanceId,proto3,oneof" json:"instance_id,omitempty"`    // instance_id identifies the dewy process that deployed.
+	PreviousTag   *string                `protobuf:"bytes,7,opt,name=previous_tag,json=previousTag,proto3,oneof" json:"previous_tag,omitempty"` // previous_tag is the tag deployed before this one.
+	Duration      *durationpb.Duration   `protobuf:"bytes,8,opt,name=duration,proto3,oneof" json:"duration,omitempty"`                          // duration is how long the deployment took.
+	Phase         *string                `protobuf:"bytes,9,opt,name=phase,proto3,oneof" json:"phase,omitempty"`                                // phase is the deploy phase the outcome was decided in.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -222,33 +268,92 @@ func (x *ReportRequest) GetErr() string {
 	return ""
 }
 
+func (x *ReportRequest) GetHostname() string {
+	if x != nil && x.Hostname != nil {
+		return *x.Hostname
+	}
+	return ""
+}
+
+func (x *ReportRequest) GetInstanceId() string {
+	if x != nil && x.InstanceId != nil {
+		return *x.InstanceId
+	}
+	return ""
+}
+
+func (x *ReportRequest) GetPreviousTag() string {
+	if x != nil && x.PreviousTag != nil {
+		return *x.PreviousTag
+	}
+	return ""
+}
+
+func (x *ReportRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *ReportRequest) GetPhase() string {
+	if x != nil && x.Phase != nil {
+		return *x.Phase
+	}
+	return ""
+}
+
 var File_dewy_proto protoreflect.FileDescriptor
 
 const file_dewy_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"dewy.proto\x12\x04dewy\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"m\n" +
+	"dewy.proto\x12\x04dewy\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"m\n" +
 	"\x0eCurrentRequest\x12\x12\n" +
 	"\x04arch\x18\x01 \x01(\tR\x04arch\x12\x0e\n" +
 	"\x02os\x18\x02 \x01(\tR\x02os\x12&\n" +
 	"\farifact_name\x18\x03 \x01(\tH\x00R\varifactName\x88\x01\x01B\x0f\n" +
-	"\r_arifact_name\"\xa5\x01\n" +
+	"\r_arifact_name\"\xc9\x03\n" +
 	"\x0fCurrentResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
 	"\x03tag\x18\x02 \x01(\tR\x03tag\x12!\n" +
 	"\fartifact_url\x18\x03 \x01(\tR\vartifactUrl\x12>\n" +
 	"\n" +
-	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\tcreatedAt\x88\x01\x01B\r\n" +
-	"\v_created_at\"j\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\tcreatedAt\x88\x01\x01\x12\x17\n" +
+	"\x04slot\x18\x05 \x01(\tH\x01R\x04slot\x88\x01\x01\x12\x1f\n" +
+	"\bchecksum\x18\x06 \x01(\tH\x02R\bchecksum\x88\x01\x01\x12\x17\n" +
+	"\x04size\x18\a \x01(\x03H\x03R\x04size\x88\x01\x01\x129\n" +
+	"\x06labels\x18\b \x03(\v2!.dewy.CurrentResponse.LabelsEntryR\x06labels\x12(\n" +
+	"\rrelease_notes\x18\t \x01(\tH\x04R\freleaseNotes\x88\x01\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\r\n" +
+	"\v_created_atB\a\n" +
+	"\x05_slotB\v\n" +
+	"\t_checksumB\a\n" +
+	"\x05_sizeB\x10\n" +
+	"\x0e_release_notes\"\xf5\x02\n" +
 	"\rReportRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
 	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x18\n" +
 	"\acommand\x18\x03 \x01(\tR\acommand\x12\x15\n" +
-	"\x03err\x18\x04 \x01(\tH\x00R\x03err\x88\x01\x01B\x06\n" +
-	"\x04_err2\x80\x01\n" +
+	"\x03err\x18\x04 \x01(\tH\x00R\x03err\x88\x01\x01\x12\x1f\n" +
+	"\bhostname\x18\x05 \x01(\tH\x01R\bhostname\x88\x01\x01\x12$\n" +
+	"\vinstance_id\x18\x06 \x01(\tH\x02R\n" +
+	"instanceId\x88\x01\x01\x12&\n" +
+	"\fprevious_tag\x18\a \x01(\tH\x03R\vpreviousTag\x88\x01\x01\x12:\n" +
+	"\bduration\x18\b \x01(\v2\x19.google.protobuf.DurationH\x04R\bduration\x88\x01\x01\x12\x19\n" +
+	"\x05phase\x18\t \x01(\tH\x05R\x05phase\x88\x01\x01B\x06\n" +
+	"\x04_errB\v\n" +
+	"\t_hostnameB\x0e\n" +
+	"\f_instance_idB\x0f\n" +
+	"\r_previous_tagB\v\n" +
+	"\t_durationB\b\n" +
+	"\x06_phase2\xb8\x01\n" +
 	"\x0fRegistryService\x126\n" +
 	"\aCurrent\x12\x14.dewy.CurrentRequest\x1a\x15.dewy.CurrentResponse\x125\n" +
-	"\x06Report\x12\x13.dewy.ReportRequest\x1a\x16.google.protobuf.EmptyBp\n" +
+	"\x06Report\x12\x13.dewy.ReportRequest\x1a\x16.google.protobuf.Empty\x126\n" +
+	"\x05Watch\x12\x14.dewy.CurrentRequest\x1a\x15.dewy.CurrentResponse0\x01Bp\n" +
 	"\bcom.dewyB\tDewyProtoP\x01Z)github.com/linyows/dewy/registry/gen/dewy\xa2\x02\x03DXX\xaa\x02\x04Dewy\xca\x02\x04Dewy\xe2\x02\x10Dewy\\GPBMetadata\xea\x02\x04Dewyb\x06proto3"
 
 var (
@@ -263,25 +368,31 @@ func file_dewy_proto_rawDescGZIP() []byte {
 	return file_dewy_proto_rawDescData
 }
 
-var file_dewy_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_dewy_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_dewy_proto_goTypes = []any{
 	(*CurrentRequest)(nil),        // 0: dewy.CurrentRequest
 	(*CurrentResponse)(nil),       // 1: dewy.CurrentResponse
 	(*ReportRequest)(nil),         // 2: dewy.ReportRequest
-	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),         // 4: google.protobuf.Empty
+	nil,                           // 3: dewy.CurrentResponse.LabelsEntry
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 5: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 6: google.protobuf.Empty
 }
 var file_dewy_proto_depIdxs = []int32{
-	3, // 0: dewy.CurrentResponse.created_at:type_name -> google.protobuf.Timestamp
-	0, // 1: dewy.RegistryService.Current:input_type -> dewy.CurrentRequest
-	2, // 2: dewy.RegistryService.Report:input_type -> dewy.ReportRequest
-	1, // 3: dewy.RegistryService.Current:output_type -> dewy.CurrentResponse
-	4, // 4: dewy.RegistryService.Report:output_type -> google.protobuf.Empty
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	4, // 0: dewy.CurrentResponse.created_at:type_name -> google.protobuf.Timestamp
+	3, // 1: dewy.CurrentResponse.labels:type_name -> dewy.CurrentResponse.LabelsEntry
+	5, // 2: dewy.ReportRequest.duration:type_name -> google.protobuf.Duration
+	0, // 3: dewy.RegistryService.Current:input_type -> dewy.CurrentRequest
+	2, // 4: dewy.RegistryService.Report:input_type -> dewy.ReportRequest
+	0, // 5: dewy.RegistryService.Watch:input_type -> dewy.CurrentRequest
+	1, // 6: dewy.RegistryService.Current:output_type -> dewy.CurrentResponse
+	6, // 7: dewy.RegistryService.Report:output_type -> google.protobuf.Empty
+	1, // 8: dewy.RegistryService.Watch:output_type -> dewy.CurrentResponse
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_dewy_proto_init() }
@@ -298,7 +409,7 @@ func file_dewy_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dewy_proto_rawDesc), len(file_dewy_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
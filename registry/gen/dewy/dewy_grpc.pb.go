@@ -22,6 +22,7 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	RegistryService_Current_FullMethodName = "/dewy.RegistryService/Current"
 	RegistryService_Report_FullMethodName  = "/dewy.RegistryService/Report"
+	RegistryService_Watch_FullMethodName   = "/dewy.RegistryService/Watch"
 )
 
 // RegistryServiceClient is the client API for RegistryService service.
@@ -32,6 +33,9 @@ type RegistryServiceClient interface {
 	Current(ctx context.Context, in *CurrentRequest, opts ...grpc.CallOption) (*CurrentResponse, error)
 	// Report reports the result of deploying the artifact.
 	Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Watch streams the current artifact: the latest one first, then each new
+	// one as soon as the registry knows about it.
+	Watch(ctx context.Context, in *CurrentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CurrentResponse], error)
 }
 
 type registryServiceClient struct {
@@ -62,6 +66,25 @@ func (c *registryServiceClient) Report(ctx context.Context, in *ReportRequest, o
 	return out, nil
 }
 
+func (c *registryServiceClient) Watch(ctx context.Context, in *CurrentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CurrentResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RegistryService_ServiceDesc.Streams[0], RegistryService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CurrentRequest, CurrentResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RegistryService_WatchClient = grpc.ServerStreamingClient[CurrentResponse]
+
 // RegistryServiceServer is the server API for RegistryService service.
 // All implementations must embed UnimplementedRegistryServiceServer
 // for forward compatibility.
@@ -70,6 +93,9 @@ type RegistryServiceServer interface {
 	Current(context.Context, *CurrentRequest) (*CurrentResponse, error)
 	// Report reports the result of deploying the artifact.
 	Report(context.Context, *ReportRequest) (*emptypb.Empty, error)
+	// Watch streams the current artifact: the latest one first, then each new
+	// one as soon as the registry knows about it.
+	Watch(*CurrentRequest, grpc.ServerStreamingServer[CurrentResponse]) error
 	mustEmbedUnimplementedRegistryServiceServer()
 }
 
@@ -86,6 +112,9 @@ func (UnimplementedRegistryServiceServer) Current(context.Context, *CurrentReque
 func (UnimplementedRegistryServiceServer) Report(context.Context, *ReportRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Report not implemented")
 }
+func (UnimplementedRegistryServiceServer) Watch(*CurrentRequest, grpc.ServerStreamingServer[CurrentResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedRegistryServiceServer) mustEmbedUnimplementedRegistryServiceServer() {}
 func (UnimplementedRegistryServiceServer) testEmbeddedByValue()                         {}
 
@@ -143,6 +172,17 @@ func _RegistryService_Report_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RegistryService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CurrentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServiceServer).Watch(m, &grpc.GenericServerStream[CurrentRequest, CurrentResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RegistryService_WatchServer = grpc.ServerStreamingServer[CurrentResponse]
+
 // RegistryService_ServiceDesc is the grpc.ServiceDesc for RegistryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -159,6 +199,12 @@ var RegistryService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _RegistryService_Report_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _RegistryService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "dewy.proto",
 }
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	giteaFormat string = "gitea://<host>/<owner>/<repo>"
+)
+
+// Gitea is a registry on the releases of a Gitea or Forgejo repository.
+type Gitea struct {
+	Host       string `schema:"-"`
+	Owner      string `schema:"-"`
+	Repo       string `schema:"-"`
+	Artifact   string `schema:"artifact"`
+	PreRelease bool   `schema:"pre-release"`
+	CalVer     string `schema:"calver"`
+	// Scheme is how the instance is reached, http or https; see
+	// client.NewGitea for the default.
+	Scheme string `schema:"scheme"`
+	cl     *client.Gitea
+	logger *logging.Logger
+}
+
+// NewGitea returns Gitea.
+func NewGitea(ctx context.Context, u string, log *logging.Logger) (*Gitea, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	splitted := strings.Split(strings.Trim(ur.Path, "/"), "/")
+	if ur.Host == "" || len(splitted) != 2 || splitted[0] == "" || splitted[1] == "" {
+		return nil, fmt.Errorf("host, owner and repo are required: %s", giteaFormat)
+	}
+
+	g := &Gitea{
+		Host:  ur.Host,
+		Owner: splitted[0],
+		Repo:  splitted[1],
+	}
+	if err := decoder.Decode(g, ur.Query()); err != nil {
+		return nil, err
+	}
+
+	g.cl, err = client.NewGitea(g.Host, g.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	g.logger = log
+	return g, nil
+}
+
+// String to string.
+func (g *Gitea) String() string {
+	return g.Host
+}
+
+// Current returns current artifact.
+func (g *Gitea) Current(ctx context.Context) (*CurrentResponse, error) {
+	release, err := g.latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, a := range release.Assets {
+		names = append(names, a.Name)
+	}
+
+	artifactName, found := "", false
+	if g.Artifact != "" {
+		for _, n := range names {
+			if n == g.Artifact {
+				artifactName, found = n, true
+				break
+			}
+		}
+	} else {
+		artifactName, found = MatchArtifactByPlatform(names)
+	}
+	if !found {
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: g.Artifact,
+			// As with GHR, CI usually attaches the artifacts after
+			// publishing, so the grace period counts from PublishedAt.
+			ReleaseTime: release.PublishedAt,
+			Message:     fmt.Sprintf("artifact not found: %s", g.Artifact),
+		}
+	}
+
+	var size int64
+	for _, a := range release.Assets {
+		if a.Name == artifactName {
+			size = a.Size
+			break
+		}
+	}
+	g.logger.Debug("Fetched artifact", slog.String("name", artifactName))
+
+	return &CurrentResponse{
+		ID:           time.Now().Format(ISO8601),
+		Tag:          release.TagName,
+		ArtifactURL:  g.artifactURL(release.TagName, artifactName),
+		CreatedAt:    release.PublishedAt,
+		Slot:         extractSlot(release.TagName, g.CalVer),
+		Size:         size,
+		ReleaseNotes: release.Body,
+	}, nil
+}
+
+// artifactURL returns the artifact URL of a release asset. A scheme option
+// is carried over so the artifact reaches the instance the same way.
+func (g *Gitea) artifactURL(tag, name string) string {
+	u := fmt.Sprintf("%s://%s/%s/%s/tag/%s/%s", scheme.Gitea, g.Host, g.Owner, g.Repo, url.PathEscape(tag), url.PathEscape(name))
+	if g.Scheme != "" {
+		u += "?" + url.Values{"scheme": {g.Scheme}}.Encode()
+	}
+	return u
+}
+
+func (g *Gitea) latest(ctx context.Context) (*client.GiteaRelease, error) {
+	releases, err := g.cl.ListReleases(ctx, g.Owner, g.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed gitea.ListReleases: %w", err)
+	}
+
+	// Drafts are never deployed. Releases marked as pre-release are
+	// skipped without pre-release, even when their tag looks stable.
+	var tagNames []string
+	releaseMap := make(map[string]*client.GiteaRelease)
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && !g.PreRelease) {
+			continue
+		}
+		tagNames = append(tagNames, r.TagName)
+		releaseMap[r.TagName] = r
+	}
+	if len(tagNames) == 0 {
+		return nil, fmt.Errorf("no non-draft releases found")
+	}
+
+	var latestTag string
+	var findErr error
+	if g.CalVer != "" {
+		_, latestTag, findErr = FindLatestCalVer(tagNames, g.CalVer, g.PreRelease)
+	} else {
+		_, latestTag, findErr = FindLatestSemVer(tagNames, g.PreRelease)
+	}
+	if findErr != nil {
+		return nil, fmt.Errorf("failed to find latest version: %w", findErr)
+	}
+
+	g.logger.Debug("Selected release based on version", slog.String("tag", latestTag))
+
+	return releaseMap[latestTag], nil
+}
+
+// Report attaches an audit asset to the release, like GHR.
+func (g *Gitea) Report(ctx context.Context, req *ReportRequest) error {
+	if req.Err != nil {
+		return req.Err
+	}
+	now := time.Now().UTC().Format(iso8601Nano)
+	hostname := req.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	info := fmt.Sprintf("shipped to %s %s at %s", strings.ToLower(hostname), req.Command, now)
+
+	release, err := g.cl.GetReleaseByTag(ctx, g.Owner, g.Repo, req.Tag)
+	if err != nil {
+		return err
+	}
+	name := strings.ReplaceAll(info, " ", "_") + ".txt"
+	return g.cl.CreateReleaseAttachment(ctx, g.Owner, g.Repo, release.ID, name, []byte(info))
+}
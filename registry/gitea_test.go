@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGiteaServer fakes the releases API of owner/app, serving pages of
+// releases linked by the Link header, and returns its host and the assets
+// uploaded to it.
+func newGiteaServer(t *testing.T, pages ...string) (host string, uploads map[string]string) {
+	t.Helper()
+	uploads = make(map[string]string)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/app/releases", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gt" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		page := 1
+		if r.URL.Query().Get("page") == "2" {
+			page = 2
+		}
+		if page < len(pages) {
+			w.Header().Set("Link", `<http://`+r.Host+`/api/v1/repos/owner/app/releases?page=2>; rel="next"`)
+		}
+		_, _ = w.Write([]byte(pages[page-1]))
+	})
+	mux.HandleFunc("GET /api/v1/repos/owner/app/releases/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 7, "tag_name": "` + r.PathValue("tag") + `"}`))
+	})
+	mux.HandleFunc("POST /api/v1/repos/owner/app/releases/7/assets", func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("attachment")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		uploads[r.URL.Query().Get("name")] = string(b)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	t.Setenv("GITEA_TOKEN", "gt")
+	return strings.TrimPrefix(ts.URL, "http://"), uploads
+}
+
+func TestNewGitea(t *testing.T) {
+	ctx := context.Background()
+	g, err := NewGitea(ctx, "gitea://git.example.com/owner/app?artifact=app.zip&pre-release=true", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Host != "git.example.com" || g.Owner != "owner" || g.Repo != "app" || g.Artifact != "app.zip" || !g.PreRelease {
+		t.Errorf("got %+v", g)
+	}
+	g, err = NewGitea(ctx, "forgejo://forgejo.internal:3000/owner/app?scheme=http", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Scheme != "http" || g.cl.BaseURL.String() != "http://forgejo.internal:3000/api/v1" {
+		t.Errorf("got scheme %q, base URL %s", g.Scheme, g.cl.BaseURL)
+	}
+	for _, u := range []string{"gitea://owner/app", "gitea://git.example.com/owner", "gitea:///owner/app", "gitea://git.example.com/owner/app?scheme=ftp"} {
+		if _, err := NewGitea(ctx, u, testLogger()); err == nil {
+			t.Errorf("NewGitea(%q) succeeded", u)
+		}
+	}
+}
+
+func TestGiteaCurrent(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	host, _ := newGiteaServer(t,
+		`[{"tag_name": "v1.3.0", "draft": true, "assets": [{"name": "app_linux_amd64.tar.gz"}]},
+		  {"tag_name": "v1.2.0", "prerelease": true, "assets": [{"name": "app_linux_amd64.tar.gz"}]}]`,
+		`[{"tag_name": "v1.1.0", "body": "Faster startup", "published_at": "2026-03-01T12:00:00Z",
+		   "assets": [{"name": "app_darwin_arm64.tar.gz"}, {"name": "app_linux_amd64.tar.gz", "size": 42}]},
+		  {"tag_name": "v1.0.0", "assets": [{"name": "app_linux_amd64.tar.gz"}]}]`)
+	ctx := context.Background()
+
+	g, err := NewGitea(ctx, "gitea://"+host+"/owner/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.1.0" || res.ArtifactURL != "gitea://"+host+"/owner/app/tag/v1.1.0/app_linux_amd64.tar.gz" ||
+		res.Size != 42 || res.ReleaseNotes != "Faster startup" || res.CreatedAt == nil {
+		t.Errorf("got %+v", res)
+	}
+
+	g.PreRelease = true
+	if res, err := g.Current(ctx); err != nil || res.Tag != "v1.2.0" {
+		t.Errorf("with pre-release got %+v, %v; want v1.2.0", res, err)
+	}
+
+	g, err = NewGitea(ctx, "gitea://"+host+"/owner/app?scheme=http", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := g.Current(ctx); err != nil || res.ArtifactURL != "gitea://"+host+"/owner/app/tag/v1.1.0/app_linux_amd64.tar.gz?scheme=http" {
+		t.Errorf("with scheme got %+v, %v; want the scheme in the artifact URL", res, err)
+	}
+}
+
+func TestGiteaCurrent_ArtifactNotFound(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	published := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	host, _ := newGiteaServer(t,
+		`[{"tag_name": "v1.0.0", "published_at": "`+published+`", "assets": [{"name": "app_darwin_arm64.tar.gz"}]}]`)
+
+	g, err := NewGitea(context.Background(), "gitea://"+host+"/owner/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Current(context.Background())
+	var notFound *ArtifactNotFoundError
+	if !errors.As(err, &notFound) || !notFound.IsWithinGracePeriod(30*time.Minute) {
+		t.Errorf("err = %v, want ArtifactNotFoundError within the grace period", err)
+	}
+}
+
+func TestGiteaReport(t *testing.T) {
+	host, uploads := newGiteaServer(t, `[]`)
+	ctx := context.Background()
+
+	g, err := NewGitea(ctx, "gitea://"+host+"/owner/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Report(ctx, &ReportRequest{Tag: "v1.0.0", Command: "server", Hostname: "Web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads) != 1 {
+		t.Fatalf("uploads = %v", uploads)
+	}
+	for name, content := range uploads {
+		if !strings.HasPrefix(name, "shipped_to_web-1_server_at_") || !strings.HasPrefix(content, "shipped to web-1 server at ") {
+			t.Errorf("uploaded %q: %q", name, content)
+		}
+	}
+}
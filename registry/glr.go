@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	glrFormat string = "glr://<group>/<project>[?base-url=<api url>]"
+	// glrDefaultEnvironment is the environment folder deployments are
+	// recorded in.
+	glrDefaultEnvironment = "production"
+)
+
+// GLR is a registry on GitLab Releases, on gitlab.com or a self-hosted
+// instance (see client.NewGitLab). Artifacts are the release's asset links.
+type GLR struct {
+	Project    string `schema:"-"`
+	Artifact   string `schema:"artifact"`
+	PreRelease bool   `schema:"pre-release"`
+	CalVer     string `schema:"calver"`
+	// Environment is the environment folder deployments are recorded in;
+	// each host gets its own environment below it.
+	Environment string `schema:"environment"`
+	// BaseURL is the API URL of the GitLab instance; see client.NewGitLab
+	// for the default.
+	BaseURL string `schema:"base-url"`
+	cl      *client.GitLab
+	logger  *logging.Logger
+}
+
+// NewGLR returns GLR. The project path may include subgroups, as in
+// glr://group/subgroup/project, and a base-url option points it at a
+// self-hosted instance, as in glr://group/project?base-url=https://git.example.com.
+func NewGLR(ctx context.Context, u string, log *logging.Logger) (*GLR, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GLR{
+		Project:     strings.TrimSuffix(ur.Host+removeTrailingSlash(ur.Path), "/"),
+		Environment: glrDefaultEnvironment,
+	}
+	if ur.Host == "" || !strings.Contains(g.Project, "/") {
+		return nil, fmt.Errorf("group and project are required: %s", glrFormat)
+	}
+	if err := decoder.Decode(g, ur.Query()); err != nil {
+		return nil, err
+	}
+
+	g.cl, err = client.NewGitLab(g.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	g.logger = log
+	return g, nil
+}
+
+// String to string.
+func (g *GLR) String() string {
+	return g.cl.Host()
+}
+
+// Current returns current artifact.
+func (g *GLR) Current(ctx context.Context) (*CurrentResponse, error) {
+	release, err := g.latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, l := range release.Assets.Links {
+		names = append(names, l.Name)
+	}
+
+	artifactName, found := "", false
+	if g.Artifact != "" {
+		for _, n := range names {
+			if n == g.Artifact {
+				artifactName, found = n, true
+				break
+			}
+		}
+	} else {
+		artifactName, found = MatchArtifactByPlatform(names)
+	}
+	if !found {
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: g.Artifact,
+			// Like PublishedAt of GHR, released_at is when the release went
+			// public; the asset links are often added after that by CI.
+			ReleaseTime: release.ReleasedAt,
+			Message:     fmt.Sprintf("artifact not found: %s", g.Artifact),
+		}
+	}
+	g.logger.Debug("Fetched artifact", slog.String("name", artifactName))
+
+	return &CurrentResponse{
+		ID:           time.Now().Format(ISO8601),
+		Tag:          release.TagName,
+		ArtifactURL:  g.artifactURL(release.TagName, artifactName),
+		CreatedAt:    release.ReleasedAt,
+		Slot:         extractSlot(release.TagName, g.CalVer),
+		ReleaseNotes: release.Description,
+	}, nil
+}
+
+// artifactURL returns the artifact URL of a release asset link, in the
+// form glr://<project>/-/releases/<tag>/<name> that GitLab uses for its own
+// release pages. The "-" separates the project path, which may have any
+// number of elements, from the release. A base-url option is carried over
+// so the artifact is downloaded from the same instance.
+func (g *GLR) artifactURL(tag, name string) string {
+	u := fmt.Sprintf("%s://%s/-/releases/%s/%s", scheme.GLR, g.Project, url.PathEscape(tag), url.PathEscape(name))
+	if g.BaseURL != "" {
+		u += "?" + url.Values{"base-url": {g.BaseURL}}.Encode()
+	}
+	return u
+}
+
+func (g *GLR) latest(ctx context.Context) (*client.GitLabRelease, error) {
+	releases, err := g.cl.ListReleases(ctx, g.Project)
+	if err != nil {
+		return nil, fmt.Errorf("failed gitlab.ListReleases: %w", err)
+	}
+
+	// Upcoming releases have a release date in the future; they are not
+	// published yet, like GitHub drafts.
+	var tagNames []string
+	releaseMap := make(map[string]*client.GitLabRelease)
+	for _, r := range releases {
+		if r.UpcomingRelease {
+			continue
+		}
+		tagNames = append(tagNames, r.TagName)
+		releaseMap[r.TagName] = r
+	}
+	if len(tagNames) == 0 {
+		return nil, fmt.Errorf("no published releases found")
+	}
+
+	var latestTag string
+	var findErr error
+	if g.CalVer != "" {
+		_, latestTag, findErr = FindLatestCalVer(tagNames, g.CalVer, g.PreRelease)
+	} else {
+		_, latestTag, findErr = FindLatestSemVer(tagNames, g.PreRelease)
+	}
+	if findErr != nil {
+		return nil, fmt.Errorf("failed to find latest version: %w", findErr)
+	}
+
+	g.logger.Debug("Selected release based on version", slog.String("tag", latestTag))
+
+	return releaseMap[latestTag], nil
+}
+
+// Report records the deployment as a GitLab deployment to the environment
+// <environment>/<hostname>, so every host keeps its own history in the
+// environment folder. Deployments are append-only, unlike the release notes,
+// which hosts reporting at once would overwrite. A failed deployment is
+// recorded with the failed status.
+func (g *GLR) Report(ctx context.Context, req *ReportRequest) error {
+	hostname := req.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	release, err := g.cl.GetRelease(ctx, g.Project, req.Tag)
+	if err != nil {
+		return err
+	}
+	status := "success"
+	if req.Err != nil {
+		status = "failed"
+	}
+	return g.cl.CreateDeployment(ctx, g.Project, &client.GitLabDeployment{
+		Environment: g.Environment + "/" + strings.ToLower(hostname),
+		Ref:         req.Tag,
+		Tag:         true,
+		SHA:         release.Commit.ID,
+		Status:      status,
+	})
+}
@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/linyows/dewy/client"
+)
+
+// newGitLabServer fakes the releases API of one GitLab project and records
+// the deployments created in it.
+func newGitLabServer(t *testing.T, project, releases string) *[]client.GitLabDeployment {
+	t.Helper()
+	deployments := new([]client.GitLabDeployment)
+	mux := http.NewServeMux()
+	projectPath := "/api/v4/projects/" + strings.ReplaceAll(project, "/", "%2F")
+	base := projectPath + "/releases"
+	mux.HandleFunc("GET "+base, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(releases))
+	})
+	mux.HandleFunc("GET "+base+"/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"tag_name": r.PathValue("tag"), "commit": map[string]string{"id": "0a1b2c"}})
+	})
+	mux.HandleFunc("POST "+projectPath+"/deployments", func(w http.ResponseWriter, r *http.Request) {
+		var d client.GitLabDeployment
+		_ = json.NewDecoder(r.Body).Decode(&d)
+		*deployments = append(*deployments, d)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	t.Setenv("GITLAB_API_URL", ts.URL+"/api/v4")
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("CI_JOB_TOKEN", "")
+	return deployments
+}
+
+func TestNewGLR(t *testing.T) {
+	ctx := context.Background()
+	g, err := NewGLR(ctx, "glr://group/sub/app/?artifact=app.zip&pre-release=true", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Project != "group/sub/app" || g.Artifact != "app.zip" || !g.PreRelease || g.Environment != "production" {
+		t.Errorf("got %+v", g)
+	}
+	if _, err := NewGLR(ctx, "glr://app", testLogger()); err == nil {
+		t.Error("expected a project without group to be rejected")
+	}
+	if _, err := NewGLR(ctx, "glr://group/app?base-url=git.example.com", testLogger()); err == nil {
+		t.Error("expected a base-url without scheme to be rejected")
+	}
+}
+
+func TestGLRCurrent(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	newGitLabServer(t, "group/sub/app", `[
+  {"tag_name": "v2.0.0", "upcoming_release": true,
+   "assets": {"links": [{"name": "app_linux_amd64.tar.gz"}]}},
+  {"tag_name": "v1.1.0", "description": "Faster startup", "released_at": "2026-03-01T12:00:00Z",
+   "assets": {"links": [{"name": "app_darwin_arm64.tar.gz"}, {"name": "app_linux_amd64.tar.gz"}]}},
+  {"tag_name": "v1.0.0", "assets": {"links": [{"name": "app_linux_amd64.tar.gz"}]}}
+]`)
+	ctx := context.Background()
+
+	g, err := NewGLR(ctx, "glr://group/sub/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.1.0" || res.ArtifactURL != "glr://group/sub/app/-/releases/v1.1.0/app_linux_amd64.tar.gz" ||
+		res.CreatedAt == nil || res.ReleaseNotes != "Faster startup" {
+		t.Errorf("got %+v", res)
+	}
+
+	// A base-url option wins over the environment and is carried over to
+	// the artifact, so each URL names its own instance.
+	base := os.Getenv("GITLAB_API_URL")
+	t.Setenv("GITLAB_API_URL", "http://127.0.0.1:1/api/v4")
+	g, err = NewGLR(ctx, "glr://group/sub/app?base-url="+url.QueryEscape(base), testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = g.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "glr://group/sub/app/-/releases/v1.1.0/app_linux_amd64.tar.gz?base-url=" + url.QueryEscape(base); res.ArtifactURL != want {
+		t.Errorf("ArtifactURL = %s, want %s", res.ArtifactURL, want)
+	}
+
+	g.Artifact = "app_windows_amd64.zip"
+	_, err = g.Current(ctx)
+	var notFound *ArtifactNotFoundError
+	if !errors.As(err, &notFound) || notFound.ReleaseTime == nil {
+		t.Errorf("err = %v, want ArtifactNotFoundError with release time", err)
+	}
+}
+
+func TestGLRReport(t *testing.T) {
+	deployments := newGitLabServer(t, "group/app", `[]`)
+	ctx := context.Background()
+
+	g, err := NewGLR(ctx, "glr://group/app?environment=staging", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Report(ctx, &ReportRequest{Tag: "v1.0.0", Command: "server", Hostname: "Web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Report(ctx, &ReportRequest{Tag: "v1.0.0", Command: "server", Hostname: "web-2", Err: errors.New("health check failed")}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []client.GitLabDeployment{
+		{Environment: "staging/web-1", Ref: "v1.0.0", Tag: true, SHA: "0a1b2c", Status: "success"},
+		{Environment: "staging/web-2", Ref: "v1.0.0", Tag: true, SHA: "0a1b2c", Status: "failed"},
+	}
+	if len(*deployments) != len(want) {
+		t.Fatalf("deployments = %+v, want %+v", *deployments, want)
+	}
+	for i := range want {
+		if (*deployments)[i] != want[i] {
+			t.Errorf("deployment %d = %+v, want %+v", i, (*deployments)[i], want[i])
+		}
+	}
+}
@@ -2,20 +2,39 @@ package registry
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	pb "github.com/linyows/dewy/registry/gen/dewy"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type GRPC struct {
-	Target   string `schema:"-"`
-	NoTLS    bool   `schema:"no-tls"`
-	Artifact string `schema:"artifact"`
-	CalVer   string `schema:"calver"`
-	cl       pb.RegistryServiceClient
+	Target     string        `schema:"-"`
+	NoTLS      bool          `schema:"no-tls"`
+	Artifact   string        `schema:"artifact"`
+	CalVer     string        `schema:"calver"`
+	CA         string        `schema:"ca"`          // PEM bundle trusted instead of the system roots
+	Cert       string        `schema:"cert"`        // client certificate for mTLS
+	Key        string        `schema:"key"`         // client key for mTLS
+	ServerName string        `schema:"server-name"` // name verified in the server certificate instead of the host
+	TokenFile  string        `schema:"token-file"`  // bearer token sent with every RPC, re-read on each call
+	Keepalive  time.Duration `schema:"keepalive"`   // ping interval of an idle connection; 0 disables
+	Retry      int           `schema:"retry"`       // attempts of an RPC that failed with UNAVAILABLE (at most 5)
+	cl         pb.RegistryServiceClient
 }
 
 func NewGRPC(ctx context.Context, u string) (*GRPC, error) {
@@ -39,9 +58,9 @@ func NewGRPC(ctx context.Context, u string) (*GRPC, error) {
 // Dial returns GRPC.
 func (c *GRPC) Dial(ctx context.Context, target string) error {
 	c.Target = target
-	opts := []grpc.DialOption{}
-	if c.NoTLS {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	opts, err := c.dialOptions()
+	if err != nil {
+		return err
 	}
 	// cc, err := grpc.NewClient("passthrough://"+c.Target, opts...)
 	cc, err := grpc.NewClient(c.Target, opts...)
@@ -53,38 +72,169 @@ func (c *GRPC) Dial(ctx context.Context, target string) error {
 	return nil
 }
 
+func (c *GRPC) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if c.NoTLS {
+		if c.TokenFile != "" {
+			return nil, errors.New("grpc registry: token-file cannot be used with no-tls")
+		}
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tc, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tc)))
+	}
+	if c.TokenFile != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenFile(c.TokenFile)))
+	}
+	if c.Keepalive > 0 {
+		// The server's keepalive enforcement policy must allow this interval,
+		// or it closes the connection with too_many_pings.
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.Keepalive,
+			PermitWithoutStream: true,
+		}))
+	}
+	if c.Retry > 1 {
+		opts = append(opts, grpc.WithDefaultServiceConfig(retryServiceConfig(c.Retry)))
+	}
+	return opts, nil
+}
+
+// tlsConfig builds the client TLS configuration: system roots unless a CA
+// bundle is given, and a client certificate for mTLS when cert and key are.
+func (c *GRPC) tlsConfig() (*tls.Config, error) {
+	tc := &tls.Config{
+		ServerName: c.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if c.CA != "" {
+		b, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("grpc registry: failed to read ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("grpc registry: no certificates found in %s", c.CA)
+		}
+		tc.RootCAs = pool
+	}
+	if c.Cert != "" || c.Key != "" {
+		if c.Cert == "" || c.Key == "" {
+			return nil, errors.New("grpc registry: cert and key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("grpc registry: failed to load client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// retryServiceConfig retries every registry RPC that fails with UNAVAILABLE,
+// e.g. while the server restarts, up to attempts times in all.
+func retryServiceConfig(attempts int) string {
+	return fmt.Sprintf(`{"methodConfig":[{"name":[{"service":"dewy.RegistryService"}],"retryPolicy":{`+
+		`"maxAttempts":%d,"initialBackoff":"0.5s","maxBackoff":"5s","backoffMultiplier":2,`+
+		`"retryableStatusCodes":["UNAVAILABLE"]}}]}`, attempts)
+}
+
+// tokenFile sends the token stored in the file as a bearer token. The file
+// is read on every RPC so a rotated token is picked up without a restart.
+type tokenFile string
+
+func (f tokenFile) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("grpc registry: failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return nil, fmt.Errorf("grpc registry: token file %s is empty", string(f))
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (tokenFile) RequireTransportSecurity() bool {
+	return true
+}
+
 // Current returns current artifact.
 func (c *GRPC) Current(ctx context.Context) (*CurrentResponse, error) {
+	cres, err := c.cl.Current(ctx, c.currentRequest())
+	if err != nil {
+		return nil, err
+	}
+	return c.currentResponse(cres), nil
+}
+
+// Watch streams the current artifact to fn until the server ends the stream
+// (nil) or the stream fails. A server without the Watch RPC yields
+// ErrWatchUnsupported.
+func (c *GRPC) Watch(ctx context.Context, fn func(*CurrentResponse)) error {
+	stream, err := c.cl.Watch(ctx, c.currentRequest())
+	if err != nil {
+		return watchError(err)
+	}
+	for {
+		cres, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return watchError(err)
+		}
+		fn(c.currentResponse(cres))
+	}
+}
+
+func watchError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return ErrWatchUnsupported
+	}
+	return err
+}
+
+func (c *GRPC) currentRequest() *pb.CurrentRequest {
 	var an *string
 	if c.Artifact != "" {
 		an = &c.Artifact
 	}
-	creq := &pb.CurrentRequest{
+	return &pb.CurrentRequest{
 		Arch:        getArch(),
 		Os:          getOS(),
 		ArifactName: an,
 	}
-	cres, err := c.cl.Current(ctx, creq)
-	if err != nil {
-		return nil, err
-	}
+}
+
+func (c *GRPC) currentResponse(cres *pb.CurrentResponse) *CurrentResponse {
 	var createdAt *time.Time
 	if cres.CreatedAt != nil {
 		t := cres.CreatedAt.AsTime()
 		createdAt = &t
 	}
 
-	// Extract slot from build metadata
-	slot := extractSlot(cres.Tag, c.CalVer)
+	// Servers that predate the slot field leave it to the build metadata.
+	slot := cres.GetSlot()
+	if cres.Slot == nil {
+		slot = extractSlot(cres.Tag, c.CalVer)
+	}
 
 	res := &CurrentResponse{
-		ID:          cres.Id,
-		Tag:         cres.Tag,
-		ArtifactURL: cres.ArtifactUrl,
-		CreatedAt:   createdAt,
-		Slot:        slot,
+		ID:           cres.Id,
+		Tag:          cres.Tag,
+		ArtifactURL:  cres.ArtifactUrl,
+		CreatedAt:    createdAt,
+		Slot:         slot,
+		Checksum:     cres.GetChecksum(),
+		Size:         cres.GetSize(),
+		Labels:       cres.Labels,
+		ReleaseNotes: cres.GetReleaseNotes(),
 	}
-	return res, nil
+	return res
 }
 
 // Report report shipping.
@@ -95,13 +245,29 @@ func (c *GRPC) Report(ctx context.Context, req *ReportRequest) error {
 		perr = &serr
 	}
 	creq := &pb.ReportRequest{
-		Id:      req.ID,
-		Tag:     req.Tag,
-		Command: req.Command,
-		Err:     perr,
+		Id:          req.ID,
+		Tag:         req.Tag,
+		Command:     req.Command,
+		Err:         perr,
+		Hostname:    optional(req.Hostname),
+		InstanceId:  optional(req.InstanceID),
+		PreviousTag: optional(req.PreviousTag),
+		Phase:       optional(req.Phase),
+	}
+	if req.Duration > 0 {
+		creq.Duration = durationpb.New(req.Duration)
 	}
 	if _, err := c.cl.Report(ctx, creq); err != nil {
 		return err
 	}
 	return nil
 }
+
+// optional leaves an empty string unset on the wire, so servers can tell
+// "not reported" from an empty value.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -2,12 +2,25 @@ package registry
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/k1LoW/grpcstub"
 	pb "github.com/linyows/dewy/registry/gen/dewy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -100,3 +113,279 @@ func TestReport(t *testing.T) {
 		}
 	})
 }
+
+func TestCurrentMetadata(t *testing.T) {
+	ctx := context.Background()
+	ts := grpcstub.NewServer(t, "dewy.proto")
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	slot := "green"
+	checksum := "sha256:abcd"
+	size := int64(1024)
+	notes := "Fixes the login bug"
+	ts.Method("Current").Response(&pb.CurrentResponse{
+		Id:           "1",
+		Tag:          "v1.0.0+blue",
+		ArtifactUrl:  "ghr://linyows/dewy",
+		Slot:         &slot,
+		Checksum:     &checksum,
+		Size:         &size,
+		Labels:       map[string]string{"team": "web"},
+		ReleaseNotes: &notes,
+	})
+	g := &GRPC{NoTLS: true}
+	if err := g.Dial(ctx, ts.Addr()); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := g.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CurrentResponse{
+		ID:           "1",
+		Tag:          "v1.0.0+blue",
+		ArtifactURL:  "ghr://linyows/dewy",
+		Slot:         "green",
+		Checksum:     "sha256:abcd",
+		Size:         1024,
+		Labels:       map[string]string{"team": "web"},
+		ReleaseNotes: "Fixes the login bug",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReportDetails(t *testing.T) {
+	ctx := context.Background()
+	ts := grpcstub.NewServer(t, "dewy.proto")
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Method("Report").Response(&emptypb.Empty{})
+	g := &GRPC{NoTLS: true}
+	if err := g.Dial(ctx, ts.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	req := &ReportRequest{
+		ID:          "1234567890",
+		Tag:         "v1.1.0",
+		Command:     "server",
+		Hostname:    "web-1",
+		InstanceID:  "abc",
+		PreviousTag: "v1.0.0",
+		Duration:    1500 * time.Millisecond,
+		Phase:       "promote",
+	}
+	if err := g.Report(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := grpcstub.Message{
+		"id":           "1234567890",
+		"tag":          "v1.1.0",
+		"command":      "server",
+		"hostname":     "web-1",
+		"instance_id":  "abc",
+		"previous_tag": "v1.0.0",
+		"duration":     "1.500s",
+		"phase":        "promote",
+	}
+	if diff := cmp.Diff(ts.Requests()[0].Message, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	ts := grpcstub.NewServer(t, "dewy.proto")
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Method("Watch").
+		Response(&pb.CurrentResponse{Id: "1", Tag: "v1.0.0", ArtifactUrl: "ghr://linyows/dewy"}).
+		Response(&pb.CurrentResponse{Id: "2", Tag: "v1.1.0", ArtifactUrl: "ghr://linyows/dewy"})
+	g := &GRPC{NoTLS: true}
+	if err := g.Dial(ctx, ts.Addr()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := g.Watch(ctx, func(res *CurrentResponse) {
+		got = append(got, res.ID+":"+res.Tag)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, []string{"1:v1.0.0", "2:v1.1.0"}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWatchUnsupported(t *testing.T) {
+	ctx := context.Background()
+	ts := grpcstub.NewServer(t, "dewy.proto")
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Method("Watch").Status(status.New(codes.Unimplemented, "method Watch not implemented"))
+	g := &GRPC{NoTLS: true}
+	if err := g.Dial(ctx, ts.Addr()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.Watch(ctx, func(*CurrentResponse) {
+		t.Error("unexpected response")
+	})
+	if !errors.Is(err, ErrWatchUnsupported) {
+		t.Errorf("got %v, want ErrWatchUnsupported", err)
+	}
+}
+
+// writeTestPKI writes a CA and a server certificate for registry.internal
+// signed by it, returning the PEMs and the path of the CA file.
+func writeTestPKI(t *testing.T) (caPEM, certPEM, keyPEM []byte, caFile string) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srvKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srvTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "registry.internal"},
+		DNSNames:     []string{"registry.internal"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	srvDER, err := x509.CreateCertificate(rand.Reader, srvTmpl, caTmpl, &srvKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(srvKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srvDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	caFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return caPEM, certPEM, keyPEM, caFile
+}
+
+func TestDialTLS(t *testing.T) {
+	ctx := context.Background()
+	caPEM, certPEM, keyPEM, caFile := writeTestPKI(t)
+	ts := grpcstub.NewTLSServer(t, "dewy.proto", caPEM, certPEM, keyPEM)
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Method("Current").Response(&pb.CurrentResponse{Id: "1", Tag: "v1.0.0"})
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("PrivateCA", func(t *testing.T) {
+		g := &GRPC{CA: caFile, ServerName: "registry.internal", TokenFile: tokenPath, Retry: 3}
+		if err := g.Dial(ctx, ts.Addr()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Current(ctx); err != nil {
+			t.Fatal(err)
+		}
+		reqs := ts.Requests()
+		if got := reqs[len(reqs)-1].Headers.Get("authorization"); len(got) != 1 || got[0] != "Bearer s3cret" {
+			t.Errorf("authorization = %v, want the bearer token", got)
+		}
+	})
+
+	t.Run("SystemRoots", func(t *testing.T) {
+		g := &GRPC{ServerName: "registry.internal"}
+		if err := g.Dial(ctx, ts.Addr()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Current(ctx); err == nil {
+			t.Error("want a certificate error without the private CA")
+		}
+	})
+}
+
+func TestGRPCDialOptions(t *testing.T) {
+	_, certPEM, keyPEM, caFile := writeTestPKI(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ClientCertificate", func(t *testing.T) {
+		g := &GRPC{CA: caFile, Cert: certFile, Key: keyFile}
+		tc, err := g.tlsConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tc.Certificates) != 1 || tc.RootCAs == nil {
+			t.Errorf("tls config = %+v, want the client certificate and the CA", tc)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		g       GRPC
+		wantErr string
+	}{
+		{"cert without key", GRPC{Cert: certFile}, "given together"},
+		{"ca without certificates", GRPC{CA: keyFile}, "no certificates"},
+		{"token over plaintext", GRPC{NoTLS: true, TokenFile: "token"}, "no-tls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.g.dialOptions()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewGRPCOptions(t *testing.T) {
+	g, err := NewGRPC(context.Background(), "grpc://localhost:9000?no-tls=true&keepalive=30s&retry=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Keepalive != 30*time.Second || g.Retry != 4 {
+		t.Errorf("keepalive = %s, retry = %d; want 30s and 4", g.Keepalive, g.Retry)
+	}
+	if _, err := NewGRPC(context.Background(), "grpc://localhost:9000?no-tls=true&keepalive=soon"); err == nil {
+		t.Error("want an error for an invalid keepalive")
+	}
+}
@@ -0,0 +1,372 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linyows/dewy/client"
+	"github.com/linyows/dewy/logging"
+)
+
+// HTTP is a registry on a plain web server or CDN. The URL points either to
+// a JSON release index (see httpIndex) or to a directory listing with one
+// subdirectory per version, like the S3 and GS layout. Responses are polled
+// with If-None-Match, so an unchanged index costs a 304. Credentials come
+// from the environment and are only sent to the host of the URL; artifacts on
+// that host are marked with client.HTTPAuthFragment so they get them too.
+type HTTP struct {
+	URL        string `schema:"-"`
+	Artifact   string `schema:"artifact"`
+	PreRelease bool   `schema:"pre-release"`
+	CalVer     string `schema:"calver"`
+	host       string
+	client     *http.Client
+	logger     *logging.Logger
+	mu         sync.Mutex
+	etags      map[string]httpCached
+}
+
+// httpCached is the last response for a URL, reused on 304 Not Modified.
+type httpCached struct {
+	etag        string
+	contentType string
+	body        []byte
+}
+
+// httpIndex is the JSON release index:
+//
+//	{"releases": [{"tag": "v1.2.0", "created_at": "2026-03-01T12:00:00Z",
+//	  "artifacts": [{"url": "app_linux_amd64.tar.gz", "os": "linux", "arch": "amd64",
+//	    "checksum": "sha256:...", "size": 1234}]}]}
+//
+// Artifact URLs may be relative to the index. Without os and arch, artifacts
+// are matched by name like in the other registries.
+type httpIndex struct {
+	Releases []httpRelease `json:"releases"`
+}
+
+type httpRelease struct {
+	Tag       string            `json:"tag"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	Notes     string            `json:"notes,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Artifacts []httpArtifact    `json:"artifacts"`
+}
+
+type httpArtifact struct {
+	Name     string `json:"name,omitempty"` // defaults to the last element of URL
+	URL      string `json:"url"`
+	OS       string `json:"os,omitempty"`
+	Arch     string `json:"arch,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+func (a httpArtifact) name() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	u, _, _ := strings.Cut(a.URL, "#")
+	u, _, _ = strings.Cut(u, "?")
+	return u[strings.LastIndex(u, "/")+1:]
+}
+
+// NewHTTP returns HTTP for a URL such as
+// https://cdn.example.com/myapp/index.json?pre-release=true. The query holds
+// dewy's options and is not sent to the server.
+func NewHTTP(ctx context.Context, u string, log *logging.Logger) (*HTTP, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if ur.Host == "" {
+		return nil, fmt.Errorf("invalid http registry URL %q: host is required", u)
+	}
+
+	h := &HTTP{
+		client: &http.Client{Timeout: 30 * time.Second},
+		logger: log,
+		etags:  make(map[string]httpCached),
+	}
+	if err := decoder.Decode(h, ur.Query()); err != nil {
+		return nil, err
+	}
+	ur.RawQuery = ""
+	h.URL = ur.String()
+	h.host = strings.ToLower(ur.Host)
+	return h, nil
+}
+
+// Current returns current artifact.
+func (h *HTTP) Current(ctx context.Context) (*CurrentResponse, error) {
+	body, contentType, err := h.fetch(ctx, h.URL)
+	if err != nil {
+		return nil, err
+	}
+	if isJSON(contentType, body) {
+		var idx httpIndex
+		if err := json.Unmarshal(body, &idx); err != nil {
+			return nil, fmt.Errorf("invalid release index %s: %w", h.URL, err)
+		}
+		return h.currentFromIndex(&idx)
+	}
+	return h.currentFromListing(ctx, body)
+}
+
+func (h *HTTP) latest(tags []string) (Version, string, error) {
+	if h.CalVer != "" {
+		return FindLatestCalVer(tags, h.CalVer, h.PreRelease)
+	}
+	return FindLatestSemVer(tags, h.PreRelease)
+}
+
+func (h *HTTP) currentFromIndex(idx *httpIndex) (*CurrentResponse, error) {
+	var tags []string
+	for _, r := range idx.Releases {
+		tags = append(tags, r.Tag)
+	}
+	version, tag, err := h.latest(tags)
+	if err != nil {
+		return nil, err
+	}
+	var rel httpRelease
+	for _, r := range idx.Releases {
+		if r.Tag == tag {
+			rel = r
+			break
+		}
+	}
+
+	a, found := h.matchIndexArtifact(rel.Artifacts)
+	if !found {
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: h.Artifact,
+			ReleaseTime:  rel.CreatedAt,
+			Message:      fmt.Sprintf("artifact not found in release %s of %s", tag, h.URL),
+		}
+	}
+	artifactURL, err := h.artifactURL(h.URL, a.URL)
+	if err != nil {
+		return nil, err
+	}
+	return &CurrentResponse{
+		ID:           time.Now().Format(ISO8601),
+		Tag:          version.String(),
+		ArtifactURL:  artifactURL,
+		CreatedAt:    rel.CreatedAt,
+		Slot:         version.GetBuildMetadata(),
+		Checksum:     a.Checksum,
+		Size:         a.Size,
+		Labels:       rel.Labels,
+		ReleaseNotes: rel.Notes,
+	}, nil
+}
+
+// matchIndexArtifact picks the artifact named by the artifact option, else
+// the one declared for this platform, else the first whose name matches it.
+func (h *HTTP) matchIndexArtifact(list []httpArtifact) (httpArtifact, bool) {
+	if h.Artifact != "" {
+		for _, a := range list {
+			if a.name() == h.Artifact {
+				return a, true
+			}
+		}
+		return httpArtifact{}, false
+	}
+	for _, a := range list {
+		if slices.Contains(osAliases(getOS()), strings.ToLower(a.OS)) &&
+			slices.Contains(archAliases(getArch()), strings.ToLower(a.Arch)) {
+			return a, true
+		}
+	}
+	var names []string
+	for _, a := range list {
+		names = append(names, a.name())
+	}
+	if name, ok := MatchArtifactByPlatform(names); ok {
+		for _, a := range list {
+			if a.name() == name {
+				return a, true
+			}
+		}
+	}
+	return httpArtifact{}, false
+}
+
+func (h *HTTP) currentFromListing(ctx context.Context, body []byte) (*CurrentResponse, error) {
+	dirs, _ := parseListing(body)
+	version, tag, err := h.latest(dirs)
+	if err != nil {
+		return nil, err
+	}
+	dirURL, err := h.resolve(h.URL, url.PathEscape(tag)+"/")
+	if err != nil {
+		return nil, err
+	}
+	body, _, err = h.fetch(ctx, dirURL)
+	if err != nil {
+		return nil, err
+	}
+	_, files := parseListing(body)
+
+	name, found := "", false
+	if h.Artifact != "" {
+		for _, f := range files {
+			if f == h.Artifact {
+				name, found = f, true
+				break
+			}
+		}
+	} else {
+		name, found = MatchArtifactByPlatform(files)
+	}
+	if !found {
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: tag + "/" + h.Artifact,
+			Message:      fmt.Sprintf("artifact not found in %s", dirURL),
+		}
+	}
+	artifactURL, err := h.artifactURL(dirURL, url.PathEscape(name))
+	if err != nil {
+		return nil, err
+	}
+	return &CurrentResponse{
+		ID:          time.Now().Format(ISO8601),
+		Tag:         version.String(),
+		ArtifactURL: artifactURL,
+		Slot:        version.GetBuildMetadata(),
+	}, nil
+}
+
+// fetch GETs u, answering from the last response when the server says it
+// has not changed.
+func (h *HTTP) fetch(ctx context.Context, u string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if h.sameHost(req.URL) {
+		client.SetHTTPAuth(req)
+	}
+	h.mu.Lock()
+	cached, ok := h.etags[u]
+	h.mu.Unlock()
+	if ok {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && ok:
+		h.logger.Debug("Registry index not modified", slog.String("url", u))
+		return cached.body, cached.contentType, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("failed to fetch %s: %s", u, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	contentType := res.Header.Get("Content-Type")
+	if etag := res.Header.Get("ETag"); etag != "" {
+		h.mu.Lock()
+		h.etags[u] = httpCached{etag: etag, contentType: contentType, body: body}
+		h.mu.Unlock()
+	}
+	return body, contentType, nil
+}
+
+func (h *HTTP) resolve(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+// artifactURL resolves ref like resolve and, when credentials are set, marks
+// it with client.HTTPAuthFragment if it is on the host of the index. Any
+// fragment from the index itself is dropped, so an index cannot send the
+// credentials elsewhere.
+func (h *HTTP) artifactURL(base, ref string) (string, error) {
+	s, err := h.resolve(base, ref)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	u.Fragment, u.RawFragment = "", ""
+	if h.sameHost(u) && client.HasHTTPAuth() {
+		u.Fragment = client.HTTPAuthFragment
+	}
+	return u.String(), nil
+}
+
+func (h *HTTP) sameHost(u *url.URL) bool {
+	return strings.ToLower(u.Host) == h.host
+}
+
+// Report is a no-op: a web server has nowhere to record it.
+func (h *HTTP) Report(ctx context.Context, req *ReportRequest) error {
+	return nil
+}
+
+func isJSON(contentType string, body []byte) bool {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		if mt == "application/json" || strings.HasSuffix(mt, "+json") {
+			return true
+		}
+		if mt == "text/html" {
+			return false
+		}
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(body)), "{")
+}
+
+var hrefRegexp = regexp.MustCompile(`(?i)href\s*=\s*"([^"]+)"`)
+
+// parseListing returns the subdirectories and files an autoindex page (nginx,
+// Apache, Caddy, http.FileServer) links to. Parent, absolute and query links
+// are skipped.
+func parseListing(body []byte) (dirs, files []string) {
+	seen := make(map[string]bool)
+	for _, m := range hrefRegexp.FindAllSubmatch(body, -1) {
+		ref := strings.TrimPrefix(string(m[1]), "./")
+		if strings.ContainsAny(ref, "?#:") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, ".") {
+			continue
+		}
+		name, err := url.PathUnescape(strings.TrimSuffix(ref, "/"))
+		if err != nil || name == "" || strings.Contains(name, "/") || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if strings.HasSuffix(ref, "/") {
+			dirs = append(dirs, name)
+		} else {
+			files = append(files, name)
+		}
+	}
+	return dirs, files
+}
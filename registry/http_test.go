@@ -0,0 +1,210 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/linyows/dewy/client"
+)
+
+func setTestPlatform(t *testing.T, os, arch string) {
+	t.Helper()
+	TestOS, TestArch = os, arch
+	t.Cleanup(func() { TestOS, TestArch = "", "" })
+}
+
+func TestHTTPCurrentIndex(t *testing.T) {
+	setTestPlatform(t, "darwin", "amd64")
+	const index = `{"releases": [
+  {"tag": "v1.0.0", "artifacts": [{"url": "v1.0.0/app_darwin_amd64.tar.gz"}]},
+  {"tag": "v1.1.0", "created_at": "2026-03-01T12:00:00Z", "notes": "Faster startup",
+   "labels": {"channel": "stable"},
+   "artifacts": [
+     {"url": "v1.1.0/app_linux_amd64.tar.gz", "os": "linux", "arch": "amd64"},
+     {"url": "https://cdn.example.com/app/v1.1.0/app-macos-x86_64.zip", "os": "macos", "arch": "x86_64",
+      "checksum": "sha256:abc", "size": 42}]},
+  {"tag": "v1.2.0-rc.1", "artifacts": [{"url": "v1.2.0-rc.1/app_darwin_amd64.tar.gz"}]}
+]}`
+	var requests, notModified atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/app/index.json" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(index))
+	}))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	h, err := NewHTTP(ctx, ts.URL+"/app/index.json", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		res, err := h.Current(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Tag != "v1.1.0" || res.ArtifactURL != "https://cdn.example.com/app/v1.1.0/app-macos-x86_64.zip" {
+			t.Errorf("got %s %s", res.Tag, res.ArtifactURL)
+		}
+		if res.Checksum != "sha256:abc" || res.Size != 42 || res.ReleaseNotes != "Faster startup" ||
+			res.Labels["channel"] != "stable" || res.CreatedAt == nil {
+			t.Errorf("metadata = %+v", res)
+		}
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("requests = %d, not modified = %d, want 2 and 1", requests.Load(), notModified.Load())
+	}
+
+	h, err = NewHTTP(ctx, ts.URL+"/app/index.json?pre-release=true", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := h.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.2.0-rc.1" || res.ArtifactURL != ts.URL+"/app/v1.2.0-rc.1/app_darwin_amd64.tar.gz" {
+		t.Errorf("pre-release got %s %s", res.Tag, res.ArtifactURL)
+	}
+}
+
+func TestHTTPCurrentListing(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_linux_amd64.tar.gz")
+	writeRelease(t, dir, "v1.2.0", "app_darwin_arm64.tar.gz", "app_linux_amd64.tar.gz")
+	writeRelease(t, dir, "v1.10.0", "app_darwin_arm64.tar.gz")
+	writeRelease(t, dir, "latest", "app_linux_amd64.tar.gz")
+	ts := httptest.NewServer(http.StripPrefix("/releases/", http.FileServer(http.Dir(dir))))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	h, err := NewHTTP(ctx, ts.URL+"/releases/", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.Current(ctx)
+	if _, ok := err.(*ArtifactNotFoundError); !ok {
+		t.Fatalf("err = %v, want ArtifactNotFoundError for v1.10.0", err)
+	}
+
+	h, err = NewHTTP(ctx, ts.URL+"/releases/?artifact=app_darwin_arm64.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := h.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.10.0" || res.ArtifactURL != ts.URL+"/releases/v1.10.0/app_darwin_arm64.tar.gz" {
+		t.Errorf("got %s %s", res.Tag, res.ArtifactURL)
+	}
+}
+
+func TestHTTPAuth(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"releases": [{"tag": "v1.0.0", "artifacts": [{"url": "app_linux_amd64.tar.gz"}]}]}`))
+	}))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"none", nil, ""},
+		{"bearer", map[string]string{"DEWY_HTTP_TOKEN": "secret"}, "Bearer secret"},
+		{"basic wins", map[string]string{"DEWY_HTTP_TOKEN": "secret", "DEWY_HTTP_USERNAME": "u", "DEWY_HTTP_PASSWORD": "p"}, "Basic dTpw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"DEWY_HTTP_TOKEN", "DEWY_HTTP_USERNAME", "DEWY_HTTP_PASSWORD"} {
+				t.Setenv(k, tt.env[k])
+			}
+			h, err := NewHTTP(ctx, ts.URL+"/index.json", testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := h.Current(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPAuthArtifactURL(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	var index string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(index))
+	}))
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		token string
+		url   string
+		want  string
+	}{
+		{"same host", "secret", "app_linux_amd64.tar.gz", ts.URL + "/app_linux_amd64.tar.gz#" + client.HTTPAuthFragment},
+		{"no credentials", "", "app_linux_amd64.tar.gz", ts.URL + "/app_linux_amd64.tar.gz"},
+		{"other host", "secret", "https://cdn.example.com/app_linux_amd64.tar.gz", "https://cdn.example.com/app_linux_amd64.tar.gz"},
+		{"fragment from index", "secret", "https://cdn.example.com/app_linux_amd64.tar.gz#" + client.HTTPAuthFragment, "https://cdn.example.com/app_linux_amd64.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEWY_HTTP_TOKEN", tt.token)
+			index = `{"releases": [{"tag": "v1.0.0", "artifacts": [{"url": "` + tt.url + `"}]}]}`
+			h, err := NewHTTP(ctx, ts.URL+"/index.json", testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := h.Current(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.ArtifactURL != tt.want {
+				t.Errorf("ArtifactURL = %q, want %q", res.ArtifactURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListing(t *testing.T) {
+	body := `<pre><a href="../">../</a>
+<a href="v1.0.0/">v1.0.0/</a>
+<a HREF="./v1.1.0%2Bblue/">v1.1.0+blue/</a>
+<a href="?C=M;O=A">Last modified</a>
+<a href="/icons/back.gif">back</a>
+<a href="https://example.com/">elsewhere</a>
+<a href="app_linux_amd64.tar.gz">app_linux_amd64.tar.gz</a>
+<a href="v1.0.0/">v1.0.0/</a></pre>`
+	dirs, files := parseListing([]byte(body))
+	if len(dirs) != 2 || dirs[0] != "v1.0.0" || dirs[1] != "v1.1.0+blue" {
+		t.Errorf("dirs = %v", dirs)
+	}
+	if len(files) != 1 || files[0] != "app_linux_amd64.tar.gz" {
+		t.Errorf("files = %v", files)
+	}
+}
@@ -271,6 +271,55 @@ func (o *OCI) getBearerToken(ctx context.Context, authHeader string) error {
 	return nil
 }
 
+// get sends an authenticated GET to the registry API accepting the given
+// media types. On 401 it fetches a bearer token as the WWW-Authenticate
+// header says and retries once. The caller closes the response body.
+func (o *OCI) get(ctx context.Context, cl *http.Client, apiURL string, accept ...string) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		// Add authentication if available
+		if o.token != "" {
+			req.Header.Set("Authorization", "Bearer "+o.token)
+		} else if o.username != "" && o.password != "" {
+			auth := base64.StdEncoding.EncodeToString([]byte(o.username + ":" + o.password))
+			req.Header.Set("Authorization", "Basic "+auth)
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cl.Do(req) //nolint:gosec // G704
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle 401 Unauthorized - need to get bearer token
+	authHeader := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode != http.StatusUnauthorized || authHeader == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := o.getBearerToken(ctx, authHeader); err != nil {
+		return nil, fmt.Errorf("failed to get bearer token: %w", err)
+	}
+
+	// Retry with bearer token
+	if req, err = newRequest(); err != nil {
+		return nil, err
+	}
+	return cl.Do(req) //nolint:gosec // G704
+}
+
 // listTags retrieves the list of tags from the registry with pagination support.
 func (o *OCI) listTags(ctx context.Context) ([]string, error) {
 	// Docker Registry HTTP API V2: GET /v2/<name>/tags/list
@@ -299,48 +348,12 @@ func (o *OCI) listTags(ctx context.Context) ([]string, error) {
 
 // fetchTagsPage fetches a single page of tags and returns the next page URL if available.
 func (o *OCI) fetchTagsPage(ctx context.Context, apiURL string) ([]string, string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, "", err
-	}
-
-	// Add authentication if available
-	if o.token != "" {
-		req.Header.Set("Authorization", "Bearer "+o.token)
-	} else if o.username != "" && o.password != "" {
-		auth := base64.StdEncoding.EncodeToString([]byte(o.username + ":" + o.password))
-		req.Header.Set("Authorization", "Basic "+auth)
-	}
-
-	resp, err := o.client.Do(req) //nolint:gosec // G704
+	resp, err := o.get(ctx, o.client, apiURL)
 	if err != nil {
 		return nil, "", err
 	}
 	defer resp.Body.Close()
 
-	// Handle 401 Unauthorized - need to get bearer token
-	if resp.StatusCode == http.StatusUnauthorized {
-		authHeader := resp.Header.Get("WWW-Authenticate")
-		if authHeader != "" {
-			if err := o.getBearerToken(ctx, authHeader); err != nil {
-				return nil, "", fmt.Errorf("failed to get bearer token: %w", err)
-			}
-
-			// Retry with bearer token
-			req, err = http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-			if err != nil {
-				return nil, "", err
-			}
-			req.Header.Set("Authorization", "Bearer "+o.token)
-
-			resp, err = o.client.Do(req) //nolint:gosec // G704
-			if err != nil {
-				return nil, "", err
-			}
-			defer resp.Body.Close()
-		}
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 		return nil, "", fmt.Errorf("failed to list tags: status %d: %s", resp.StatusCode, string(body))
@@ -437,63 +450,19 @@ func (o *OCI) getImageDigest(ctx context.Context, tag string) (string, *time.Tim
 	scheme := o.getScheme()
 	apiURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, o.Registry, o.Repository, tag)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return "", nil, err
-	}
-
-	// Add authentication if available
-	if o.token != "" {
-		req.Header.Set("Authorization", "Bearer "+o.token)
-	} else if o.username != "" && o.password != "" {
-		auth := base64.StdEncoding.EncodeToString([]byte(o.username + ":" + o.password))
-		req.Header.Set("Authorization", "Basic "+auth)
-	}
-
 	// Request Docker manifest schema v2 and OCI manifest/index
 	// Support both single-platform and multi-platform images
-	req.Header.Set("Accept", strings.Join([]string{
+	resp, err := o.get(ctx, o.client, apiURL,
 		"application/vnd.oci.image.index.v1+json",                   // OCI Index (multi-platform)
 		"application/vnd.oci.image.manifest.v1+json",                // OCI Manifest
 		"application/vnd.docker.distribution.manifest.list.v2+json", // Docker Manifest List (multi-platform)
 		"application/vnd.docker.distribution.manifest.v2+json",      // Docker Manifest v2
-	}, ", "))
-
-	resp, err := o.client.Do(req) //nolint:gosec // G704
+	)
 	if err != nil {
 		return "", nil, err
 	}
 	defer resp.Body.Close()
 
-	// Handle 401 Unauthorized - need to get bearer token
-	if resp.StatusCode == http.StatusUnauthorized {
-		authHeader := resp.Header.Get("WWW-Authenticate")
-		if authHeader != "" {
-			if err := o.getBearerToken(ctx, authHeader); err != nil {
-				return "", nil, fmt.Errorf("failed to get bearer token: %w", err)
-			}
-
-			// Retry with bearer token
-			req, err = http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-			if err != nil {
-				return "", nil, err
-			}
-			req.Header.Set("Authorization", "Bearer "+o.token)
-			req.Header.Set("Accept", strings.Join([]string{
-				"application/vnd.oci.image.index.v1+json",
-				"application/vnd.oci.image.manifest.v1+json",
-				"application/vnd.docker.distribution.manifest.list.v2+json",
-				"application/vnd.docker.distribution.manifest.v2+json",
-			}, ", "))
-
-			resp, err = o.client.Do(req) //nolint:gosec // G704
-			if err != nil {
-				return "", nil, err
-			}
-			defer resp.Body.Close()
-		}
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 		return "", nil, fmt.Errorf("failed to get manifest: status %d: %s", resp.StatusCode, string(body))
@@ -529,6 +498,28 @@ func (o *OCI) getImageDigest(ctx context.Context, tag string) (string, *time.Tim
 	return digest, &now, nil
 }
 
+// FetchBlob opens the blob with digest in the repository, e.g. a layer of an
+// ORAS artifact. The caller closes the returned body.
+func (o *OCI) FetchBlob(ctx context.Context, digest string) (io.ReadCloser, error) {
+	// Docker Registry HTTP API V2: GET /v2/<name>/blobs/<digest>
+	apiURL := fmt.Sprintf("%s://%s/v2/%s/blobs/%s", o.getScheme(), o.Registry, o.Repository, digest)
+
+	// Blobs can be large, so the download is bounded by ctx rather than the
+	// API timeout. Registries commonly redirect to a CDN; net/http drops the
+	// Authorization header when the redirect leaves the registry host.
+	cl := &http.Client{Transport: o.client.Transport}
+	resp, err := o.get(ctx, cl, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		return nil, fmt.Errorf("failed to get blob %s: status %d: %s", digest, resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
 // privateIPNets defines private/internal IP address ranges that should be blocked for SSRF protection.
 var privateIPNets = func() []*net.IPNet {
 	cidrs := []string{
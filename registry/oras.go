@@ -0,0 +1,266 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/internal/scheme"
+	"github.com/linyows/dewy/logging"
+)
+
+const (
+	orasFormat string = "oras://<registry>/<repository>"
+
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// ociTitleAnnotation names the file a layer was pushed from; oras push
+	// sets it for every file.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// ociCreatedAnnotation is the creation time oras push puts on the manifest.
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+	// platformAnnotation marks the platform of a layer as "<os>/<arch>" when
+	// one manifest holds the archives of several platforms, e.g.
+	// oras push --annotation-file with {"app.tar.gz": {"dewy.platform": "linux/amd64"}}.
+	platformAnnotation = "dewy.platform"
+)
+
+// ORAS is a registry on OCI artifacts pushed with ORAS: the tags of the
+// repository are the versions and a layer of the manifest is the archive.
+// Tags and authentication are handled as for container images by OCI.
+type ORAS struct {
+	Artifact  string `schema:"artifact"`
+	MediaType string `schema:"media-type"`
+	oci       *OCI
+	// ociQuery holds the options of OCI, carried over to the artifact URL so
+	// the download uses the same registry settings.
+	ociQuery string
+	logger   *logging.Logger
+}
+
+// ociDescriptor is a descriptor of a manifest or a layer.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform,omitempty"`
+}
+
+// ociManifest is an OCI image manifest or index; which one is told by MediaType.
+type ociManifest struct {
+	MediaType   string            `json:"mediaType"`
+	Layers      []ociDescriptor   `json:"layers"`
+	Manifests   []ociDescriptor   `json:"manifests"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// NewORAS returns ORAS. The query of u takes artifact and media-type on top
+// of the options of OCI.
+func NewORAS(ctx context.Context, u string, log *logging.Logger) (*ORAS, error) {
+	ur, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if ur.Host == "" || strings.Trim(ur.Path, "/") == "" {
+		return nil, fmt.Errorf("registry and repository are required: %s", orasFormat)
+	}
+
+	// The decoder rejects unknown keys, so the options of ORAS are taken
+	// out before the rest goes to OCI.
+	q := ur.Query()
+	o := &ORAS{logger: log}
+	own := url.Values{}
+	for _, k := range []string{"artifact", "media-type"} {
+		if v, ok := q[k]; ok {
+			own[k] = v
+			q.Del(k)
+		}
+	}
+	if err := decoder.Decode(o, own); err != nil {
+		return nil, err
+	}
+
+	ur.Scheme = scheme.OCI
+	ur.RawQuery = q.Encode()
+	o.ociQuery = ur.RawQuery
+	if o.oci, err = NewOCI(ctx, ur.String(), log); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// artifactURL returns the URL of a layer, in the form
+// oras://<registry>/<repository>/-/blobs/<digest>/<name>[?<OCI options>].
+func (o *ORAS) artifactURL(digest, name string) string {
+	u := fmt.Sprintf("%s://%s/-/blobs/%s/%s", scheme.ORAS, o, digest, url.PathEscape(name))
+	if o.ociQuery != "" {
+		u += "?" + o.ociQuery
+	}
+	return u
+}
+
+// String to string.
+func (o *ORAS) String() string {
+	return o.oci.Registry + "/" + o.oci.Repository
+}
+
+// Current returns current artifact.
+func (o *ORAS) Current(ctx context.Context) (*CurrentResponse, error) {
+	tags, err := o.oci.listTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags found in registry %s", o)
+	}
+	tag, err := o.oci.findLatestTag(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find latest tag: %w", err)
+	}
+
+	m, err := o.manifest(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	var createdAt *time.Time
+	if t, err := time.Parse(time.RFC3339, m.Annotations[ociCreatedAnnotation]); err == nil {
+		createdAt = &t
+	}
+
+	layer, found := o.selectLayer(m.Layers)
+	if !found {
+		return nil, &ArtifactNotFoundError{
+			ArtifactName: o.Artifact,
+			ReleaseTime:  createdAt,
+			Message:      fmt.Sprintf("artifact not found: %s:%s has no layer for %s/%s", o, tag, getOS(), getArch()),
+		}
+	}
+	name := layer.Annotations[ociTitleAnnotation]
+	o.logger.Debug("Selected layer", slog.String("name", name), slog.String("digest", layer.Digest))
+
+	res := &CurrentResponse{
+		ID:          time.Now().Format(ISO8601),
+		Tag:         tag,
+		ArtifactURL: o.artifactURL(layer.Digest, name),
+		CreatedAt:   createdAt,
+		Slot:        extractSlot(tag, o.oci.CalVer),
+		Size:        layer.Size,
+	}
+	if strings.HasPrefix(layer.Digest, "sha256:") {
+		res.Checksum = layer.Digest
+	}
+	return res, nil
+}
+
+// manifest returns the image manifest of ref. An index is resolved to the
+// manifest of the current platform.
+func (o *ORAS) manifest(ctx context.Context, ref string) (*ociManifest, error) {
+	m, err := o.fetchManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if m.MediaType != ociIndexMediaType {
+		return m, nil
+	}
+
+	for _, d := range m.Manifests {
+		if d.Platform != nil &&
+			slices.Contains(osAliases(getOS()), strings.ToLower(d.Platform.OS)) &&
+			slices.Contains(archAliases(getArch()), strings.ToLower(d.Platform.Architecture)) {
+			return o.fetchManifest(ctx, d.Digest)
+		}
+	}
+	// An index without a manifest for this platform leaves nothing to
+	// deploy here, the same as a release without a matching asset.
+	return &ociManifest{Annotations: m.Annotations}, nil
+}
+
+func (o *ORAS) fetchManifest(ctx context.Context, ref string) (*ociManifest, error) {
+	apiURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", o.oci.getScheme(), o.oci.Registry, o.oci.Repository, ref)
+	resp, err := o.oci.get(ctx, o.oci.client, apiURL, ociIndexMediaType, ociManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get manifest %s: status %d: %s", ref, resp.StatusCode, string(body))
+	}
+
+	var m ociManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", ref, err)
+	}
+	if m.MediaType == "" {
+		m.MediaType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+	}
+	return &m, nil
+}
+
+// selectLayer picks the archive among layers. Only layers with a file name
+// are candidates, narrowed to media-type when set. The artifact option picks
+// one by name; otherwise a layer annotated with the current platform wins,
+// then a single candidate, then the name matching the platform.
+func (o *ORAS) selectLayer(layers []ociDescriptor) (ociDescriptor, bool) {
+	var candidates []ociDescriptor
+	for _, l := range layers {
+		if l.Annotations[ociTitleAnnotation] == "" {
+			continue
+		}
+		if o.MediaType != "" && l.MediaType != o.MediaType {
+			continue
+		}
+		candidates = append(candidates, l)
+	}
+
+	if o.Artifact != "" {
+		for _, l := range candidates {
+			if l.Annotations[ociTitleAnnotation] == o.Artifact {
+				return l, true
+			}
+		}
+		return ociDescriptor{}, false
+	}
+
+	for _, l := range candidates {
+		if os, arch, ok := strings.Cut(l.Annotations[platformAnnotation], "/"); ok &&
+			slices.Contains(osAliases(getOS()), strings.ToLower(os)) &&
+			slices.Contains(archAliases(getArch()), strings.ToLower(arch)) {
+			return l, true
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	names := make([]string, 0, len(candidates))
+	for _, l := range candidates {
+		names = append(names, l.Annotations[ociTitleAnnotation])
+	}
+	if name, found := MatchArtifactByPlatform(names); found {
+		return candidates[slices.Index(names, name)], true
+	}
+	return ociDescriptor{}, false
+}
+
+// Report does nothing, as OCI does not: a registry has no place for the
+// record without pushing another artifact.
+func (o *ORAS) Report(ctx context.Context, req *ReportRequest) error {
+	return nil
+}
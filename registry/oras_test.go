@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newORASServer serves the repository "team/app" whose tags hold
+// manifests of archive layers. Manifests and indexes are keyed by tag or
+// digest.
+func newORASServer(t *testing.T, tags []string, manifests map[string]any) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/team/app/tags/list":
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "team/app", "tags": tags})
+		case strings.HasPrefix(r.URL.Path, "/v2/team/app/manifests/"):
+			m, ok := manifests[strings.TrimPrefix(r.URL.Path, "/v2/team/app/manifests/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(m)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func orasLayer(name, digest string, annotations ...string) map[string]any {
+	a := map[string]string{ociTitleAnnotation: name}
+	for i := 0; i+1 < len(annotations); i += 2 {
+		a[annotations[i]] = annotations[i+1]
+	}
+	return map[string]any{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": digest, "size": 42, "annotations": a}
+}
+
+func orasManifest(layers ...map[string]any) map[string]any {
+	return map[string]any{
+		"mediaType":   ociManifestMediaType,
+		"layers":      layers,
+		"annotations": map[string]string{ociCreatedAnnotation: "2026-03-01T12:00:00Z"},
+	}
+}
+
+func TestNewORAS(t *testing.T) {
+	ctx := context.Background()
+	o, err := NewORAS(ctx, "oras://ghcr.io/team/app?artifact=app.tar.gz&media-type=application/x-tar&pre-release=true", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Artifact != "app.tar.gz" || o.MediaType != "application/x-tar" || !o.oci.PreRelease || o.String() != "ghcr.io/team/app" {
+		t.Errorf("got %+v (oci %+v)", o, o.oci)
+	}
+	for _, u := range []string{"oras:///team/app", "oras://ghcr.io", "oras://ghcr.io/team/app?unknown=1"} {
+		if _, err := NewORAS(ctx, u, testLogger()); err == nil {
+			t.Errorf("NewORAS(%q) succeeded", u)
+		}
+	}
+}
+
+func TestORASCurrent(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	host := newORASServer(t, []string{"v1.0.0", "v1.1.0", "v1.2.0-rc.1", "latest"}, map[string]any{
+		"v1.0.0": orasManifest(orasLayer("app_linux_amd64.tar.gz", "sha256:old")),
+		"v1.1.0": orasManifest(
+			orasLayer("app_darwin_arm64.tar.gz", "sha256:darwin"),
+			orasLayer("app_linux_x86_64.tar.gz", "sha256:linux"),
+			map[string]any{"mediaType": "application/vnd.oci.empty.v1+json", "digest": "sha256:empty", "size": 2},
+		),
+	})
+	ctx := context.Background()
+
+	o, err := NewORAS(ctx, "oras://"+host+"/team/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := o.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.1.0" || res.Checksum != "sha256:linux" || res.Size != 42 || res.CreatedAt == nil ||
+		res.ArtifactURL != "oras://"+host+"/team/app/-/blobs/sha256:linux/app_linux_x86_64.tar.gz" {
+		t.Errorf("got %+v", res)
+	}
+
+	// The options of OCI are carried over to the artifact; those of ORAS
+	// only pick the layer.
+	withOpts, err := NewORAS(ctx, "oras://"+host+"/team/app?constraint=~1.1&artifact=app_linux_x86_64.tar.gz", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := withOpts.Current(ctx); err != nil ||
+		res.ArtifactURL != "oras://"+host+"/team/app/-/blobs/sha256:linux/app_linux_x86_64.tar.gz?constraint=~1.1" {
+		t.Errorf("Current = %+v, %v, want the constraint in the artifact URL", res, err)
+	}
+
+	o.Artifact = "app_darwin_arm64.tar.gz"
+	if res, err = o.Current(ctx); err != nil || res.Checksum != "sha256:darwin" {
+		t.Errorf("Current = %+v, %v", res, err)
+	}
+
+	o.Artifact = "app_windows_amd64.zip"
+	_, err = o.Current(ctx)
+	var notFound *ArtifactNotFoundError
+	if !errors.As(err, &notFound) || notFound.ReleaseTime == nil {
+		t.Errorf("err = %v, want ArtifactNotFoundError with the created time", err)
+	}
+}
+
+func TestORASSelectLayer(t *testing.T) {
+	setTestPlatform(t, "darwin", "arm64")
+	layer := func(name, mediaType, platform string) ociDescriptor {
+		d := ociDescriptor{MediaType: mediaType, Digest: "sha256:" + name, Annotations: map[string]string{ociTitleAnnotation: name}}
+		if platform != "" {
+			d.Annotations[platformAnnotation] = platform
+		}
+		return d
+	}
+
+	tests := []struct {
+		desc      string
+		mediaType string
+		layers    []ociDescriptor
+		want      string
+	}{
+		{"platform annotation", "", []ociDescriptor{layer("a.tar.gz", "x", "linux/amd64"), layer("b.tar.gz", "x", "macos/arm64")}, "b.tar.gz"},
+		{"single candidate", "", []ociDescriptor{layer("app.tar.gz", "x", "")}, "app.tar.gz"},
+		{"media type", "application/gzip", []ociDescriptor{layer("app.txt", "text/plain", ""), layer("app.tar.gz", "application/gzip", "")}, "app.tar.gz"},
+		{"name", "", []ociDescriptor{layer("app_linux_amd64.tar.gz", "x", ""), layer("app_darwin_arm64.tar.gz", "x", "")}, "app_darwin_arm64.tar.gz"},
+		{"none", "", []ociDescriptor{layer("app_linux_amd64.tar.gz", "x", ""), layer("app_windows_amd64.zip", "x", "")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			o := &ORAS{MediaType: tt.mediaType}
+			got, found := o.selectLayer(tt.layers)
+			if name := got.Annotations[ociTitleAnnotation]; name != tt.want || found != (tt.want != "") {
+				t.Errorf("selectLayer = %q, %v; want %q", name, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestORASCurrentIndex(t *testing.T) {
+	setTestPlatform(t, "linux", "arm64")
+	platform := func(os, arch string) map[string]string { return map[string]string{"os": os, "architecture": arch} }
+	host := newORASServer(t, []string{"v2.0.0"}, map[string]any{
+		"v2.0.0": map[string]any{
+			"mediaType": ociIndexMediaType,
+			"manifests": []map[string]any{
+				{"mediaType": ociManifestMediaType, "digest": "sha256:m-amd64", "platform": platform("linux", "amd64")},
+				{"mediaType": ociManifestMediaType, "digest": "sha256:m-arm64", "platform": platform("linux", "arm64")},
+			},
+		},
+		"sha256:m-amd64": orasManifest(orasLayer("app.tar.gz", "sha256:amd64")),
+		"sha256:m-arm64": orasManifest(orasLayer("app.tar.gz", "sha256:arm64")),
+	})
+	ctx := context.Background()
+
+	o, err := NewORAS(ctx, "oras://"+host+"/team/app", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := o.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Checksum != "sha256:arm64" {
+		t.Errorf("got %+v", res)
+	}
+
+	setTestPlatform(t, "windows", "amd64")
+	var notFound *ArtifactNotFoundError
+	if _, err := o.Current(ctx); !errors.As(err, &notFound) {
+		t.Errorf("err = %v, want ArtifactNotFoundError", err)
+	}
+}
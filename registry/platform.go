@@ -30,18 +30,14 @@ func getOS() string {
 
 // MatchArtifactByPlatform finds the first artifact name that matches current OS and architecture.
 func MatchArtifactByPlatform(artifactNames []string) (string, bool) {
-	arch := getArch()
-	os := getOS()
+	return MatchArtifactForPlatform(artifactNames, getOS(), getArch())
+}
 
-	archMatches := []string{arch}
-	if arch == "amd64" {
-		archMatches = append(archMatches, "x86_64")
-	}
-
-	osMatches := []string{os}
-	if os == "darwin" {
-		osMatches = append(osMatches, "macos")
-	}
+// MatchArtifactForPlatform finds the first artifact name that matches the
+// given OS and architecture, e.g. those a gRPC client sent.
+func MatchArtifactForPlatform(artifactNames []string, os, arch string) (string, bool) {
+	archMatches := archAliases(arch)
+	osMatches := osAliases(os)
 
 	for _, name := range artifactNames {
 		if isArchiveFile(name) && matchesPlatform(name, archMatches, osMatches) {
@@ -52,6 +48,22 @@ func MatchArtifactByPlatform(artifactNames []string) (string, bool) {
 	return "", false
 }
 
+// archAliases returns the names artifacts use for arch.
+func archAliases(arch string) []string {
+	if arch == "amd64" {
+		return []string{arch, "x86_64"}
+	}
+	return []string{arch}
+}
+
+// osAliases returns the names artifacts use for os.
+func osAliases(os string) []string {
+	if os == "darwin" {
+		return []string{os, "macos"}
+	}
+	return []string{os}
+}
+
 // matchesPlatform checks if artifact name contains both arch and OS patterns.
 func matchesPlatform(artifactName string, archMatches, osMatches []string) bool {
 	n := strings.ToLower(artifactName)
@@ -2,7 +2,9 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -11,7 +13,21 @@ import (
 	"github.com/linyows/dewy/logging"
 )
 
-var decoder = schema.NewDecoder()
+var decoder = newDecoder()
+
+// newDecoder returns the decoder of registry URL queries. Durations are
+// written the Go way, e.g. keepalive=30s.
+func newDecoder() *schema.Decoder {
+	d := schema.NewDecoder()
+	d.RegisterConverter(time.Duration(0), func(s string) reflect.Value {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(v)
+	})
+	return d
+}
 
 // factoryFn constructs a Registry from a parsed URL. logger is optional
 // (NewGRPC ignores it) but kept in the signature so all registries plug into
@@ -22,18 +38,42 @@ var factories = map[string]factoryFn{
 	scheme.GHR: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
 		return NewGHR(ctx, url, log)
 	},
+	scheme.GLR: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewGLR(ctx, url, log)
+	},
+	scheme.Gitea: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewGitea(ctx, url, log)
+	},
+	scheme.Forgejo: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewGitea(ctx, url, log)
+	},
 	scheme.S3: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
 		return NewS3(ctx, url, log)
 	},
 	scheme.GS: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
 		return NewGS(ctx, url, log)
 	},
+	scheme.AzBlob: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewAzBlob(ctx, url, log)
+	},
+	scheme.HTTP: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewHTTP(ctx, url, log)
+	},
+	scheme.HTTPS: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewHTTP(ctx, url, log)
+	},
+	scheme.File: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewFile(ctx, url, log)
+	},
 	scheme.GRPC: func(ctx context.Context, url string, _ *logging.Logger) (Registry, error) {
 		return NewGRPC(ctx, url)
 	},
 	scheme.OCI: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
 		return NewOCI(ctx, url, log)
 	},
+	scheme.ORAS: func(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
+		return NewORAS(ctx, url, log)
+	},
 }
 
 type Registry interface {
@@ -43,6 +83,19 @@ type Registry interface {
 	Report(context.Context, *ReportRequest) error
 }
 
+// ErrWatchUnsupported is returned by Watch when the registry server cannot
+// push releases, so the caller has to keep polling Current.
+var ErrWatchUnsupported = errors.New("registry does not support watch")
+
+// Watcher is implemented by registries that can push new releases instead
+// of being polled.
+type Watcher interface {
+	// Watch calls fn with the current artifact and then with every new one
+	// until ctx is done or the stream ends. It returns nil when the server
+	// closes the stream; the caller decides whether to watch again.
+	Watch(ctx context.Context, fn func(*CurrentResponse)) error
+}
+
 // CurrentResponse is the response to get the current artifact.
 type CurrentResponse struct {
 	// ID uniquely identifies the response.
@@ -57,6 +110,15 @@ type CurrentResponse struct {
 	// Slot is the deployment slot extracted from build metadata (e.g., "blue", "green").
 	// This is used for blue/green deployment support.
 	Slot string
+	// Checksum is the digest of the artifact as "sha256:<hex>", when the
+	// registry knows it. The download is verified against it.
+	Checksum string
+	// Size is the size of the artifact in bytes, or 0 when unknown.
+	Size int64
+	// Labels are arbitrary metadata the registry attached to the release.
+	Labels map[string]string
+	// ReleaseNotes describes the release.
+	ReleaseNotes string
 }
 
 // ReportRequest is the request to report the result of deploying the artifact.
@@ -69,6 +131,19 @@ type ReportRequest struct {
 	Command string
 	// Err is the error that occurred during deployment. If Err is nil, the deployment is considered successful.
 	Err error
+	// Hostname is the host the artifact was deployed to.
+	Hostname string
+	// InstanceID identifies the dewy process that deployed, among several on one host.
+	InstanceID string
+	// PreviousTag is the tag that was deployed before Tag.
+	PreviousTag string
+	// Duration is how long the deployment took.
+	Duration time.Duration
+	// Phase is the deploy phase the outcome was decided in: "promote" for a
+	// release that went live, and for a failed deploy the phase that failed,
+	// one of "download", "extract", "render", "hook", "promote" or
+	// "health-check".
+	Phase string
 }
 
 func New(ctx context.Context, url string, log *logging.Logger) (Registry, error) {
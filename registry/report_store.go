@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Report is a deployment report received by Server.
+type Report struct {
+	Time        time.Time `json:"time"`
+	ID          string    `json:"id"`
+	Tag         string    `json:"tag"`
+	Command     string    `json:"command"`
+	Error       string    `json:"error,omitempty"`
+	Hostname    string    `json:"hostname,omitempty"`
+	InstanceID  string    `json:"instance_id,omitempty"`
+	PreviousTag string    `json:"previous_tag,omitempty"`
+	Duration    string    `json:"duration,omitempty"`
+	Phase       string    `json:"phase,omitempty"`
+}
+
+// ReportQuery selects reports. Zero fields match everything.
+type ReportQuery struct {
+	Tag      string
+	Hostname string
+	Failed   bool // only reports with an error
+	Since    time.Time
+	Limit    int // the most recent Limit reports
+}
+
+func (q ReportQuery) match(r Report) bool {
+	return (q.Tag == "" || r.Tag == q.Tag) &&
+		(q.Hostname == "" || r.Hostname == q.Hostname) &&
+		(!q.Failed || r.Error != "") &&
+		(q.Since.IsZero() || !r.Time.Before(q.Since))
+}
+
+// ReportStore keeps reports in memory and, when opened with a path, appends
+// them to that file as JSON Lines so they survive a restart.
+type ReportStore struct {
+	mu      sync.RWMutex
+	path    string
+	reports []Report
+}
+
+// OpenReportStore loads the reports recorded in path, if any. An empty path
+// keeps reports in memory only.
+func OpenReportStore(path string) (*ReportStore, error) {
+	s := &ReportStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		var r Report
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		s.reports = append(s.reports, r)
+	}
+	return s, sc.Err()
+}
+
+// Add records r.
+func (s *ReportStore) Add(r Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(append(b, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	s.reports = append(s.reports, r)
+	return nil
+}
+
+// Query returns the reports matching q, oldest first.
+func (s *ReportStore) Query(q ReportQuery) []Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := []Report{}
+	for _, r := range s.reports {
+		if q.match(r) {
+			list = append(list, r)
+		}
+	}
+	if q.Limit > 0 && len(list) > q.Limit {
+		list = list[len(list)-q.Limit:]
+	}
+	return list
+}
+
+// ServeHTTP answers GET /reports?tag=&hostname=&failed=true&since=<RFC 3339>&limit=N
+// with the matching reports as a JSON array.
+func (s *ReportStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	q := ReportQuery{
+		Tag:      v.Get("tag"),
+		Hostname: v.Get("hostname"),
+		Failed:   v.Get("failed") == "true",
+	}
+	if since := v.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		q.Since = t
+	}
+	if limit := v.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Query(q)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports.jsonl")
+	s, err := OpenReportStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, r := range []Report{
+		{Time: now, Tag: "v1.0.0", Hostname: "web-1"},
+		{Time: now.Add(time.Minute), Tag: "v1.1.0", Hostname: "web-1", Error: "health check failed"},
+		{Time: now.Add(2 * time.Minute), Tag: "v1.1.0", Hostname: "web-2"},
+	} {
+		if err := s.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reopening loads what was recorded.
+	s, err = OpenReportStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := func(list []Report) []string {
+		var h []string
+		for _, r := range list {
+			h = append(h, r.Tag+"@"+r.Hostname)
+		}
+		return h
+	}
+	tests := []struct {
+		q    ReportQuery
+		want []string
+	}{
+		{ReportQuery{}, []string{"v1.0.0@web-1", "v1.1.0@web-1", "v1.1.0@web-2"}},
+		{ReportQuery{Tag: "v1.1.0"}, []string{"v1.1.0@web-1", "v1.1.0@web-2"}},
+		{ReportQuery{Failed: true}, []string{"v1.1.0@web-1"}},
+		{ReportQuery{Since: now.Add(time.Minute), Limit: 1}, []string{"v1.1.0@web-2"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(hosts(s.Query(tt.q)), tt.want); diff != "" {
+			t.Errorf("query %+v: %s", tt.q, diff)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports?hostname=web-2", nil))
+	var got []Report
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(hosts(got), []string{"v1.1.0@web-2"}); diff != "" {
+		t.Error(diff)
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports?limit=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for an invalid limit", w.Code)
+	}
+}
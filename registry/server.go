@@ -0,0 +1,383 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linyows/dewy/logging"
+	pb "github.com/linyows/dewy/registry/gen/dewy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultWatchInterval is how often Server.Watch looks for a new release
+// when none is set.
+const DefaultWatchInterval = 10 * time.Second
+
+// Server is a reference implementation of RegistryService. It serves the
+// releases of a local directory laid out as <dir>/<tag>/<artifacts>, or
+// passes through the current release of an existing dewy registry, and keeps
+// the reports it receives in a ReportStore.
+type Server struct {
+	pb.UnimplementedRegistryServiceServer
+
+	// Dir is the release directory. When empty, Upstream is used.
+	Dir string
+	// Upstream is the registry whose current release is passed through. It
+	// resolves the artifact for the platform the server runs on, and clients
+	// download from the upstream storage directly.
+	Upstream Registry
+	// ArtifactURL is the base URL Handler is reachable at from the clients,
+	// e.g. "http://registry.internal:8080". Artifact URLs of Dir point there.
+	ArtifactURL string
+	// PreRelease includes pre-release tags of Dir.
+	PreRelease bool
+	// CalVer is the CalVer format of the tags of Dir; SemVer when empty.
+	CalVer string
+	// WatchInterval is how often Watch looks for a new release. An upstream
+	// release is reused for as long, however many clients ask.
+	WatchInterval time.Duration
+	// Reports keeps the received reports. Report fails without one.
+	Reports *ReportStore
+	// Token is the bearer token every RPC and /reports must present, as the
+	// token-file option of grpc:// sends it. Without it the RPCs are open and
+	// Handler does not serve /reports.
+	Token string
+
+	logger *logging.Logger
+	sumMu  sync.Mutex
+	sums   map[string]fileSum
+	upMu   sync.Mutex
+	upRes  *pb.CurrentResponse
+	upAt   time.Time
+}
+
+// fileSum is the cached checksum of an artifact, valid while the file keeps
+// its size and modification time.
+type fileSum struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// NewServer returns a Server for dir or, when dir is empty, upstream.
+func NewServer(dir string, upstream Registry, reports *ReportStore, log *logging.Logger) (*Server, error) {
+	if (dir == "") == (upstream == nil) {
+		return nil, errors.New("registry server needs either a release directory or an upstream registry")
+	}
+	return &Server{
+		Dir:           dir,
+		Upstream:      upstream,
+		WatchInterval: DefaultWatchInterval,
+		Reports:       reports,
+		logger:        log,
+		sums:          make(map[string]fileSum),
+	}, nil
+}
+
+// Current returns the current artifact for the client's platform. An
+// upstream resolves the artifact for the server's platform only, so clients
+// on another platform get FailedPrecondition rather than an artifact they
+// cannot run.
+func (s *Server) Current(ctx context.Context, req *pb.CurrentRequest) (*pb.CurrentResponse, error) {
+	if s.Dir == "" {
+		if err := checkUpstreamPlatform(req); err != nil {
+			return nil, err
+		}
+		return s.upstreamCurrent(ctx)
+	}
+	return s.dirCurrent(req)
+}
+
+// checkUpstreamPlatform fails when req asks for another platform than the
+// server runs on. A client that sends no platform gets the server's.
+func checkUpstreamPlatform(req *pb.CurrentRequest) error {
+	goos, goarch := req.GetOs(), req.GetArch()
+	if (goos == "" || goos == getOS()) && (goarch == "" || goarch == getArch()) {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition,
+		"upstream registry serves %s/%s artifacts only, not %s/%s", getOS(), getArch(), goos, goarch)
+}
+
+func (s *Server) upstreamCurrent(ctx context.Context) (*pb.CurrentResponse, error) {
+	s.upMu.Lock()
+	defer s.upMu.Unlock()
+	if s.upRes != nil && time.Since(s.upAt) < s.WatchInterval {
+		return s.upRes, nil
+	}
+
+	res, err := s.Upstream.Current(ctx)
+	if err != nil {
+		var notFound *ArtifactNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	cres := &pb.CurrentResponse{
+		Id:          res.ID,
+		Tag:         res.Tag,
+		ArtifactUrl: res.ArtifactURL,
+		Slot:        &res.Slot,
+		Labels:      res.Labels,
+	}
+	if res.CreatedAt != nil {
+		cres.CreatedAt = timestamppb.New(*res.CreatedAt)
+	}
+	if res.Checksum != "" {
+		cres.Checksum = &res.Checksum
+	}
+	if res.Size > 0 {
+		cres.Size = &res.Size
+	}
+	if res.ReleaseNotes != "" {
+		cres.ReleaseNotes = &res.ReleaseNotes
+	}
+	s.upRes, s.upAt = cres, time.Now()
+	return cres, nil
+}
+
+func (s *Server) dirCurrent(req *pb.CurrentRequest) (*pb.CurrentResponse, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var tags []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			tags = append(tags, e.Name())
+		}
+	}
+	var version Version
+	var tag string
+	if s.CalVer != "" {
+		version, tag, err = FindLatestCalVer(tags, s.CalVer, s.PreRelease)
+	} else {
+		version, tag, err = FindLatestSemVer(tags, s.PreRelease)
+	}
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	files, err := os.ReadDir(filepath.Join(s.Dir, tag))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var names []string
+	for _, f := range files {
+		if f.Type().IsRegular() {
+			names = append(names, f.Name())
+		}
+	}
+	name, found := "", false
+	if want := req.GetArifactName(); want != "" {
+		for _, n := range names {
+			if n == want {
+				name, found = n, true
+				break
+			}
+		}
+	} else {
+		name, found = MatchArtifactForPlatform(names, req.GetOs(), req.GetArch())
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "artifact not found in %s for %s/%s", tag, req.GetOs(), req.GetArch())
+	}
+
+	path := filepath.Join(s.Dir, tag, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sum, err := s.checksum(path, info)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	slot := version.GetBuildMetadata()
+	size := info.Size()
+	return &pb.CurrentResponse{
+		Id:          sum,
+		Tag:         version.String(),
+		ArtifactUrl: s.artifactURL(tag, name),
+		CreatedAt:   timestamppb.New(info.ModTime()),
+		Slot:        &slot,
+		Checksum:    &sum,
+		Size:        &size,
+	}, nil
+}
+
+func (s *Server) artifactURL(tag, name string) string {
+	return strings.TrimSuffix(s.ArtifactURL, "/") + "/artifacts/" + url.PathEscape(tag) + "/" + url.PathEscape(name)
+}
+
+// checksum returns "sha256:<hex>" of the file at path, hashing it again only
+// when it changed since the last call.
+func (s *Server) checksum(path string, info os.FileInfo) (string, error) {
+	s.sumMu.Lock()
+	defer s.sumMu.Unlock()
+	if c, ok := s.sums[path]; ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		return c.sum, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := "sha256:" + hex.EncodeToString(h.Sum(nil))
+	s.sums[path] = fileSum{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	return sum, nil
+}
+
+// Report records the result of a deployment.
+func (s *Server) Report(_ context.Context, req *pb.ReportRequest) (*emptypb.Empty, error) {
+	if s.Reports == nil {
+		return nil, status.Error(codes.Unimplemented, "reports are not recorded")
+	}
+	r := Report{
+		Time:        time.Now(),
+		ID:          req.Id,
+		Tag:         req.Tag,
+		Command:     req.Command,
+		Error:       req.GetErr(),
+		Hostname:    req.GetHostname(),
+		InstanceID:  req.GetInstanceId(),
+		PreviousTag: req.GetPreviousTag(),
+		Phase:       req.GetPhase(),
+	}
+	if req.Duration != nil {
+		r.Duration = req.Duration.AsDuration().String()
+	}
+	if err := s.Reports.Add(r); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if s.logger != nil {
+		s.logger.Info("Report received",
+			slog.String("tag", r.Tag),
+			slog.String("hostname", r.Hostname),
+			slog.String("error", r.Error))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Watch sends the current artifact and then every new one, looking for a new
+// release every WatchInterval. A failed lookup is logged and retried; the
+// client already has the last good release.
+func (s *Server) Watch(req *pb.CurrentRequest, stream pb.RegistryService_WatchServer) error {
+	ctx := stream.Context()
+	interval := s.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	var last string
+	for {
+		res, err := s.Current(ctx, req)
+		switch {
+		case err != nil:
+			if s.logger != nil && status.Code(err) != codes.NotFound {
+				s.logger.Warn("Watch lookup failed", slog.String("error", err.Error()))
+			}
+		case res.Tag+" "+res.ArtifactUrl != last:
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			last = res.Tag + " " + res.ArtifactUrl
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ServerOptions returns the gRPC server options that require Token on every
+// RPC when it is set.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	if s.Token == "" {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.checkToken(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.checkToken(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func (s *Server) checkToken(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if s.validToken(v) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid token")
+}
+
+func (s *Server) validToken(authorization string) bool {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+// Handler serves the artifacts of Dir under /artifacts/, except dot entries,
+// and, when Token is set, the recorded reports as JSON on /reports to clients
+// presenting it.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	if s.Dir != "" {
+		files := http.FileServer(http.Dir(s.Dir))
+		mux.Handle("GET /artifacts/", http.StripPrefix("/artifacts/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Dot entries, such as the .dewy-reports of a file registry, are
+			// not releases.
+			for _, elem := range strings.Split(r.URL.Path, "/") {
+				if strings.HasPrefix(elem, ".") {
+					http.NotFound(w, r)
+					return
+				}
+			}
+			files.ServeHTTP(w, r)
+		})))
+	}
+	if s.Reports != nil && s.Token != "" {
+		mux.HandleFunc("GET /reports", func(w http.ResponseWriter, r *http.Request) {
+			if !s.validToken(r.Header.Get("Authorization")) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="dewy-registry"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			s.Reports.ServeHTTP(w, r)
+		})
+	}
+	return mux
+}
@@ -0,0 +1,282 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/linyows/dewy/registry/gen/dewy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// startTestServer serves srv over gRPC on a loopback port and returns a
+// client dialed to it.
+func startTestServer(t *testing.T, srv *Server) *GRPC {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer(srv.ServerOptions()...)
+	pb.RegisterRegistryServiceServer(gs, srv)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	g := &GRPC{NoTLS: true}
+	if err := g.Dial(context.Background(), lis.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func writeRelease(t *testing.T, dir, tag string, files ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, tag), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, tag, f), []byte(tag+"/"+f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestServerDir(t *testing.T) {
+	TestArch = "arm64"
+	TestOS = "linux"
+	defer func() {
+		TestArch = ""
+		TestOS = ""
+	}()
+
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_linux_arm64.tar.gz")
+	writeRelease(t, dir, "v1.1.0+blue", "app_linux_amd64.tar.gz", "app_linux_arm64.tar.gz", "app_darwin_arm64.zip")
+	writeRelease(t, dir, "v2.0.0-rc.1", "app_linux_arm64.tar.gz")
+
+	reports, err := OpenReportStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(dir, nil, reports, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(srv.Handler())
+	t.Cleanup(hs.Close)
+	srv.ArtifactURL = hs.URL
+	g := startTestServer(t, srv)
+	ctx := context.Background()
+
+	res, err := g.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tag != "v1.1.0+blue" || res.Slot != "blue" || res.ArtifactURL != hs.URL+"/artifacts/v1.1.0+blue/app_linux_arm64.tar.gz" {
+		t.Errorf("Current = %+v, want the arm64 artifact of v1.1.0+blue", res)
+	}
+	if res.Size != int64(len("v1.1.0+blue/app_linux_arm64.tar.gz")) || len(res.Checksum) != len("sha256:")+64 {
+		t.Errorf("size = %d, checksum = %q", res.Size, res.Checksum)
+	}
+	writeRelease(t, dir, ".dewy-reports/v1.1.0+blue", "shipped_to_web-1.txt")
+	if code := getStatus(t, hs.URL+"/artifacts/.dewy-reports/v1.1.0+blue/shipped_to_web-1.txt", ""); code != http.StatusNotFound {
+		t.Errorf("report record download = %d, want 404", code)
+	}
+	if res.ID != res.Checksum {
+		t.Errorf("ID = %q, want the checksum of the artifact", res.ID)
+	}
+
+	dl, err := http.Get(res.ArtifactURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(dl.Body)
+	_ = dl.Body.Close()
+	if string(body) != "v1.1.0+blue/app_linux_arm64.tar.gz" {
+		t.Errorf("download = %q", body)
+	}
+
+	if err := g.Report(ctx, &ReportRequest{ID: res.ID, Tag: res.Tag, Command: "server", Hostname: "web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := reports.Query(ReportQuery{Hostname: "web-1"}); len(got) != 1 || got[0].Tag != "v1.1.0+blue" {
+		t.Errorf("reports = %+v", got)
+	}
+
+	TestOS = "windows"
+	if _, err := g.Current(ctx); status.Code(err) != codes.NotFound {
+		t.Errorf("err = %v, want NotFound for a platform without artifact", err)
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	setTestPlatform(t, "linux", "amd64")
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_linux_amd64.tar.gz")
+	reports, err := OpenReportStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(dir, nil, reports, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a token /reports is not served at all.
+	hs := httptest.NewServer(srv.Handler())
+	t.Cleanup(hs.Close)
+	if code := getStatus(t, hs.URL+"/reports", ""); code != http.StatusNotFound {
+		t.Errorf("/reports without token configured = %d, want 404", code)
+	}
+
+	srv.Token = "secret"
+	hs = httptest.NewServer(srv.Handler())
+	t.Cleanup(hs.Close)
+	for _, tt := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		if code := getStatus(t, hs.URL+"/reports", tt.auth); code != tt.want {
+			t.Errorf("/reports with %q = %d, want %d", tt.auth, code, tt.want)
+		}
+	}
+	if code := getStatus(t, hs.URL+"/artifacts/v1.0.0/app_linux_amd64.tar.gz", ""); code != http.StatusOK {
+		t.Errorf("artifact download = %d, want 200 without token", code)
+	}
+
+	g := startTestServer(t, srv)
+	ctx := context.Background()
+	if _, err := g.Current(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Current without token: err = %v, want Unauthenticated", err)
+	}
+	if err := g.Report(ctx, &ReportRequest{Tag: "v1.0.0"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Report without token: err = %v, want Unauthenticated", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	if _, err := g.Current(ctx); err != nil {
+		t.Errorf("Current with token: %v", err)
+	}
+	if err := g.Report(ctx, &ReportRequest{Tag: "v1.0.0"}); err != nil {
+		t.Errorf("Report with token: %v", err)
+	}
+}
+
+func getStatus(t *testing.T, url, auth string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	return res.StatusCode
+}
+
+func TestServerWatch(t *testing.T) {
+	TestArch = "amd64"
+	TestOS = "linux"
+	defer func() {
+		TestArch = ""
+		TestOS = ""
+	}()
+
+	dir := t.TempDir()
+	writeRelease(t, dir, "v1.0.0", "app_linux_amd64.tar.gz")
+	srv, err := NewServer(dir, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.WatchInterval = 10 * time.Millisecond
+	g := startTestServer(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var tags []string
+	err = g.Watch(ctx, func(res *CurrentResponse) {
+		tags = append(tags, res.Tag)
+		if len(tags) == 1 {
+			writeRelease(t, dir, "v1.1.0", "app_linux_amd64.tar.gz")
+			return
+		}
+		cancel()
+	})
+	if status.Code(err) != codes.Canceled && !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "v1.0.0" || tags[1] != "v1.1.0" {
+		t.Errorf("pushed %v, want v1.0.0 then v1.1.0", tags)
+	}
+
+	if _, err := g.cl.Report(ctx, &pb.ReportRequest{}); err == nil {
+		t.Error("want an error reporting to a server without a report store")
+	}
+}
+
+func TestServerUpstream(t *testing.T) {
+	up := &stubRegistry{res: &CurrentResponse{ID: "1", Tag: "v3.0.0", ArtifactURL: "ghr://linyows/dewy/tag/v3.0.0/app.tar.gz"}}
+	srv, err := NewServer("", up, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := startTestServer(t, srv)
+	ctx := context.Background()
+
+	for range 2 {
+		res, err := g.Current(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Tag != "v3.0.0" || res.ArtifactURL != up.res.ArtifactURL {
+			t.Errorf("Current = %+v, want the upstream release", res)
+		}
+	}
+	if up.calls != 1 {
+		t.Errorf("upstream asked %d times, want 1 within the watch interval", up.calls)
+	}
+
+	_, err = srv.Current(ctx, &pb.CurrentRequest{Os: "plan9", Arch: getArch()})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("err = %v, want FailedPrecondition for another platform", err)
+	}
+	if up.calls != 1 {
+		t.Errorf("upstream asked %d times, want no call for another platform", up.calls)
+	}
+
+	if _, err := NewServer("", nil, nil, nil); err == nil {
+		t.Error("want an error without a directory or upstream")
+	}
+}
+
+type stubRegistry struct {
+	res   *CurrentResponse
+	calls int
+}
+
+func (s *stubRegistry) Current(context.Context) (*CurrentResponse, error) {
+	s.calls++
+	return s.res, nil
+}
+
+func (s *stubRegistry) Report(context.Context, *ReportRequest) error {
+	return nil
+}
@@ -0,0 +1,186 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+	pb "github.com/linyows/dewy/registry/gen/dewy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defaultRegistryGRPCAddr = ":9000"
+	defaultRegistryHTTPAddr = ":8080"
+)
+
+// runRegistry runs the registry subcommands; serve is the only one.
+func (c *cli) runRegistry(args []string) int {
+	if len(args) == 0 || args[0] != "serve" {
+		fmt.Fprintf(c.env.Err, "Error: usage: dewy registry serve (--dir DIR | --upstream URL)\n")
+		return ExitErr
+	}
+	if (c.RegistryDir == "") == (c.RegistryUpstream == "") {
+		fmt.Fprintf(c.env.Err, "Error: registry serve needs exactly one of --dir and --upstream\n")
+		return ExitErr
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		fmt.Fprintf(c.env.Err, "Error: --tls-cert and --tls-key must be given together\n")
+		return ExitErr
+	}
+	if c.TokenFile != "" && c.TLSCert == "" {
+		fmt.Fprintf(c.env.Err, "Error: --token-file requires --tls-cert\n")
+		return ExitErr
+	}
+
+	level := "ERROR"
+	if c.LogLevel != "" {
+		level = strings.ToUpper(c.LogLevel)
+	}
+	format := c.LogFormat
+	if format == "" {
+		format = "text"
+	}
+	logger := SetupLogger(level, format, c.env.Err)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var upstream registry.Registry
+	if c.RegistryUpstream != "" {
+		var err error
+		if upstream, err = registry.New(ctx, c.RegistryUpstream, logger); err != nil {
+			fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+			return ExitErr
+		}
+	}
+	reports, err := registry.OpenReportStore(c.ReportsFile)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	srv, err := registry.NewServer(c.RegistryDir, upstream, reports, logger)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	srv.PreRelease = c.PreRelease
+	srv.CalVer = c.CalVer
+	if c.TokenFile != "" {
+		if srv.Token, err = readRegistryToken(c.TokenFile); err != nil {
+			fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+			return ExitErr
+		}
+	}
+
+	grpcAddr, httpAddr := c.GRPCAddr, c.HTTPAddr
+	if grpcAddr == "" {
+		grpcAddr = defaultRegistryGRPCAddr
+	}
+	if httpAddr == "" {
+		httpAddr = defaultRegistryHTTPAddr
+	}
+	srv.ArtifactURL = c.ArtifactURL
+	if srv.ArtifactURL == "" {
+		srv.ArtifactURL = defaultArtifactURL(httpAddr, c.TLSCert != "")
+	}
+
+	opts := srv.ServerOptions()
+	if c.TLSCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(c.TLSCert, c.TLSKey)
+		if err != nil {
+			fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+			return ExitErr
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	gs := grpc.NewServer(opts...)
+	pb.RegisterRegistryServiceServer(gs, srv)
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+		return ExitErr
+	}
+	hs := &http.Server{
+		Addr:              httpAddr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: defaultAdminReadHeaderTimeout,
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- gs.Serve(lis) }()
+	go func() {
+		if c.TLSCert != "" {
+			errCh <- hs.ListenAndServeTLS(c.TLSCert, c.TLSKey)
+		} else {
+			errCh <- hs.ListenAndServe()
+		}
+	}()
+	fmt.Fprintf(c.env.Out, "Registry serving gRPC on %s and artifacts on %s\n", grpcAddr, srv.ArtifactURL)
+
+	code := ExitOK
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(c.env.Err, "Error: %s\n", err)
+			code = ExitErr
+		}
+	}
+
+	// Watch streams only end with their clients, so do not wait for them.
+	gs.Stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = hs.Shutdown(shutdownCtx)
+	return code
+}
+
+// readRegistryToken reads the bearer token of the registry server. Like the
+// admin token file, it must not be readable by group or others.
+func readRegistryToken(p string) (string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return "", fmt.Errorf("token file %s must not be accessible by group or others (mode %04o, want 0600)", p, perm)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", p)
+	}
+	return token, nil
+}
+
+// defaultArtifactURL guesses the URL clients reach the HTTP listener at: the
+// host name of this machine unless the address names a host.
+func defaultArtifactURL(httpAddr string, useTLS bool) string {
+	host, port, err := net.SplitHostPort(httpAddr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			host = "localhost"
+		}
+	}
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
@@ -2,81 +2,172 @@ package dewy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
-	"syscall"
 	"time"
 
 	"github.com/linyows/dewy/cache"
-	starter "github.com/linyows/server-starter"
+	"github.com/linyows/dewy/registry"
 )
 
-// deploy extracts the cached artifact into a new release directory and
-// atomically swaps the "current" symlink to point at it. Before- and after-
-// deploy hooks are wrapped around the extract step.
-func (d *Dewy) deploy(key string) (err error) {
-	ctx := context.Background()
-
-	beforeResult, beforeErr := d.execHook(d.config.BeforeDeployHook)
-	if beforeResult != nil {
-		d.notifier.SendHookResult(ctx, "Before Deploy", beforeResult)
-	}
-	if beforeErr != nil {
-		d.logger.Error("Before deploy hook failure", slog.String("error", beforeErr.Error()))
-		// Continue with deploy even if before hook fails
+// deploy extracts the cached artifact into a new release directory, renders
+// configuration templates into it, and atomically swaps the "current"
+// symlink to point at it. The before-deploy, post-extract and after-deploy
+// hooks run around those steps: a blocking failure before the swap discards
+// the new release, and one after the swap rolls back to the previous one.
+func (d *Dewy) deploy(ctx context.Context, res *registry.CurrentResponse, key string, hc hookContext) error {
+	if err := d.runHook(ctx, hookBeforeDeploy, hc); err != nil {
+		return err
 	}
 
-	defer func() {
-		if err != nil {
-			return
-		}
-		// When deploy is success, run after deploy hook
-		afterResult, afterErr := d.execHook(d.config.AfterDeployHook)
-		if afterResult != nil {
-			d.notifier.SendHookResult(ctx, "After Deploy", afterResult)
-		}
-		if afterErr != nil {
-			d.logger.Error("After deploy hook failure", slog.String("error", afterErr.Error()))
-		}
-	}()
 	p := filepath.Join(d.cache.GetDir(), key)
-	linkFrom, err := d.preserve(p)
+	linkFrom, err := d.preserve(p, hc.ReleaseDir)
 	if err != nil {
 		d.logger.Error("Preserve failure", slog.String("error", err.Error()))
-		return err
+		return failedIn(phaseExtract, err)
 	}
 	d.logger.Info("Extract archive", slog.String("path", linkFrom))
 
-	d.notifier.OnDeploy(linkFrom)
+	// Render before the symlink swap so a broken template leaves the
+	// previous release serving; the half-built release is discarded.
+	if err := d.renderTemplates(res, linkFrom); err != nil {
+		d.logger.Error("Render templates failure", slog.String("error", err.Error()))
+		d.discardRelease(linkFrom)
+		return failedIn(phaseRender, fmt.Errorf("failed to render templates: %w", err))
+	}
+
+	if err := d.runHook(ctx, hookPostExtract, hc); err != nil {
+		d.discardRelease(linkFrom)
+		return err
+	}
+
+	d.publish(ctx, Event{Type: EventExtracted, Tag: res.Tag, Path: linkFrom})
+
+	if err := d.swapCurrent(linkFrom); err != nil {
+		return failedIn(phasePromote, err)
+	}
+
+	if err := d.runHook(ctx, hookAfterDeploy, hc); err != nil {
+		return d.rollback(ctx, hc, err, false)
+	}
+
+	return nil
+}
 
+// swapCurrent atomically points the "current" symlink at target.
+func (d *Dewy) swapCurrent(target string) error {
 	linkTo := filepath.Join(d.root, symlinkDir)
 
 	// Atomic symlink replacement: create temp symlink, then rename
 	tmpLink := linkTo + ".tmp"
 	os.Remove(tmpLink) // Ensure no stale temp link exists
-	if err := os.Symlink(linkFrom, tmpLink); err != nil {
+	if err := os.Symlink(target, tmpLink); err != nil {
 		return err
 	}
 
 	d.logger.Info("Create symlink",
-		slog.String("from", linkFrom),
+		slog.String("from", target),
 		slog.String("to", linkTo))
 	if err := os.Rename(tmpLink, linkTo); err != nil {
 		os.Remove(tmpLink) // Cleanup on failure
 		return err
 	}
-
 	return nil
 }
 
-// preserve materializes the cached artifact into a timestamp-named release
-// directory under d.root and returns its path.
-func (d *Dewy) preserve(p string) (string, error) {
-	dst := filepath.Join(d.root, releasesDir, time.Now().UTC().Format(releaseDir))
+// currentRelease returns the release directory "current" points at, or ""
+// before the first deploy.
+func (d *Dewy) currentRelease() string {
+	target, err := os.Readlink(filepath.Join(d.root, symlinkDir))
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+// discardRelease removes a release directory that never went live. It is a
+// no-op when dir is what current already points at (same-second redeploy
+// into the same timestamped directory).
+func (d *Dewy) discardRelease(dir string) {
+	if d.currentRelease() == dir {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		d.logger.Warn("Discard release failure", slog.String("path", dir), slog.String("error", err.Error()))
+	}
+}
+
+// rollback points current back at the release it served before this deploy,
+// restarts the server onto it when restart is set and a server is running,
+// and runs the on-rollback hook. It returns cause wrapped so callers can
+// `return d.rollback(...)`. On a first deploy there is nothing to return to
+// and cause is returned as is.
+func (d *Dewy) rollback(ctx context.Context, hc hookContext, cause error, restart bool) error {
+	if hc.PreviousReleaseDir == "" {
+		return cause
+	}
+	if err := d.swapCurrent(hc.PreviousReleaseDir); err != nil {
+		d.logger.Error("Rollback failure", slog.String("error", err.Error()))
+		return fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	d.Lock()
+	d.cVer = hc.PreviousTag
+	running := d.isServerRunning
+	d.Unlock()
+
+	if restart && d.config.Command == SERVER && running {
+		if err := d.restartServer(); err != nil {
+			d.logger.Error("Rollback restart failure", slog.String("error", err.Error()))
+		} else {
+			d.publish(ctx, Event{Type: EventRestarted, Tag: hc.PreviousTag, Reason: "rollback"})
+		}
+	}
+
+	d.publish(ctx, Event{Type: EventRolledBack, Tag: hc.Tag, PreviousTag: hc.PreviousTag, Path: hc.PreviousReleaseDir, Err: cause})
+
+	hc.Error = cause.Error()
+	_ = d.runHook(ctx, hookOnRollback, hc)
+
+	return fmt.Errorf("rolled back to %s: %w", hc.PreviousTag, cause)
+}
+
+// newHookContext builds the hook context for a server/assets deploy of res.
+// It snapshots the current tag and release before anything moves, so they
+// stay valid as rollback targets for the rest of the deploy.
+func (d *Dewy) newHookContext(res *registry.CurrentResponse) hookContext {
+	return hookContext{
+		Tag:                res.Tag,
+		PreviousTag:        d.currentVersion(),
+		ReleaseDir:         d.newReleaseDir(),
+		PreviousReleaseDir: d.currentRelease(),
+		ArtifactURL:        res.ArtifactURL,
+		Slot:               res.Slot,
+		Started:            time.Now(),
+	}
+}
+
+// newReleaseDir returns the timestamp-named release directory a deploy
+// starting now extracts into.
+func (d *Dewy) newReleaseDir() string {
+	return filepath.Join(d.root, releasesDir, time.Now().UTC().Format(releaseDir))
+}
+
+// currentVersion returns the tag of the currently deployed release.
+func (d *Dewy) currentVersion() string {
+	d.RLock()
+	defer d.RUnlock()
+	return d.cVer
+}
+
+// preserve materializes the cached artifact into the release directory dst
+// and returns its path.
+func (d *Dewy) preserve(p, dst string) (string, error) {
 	if err := os.MkdirAll(dst, 0755); err != nil {
 		return "", err
 	}
@@ -88,51 +179,94 @@ func (d *Dewy) preserve(p string) (string, error) {
 	return dst, nil
 }
 
-// restartServer signals the running dewy process to restart its child
-// (server-starter handles the SIGHUP-driven graceful swap).
+// restartServer swaps the running server for a new worker: the supervisor
+// starts the next generation on the same listeners and signals the old one
+// once the new one is up. The managed environment is reloaded first so the
+// new worker gets it.
 func (d *Dewy) restartServer() error {
 	d.Lock()
-	defer d.Unlock()
+	if d.supervisor == nil {
+		d.Unlock()
+		return errNoServer
+	}
+
+	env, err := d.workerEnv()
+	if err != nil {
+		d.Unlock()
+		return fmt.Errorf("failed to load environment: %w", err)
+	}
+
+	d.openServerLog()
+
+	stdout, stderr := d.serverOutput()
+	s, version := d.supervisor, d.cVer
+	d.Unlock()
 
-	pid := os.Getpid()
-	p, _ := os.FindProcess(pid)
-	err := p.Signal(syscall.SIGHUP)
+	// The supervisor waits out the startup interval. The lock is not held
+	// meanwhile: status and the old worker's exit callback both take it.
+	gen, err := s.restart(workerSpec{Env: env, Stdout: stdout, Stderr: stderr})
 	if err != nil {
 		return err
 	}
-	d.logger.Info("Send SIGHUP for server restart", slog.String("version", d.cVer), slog.Int("pid", pid))
+	d.logger.Info("Restarted server", slog.String("version", version), slog.Int("generation", gen))
 
 	return nil
 }
 
-// startServer launches the managed application via server-starter and marks
-// d.isServerRunning. Errors during NewStarter are returned synchronously;
-// errors from the actual Run() goroutine flip isServerRunning back to false.
+// startServer loads the managed environment, starts a worker of the managed
+// application and marks d.isServerRunning. The listeners are bound on the
+// first start and kept across workers. A worker that exits, during startup
+// or later, is recorded as a crash, which flips isServerRunning back to
+// false and holds off the next start (see crashState).
 func (d *Dewy) startServer() error {
 	d.Lock()
-	defer d.Unlock()
 
 	d.logger.Info("Start server", slog.String("version", d.cVer))
 
-	// Try to create starter first (synchronous validation)
-	s, err := starter.NewStarter(d.config.Starter)
+	env, err := d.workerEnv()
 	if err != nil {
-		d.logger.Error("Starter failure", slog.String("error", err.Error()))
-		return err
+		d.Unlock()
+		d.logger.Error("Environment failure", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to load environment: %w", err)
 	}
 
-	// Start server in background
-	go func() {
-		err := s.Run()
+	if d.supervisor == nil {
+		s, err := newSupervisor(d.config.Starter, d.logger.Slog())
 		if err != nil {
-			d.logger.Error("Server run failure", slog.String("error", err.Error()))
-			d.Lock()
-			d.isServerRunning = false
 			d.Unlock()
+			d.logger.Error("Starter failure", slog.String("error", err.Error()))
+			return err
+		}
+		s.onExit = func(_ int, err error) {
+			d.serverCrashed(context.Background(), err)
 		}
-	}()
+		d.supervisor = s
+	}
+
+	d.openServerLog()
 
-	d.isServerRunning = true
+	stdout, stderr := d.serverOutput()
+	s := d.supervisor
+	d.Unlock()
+
+	// As in restartServer, the startup interval is waited out unlocked.
+	if err := s.start(workerSpec{Env: env, Stdout: stdout, Stderr: stderr}); err != nil {
+		d.logger.Error("Server run failure", slog.String("error", err.Error()))
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			d.serverCrashed(context.Background(), err)
+		}
+		return err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	// A worker that exited right after startup was already recorded as a
+	// crash and must not be marked running.
+	if s.currentGeneration() != 0 {
+		d.isServerRunning = true
+		d.serverStarted()
+	}
 	return nil
 }
 
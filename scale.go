@@ -0,0 +1,189 @@
+package dewy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/linyows/dewy/container"
+)
+
+const (
+	// scaleStateFile under the dewy root records a replica count set through
+	// PUT /api/replicas so that it survives a dewy restart.
+	scaleStateFile = "scale.json"
+
+	// maxReplicas bounds PUT /api/replicas against typos like 1000.
+	maxReplicas = 64
+)
+
+// scaleState is the content of scaleStateFile.
+type scaleState struct {
+	Replicas int `json:"replicas"`
+	// FlagReplicas is the --replicas value the count overrides. Restarting
+	// dewy with a different --replicas means the operator changed their mind,
+	// so the flag wins and the state is dropped.
+	FlagReplicas int `json:"flag_replicas"`
+}
+
+// scaleResult is the outcome of PUT /api/replicas.
+type scaleResult struct {
+	Outcome  string `json:"outcome"` // scaled, saved (nothing running yet) or failed
+	Replicas int    `json:"replicas"`
+	Previous int    `json:"previous"`
+	Tag      string `json:"tag,omitempty"`
+	Started  int    `json:"started"`
+	Removed  int    `json:"removed"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (d *Dewy) scaleStatePath() string {
+	return filepath.Join(d.root, scaleStateFile)
+}
+
+// loadScale applies a replica count persisted by an earlier scale, unless
+// --replicas changed since.
+func (d *Dewy) loadScale() {
+	c := d.config.Container
+	d.replicasFlag = c.Replicas
+
+	b, err := os.ReadFile(d.scaleStatePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			d.logger.Warn("Failed to read scale state", slog.String("error", err.Error()))
+		}
+		return
+	}
+	var st scaleState
+	if err := json.Unmarshal(b, &st); err != nil {
+		d.logger.Warn("Failed to parse scale state", slog.String("error", err.Error()))
+		return
+	}
+	if st.FlagReplicas != c.Replicas {
+		d.logger.Info("Replicas flag changed, dropping scale state",
+			slog.Int("flag", c.Replicas),
+			slog.Int("scaled", st.Replicas))
+		_ = os.Remove(d.scaleStatePath())
+		return
+	}
+	if st.Replicas > 0 {
+		d.logger.Info("Restored replica count", slog.Int("replicas", st.Replicas))
+		c.Replicas = st.Replicas
+	}
+}
+
+// saveScale persists n as the replica count to restore on restart.
+func (d *Dewy) saveScale(n int) error {
+	b, err := json.Marshal(scaleState{Replicas: n, FlagReplicas: d.replicasFlag})
+	if err != nil {
+		return err
+	}
+	tmp := d.scaleStatePath() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("failed to save scale state: %w", err)
+	}
+	if err := os.Rename(tmp, d.scaleStatePath()); err != nil {
+		return fmt.Errorf("failed to save scale state: %w", err)
+	}
+	return nil
+}
+
+// scaleContainers sets the replica count to n and, when containers are
+// running, starts or removes replicas to match without restarting the
+// others. Before the first deploy the count is only saved. If the running
+// replicas cannot be brought to n, the previous count is restored.
+func (d *Dewy) scaleContainers(ctx context.Context, n int) scaleResult {
+	d.tickMu.Lock()
+	defer d.tickMu.Unlock()
+
+	c := d.config.Container
+	res := scaleResult{Replicas: n, Previous: c.Replicas}
+	if res.Previous <= 0 {
+		res.Previous = 1
+	}
+	fail := func(err error) scaleResult {
+		res.Outcome = outcomeFailed
+		res.Error = err.Error()
+		return res
+	}
+
+	if err := d.saveScale(n); err != nil {
+		return fail(err)
+	}
+	d.Lock()
+	c.Replicas = n
+	d.Unlock()
+
+	rt := d.containerRuntime
+	if rt == nil {
+		res.Outcome = "saved"
+		return res
+	}
+	rel, err := d.runningContainerRelease(ctx, rt)
+	if err == nil && rel == nil {
+		res.Outcome = "saved"
+		return res
+	}
+	if err == nil {
+		res.Tag = rel.Tag
+		var opts container.RollingDeployOptions
+		if opts, err = d.rolloutOptions(ctx, rel, rt); err == nil {
+			var report *container.DeployReport
+			if report, err = rt.Scale(ctx, opts, (*proxyBackendUpdater)(d)); err == nil {
+				res.Started, res.Removed = len(report.Results), report.RemovedCount
+				d.emitReplicaChanges(ctx, res.Tag, report)
+			}
+		}
+	}
+	if err != nil {
+		d.Lock()
+		c.Replicas = res.Previous
+		d.Unlock()
+		if serr := d.saveScale(res.Previous); serr != nil {
+			err = errors.Join(err, serr)
+		}
+		return fail(err)
+	}
+	d.followContainerLogs(ctx)
+
+	res.Outcome = "scaled"
+	d.publish(ctx, Event{Type: EventScaled, Tag: res.Tag, Replicas: n, PreviousReplicas: res.Previous})
+	return res
+}
+
+// handlePutReplicas handles PUT /api/replicas endpoint. The body is
+// {"count": N}.
+func (d *Dewy) handlePutReplicas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.config.Command != CONTAINER || d.config.Container == nil {
+		http.Error(w, "Scaling is only supported for the container command", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Count < 1 || req.Count > maxReplicas {
+		http.Error(w, fmt.Sprintf("Replicas must be between 1 and %d", maxReplicas), http.StatusBadRequest)
+		return
+	}
+
+	d.logger.Info("Scale requested via admin API", slog.Int("replicas", req.Count))
+	res := d.scaleContainers(context.WithoutCancel(r.Context()), req.Count)
+	if res.Error != "" {
+		d.logger.Error("Scale failure", slog.String("error", res.Error))
+	}
+	d.writeJSON(w, res)
+}
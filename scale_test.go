@@ -0,0 +1,94 @@
+package dewy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func putReplicas(t *testing.T, d *Dewy, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	d.handlePutReplicas(w, httptest.NewRequest(http.MethodPut, "/api/replicas", strings.NewReader(body)))
+	return w
+}
+
+func TestHandlePutReplicas_SavedBeforeFirstDeploy(t *testing.T) {
+	d := newAdminTestDewy(t)
+	d.root = t.TempDir()
+	d.config.Container.Replicas = 2
+	d.replicasFlag = 2
+
+	w := putReplicas(t, d, `{"count": 4}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var res scaleResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Outcome != "saved" || res.Previous != 2 || res.Replicas != 4 {
+		t.Errorf("result = %+v, want saved 2 -> 4", res)
+	}
+	if d.config.Container.Replicas != 4 {
+		t.Errorf("config replicas = %d, want 4", d.config.Container.Replicas)
+	}
+
+	// A restart with the same --replicas picks the count back up.
+	d.config.Container.Replicas = 2
+	d.loadScale()
+	if d.config.Container.Replicas != 4 {
+		t.Errorf("restored replicas = %d, want 4", d.config.Container.Replicas)
+	}
+}
+
+func TestLoadScale_FlagChangedWins(t *testing.T) {
+	d := newAdminTestDewy(t)
+	d.root = t.TempDir()
+	d.replicasFlag = 2
+	if err := d.saveScale(5); err != nil {
+		t.Fatal(err)
+	}
+
+	d.config.Container.Replicas = 3
+	d.loadScale()
+	if d.config.Container.Replicas != 3 {
+		t.Errorf("replicas = %d, want the new flag value 3", d.config.Container.Replicas)
+	}
+	if _, err := os.Stat(d.scaleStatePath()); !os.IsNotExist(err) {
+		t.Error("stale scale state was not removed")
+	}
+}
+
+func TestHandlePutReplicas_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		assets bool
+		want   int
+	}{
+		{name: "wrong method", method: http.MethodPost, body: `{"count": 2}`, want: http.StatusMethodNotAllowed},
+		{name: "zero", method: http.MethodPut, body: `{"count": 0}`, want: http.StatusBadRequest},
+		{name: "too many", method: http.MethodPut, body: `{"count": 1000}`, want: http.StatusBadRequest},
+		{name: "malformed", method: http.MethodPut, body: `replicas=2`, want: http.StatusBadRequest},
+		{name: "not container", method: http.MethodPut, body: `{"count": 2}`, assets: true, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newAdminTestDewy(t)
+			d.root = t.TempDir()
+			if tt.assets {
+				d.config.Command = ASSETS
+			}
+			w := httptest.NewRecorder()
+			d.handlePutReplicas(w, httptest.NewRequest(tt.method, "/api/replicas", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
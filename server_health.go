@@ -0,0 +1,113 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linyows/dewy/container"
+)
+
+// HealthCheckConfig describes the readiness probe run after the managed
+// server is started or restarted for a deploy (server command only).
+type HealthCheckConfig struct {
+	Path    string        // HTTP path to GET; empty probes with a TCP connect
+	Port    string        // Port or host:port to probe (default: first --port)
+	Timeout time.Duration // Time the new worker has to become healthy
+}
+
+// healthTarget resolves the host and port the probe dials.
+func (d *Dewy) healthTarget() (string, int, error) {
+	hc := d.config.HealthCheck
+	target := hc.Port
+	if target == "" {
+		ports := d.config.Starter.Ports()
+		if len(ports) == 0 {
+			return "", 0, fmt.Errorf("health check needs a --port to probe")
+		}
+		target = ports[0]
+	}
+	host := "localhost"
+	if strings.Contains(target, ":") {
+		h, p, err := net.SplitHostPort(target)
+		if err != nil {
+			return "", 0, err
+		}
+		if h != "" {
+			host = h
+		}
+		target = p
+	}
+	port, err := strconv.Atoi(target)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid health check port %q", target)
+	}
+	return host, port, nil
+}
+
+// verifyServerHealth probes the worker started last until it answers or the
+// health timeout expires. It is a no-op when no health check is configured.
+//
+// Old and new workers accept on the same listeners, so the probe only starts
+// once every replaced worker has exited: from then on only the new
+// generation can answer. The new worker must still be the running one when
+// the probe passes. The timeout covers the old workers' drain as well as the
+// new worker's boot.
+func (d *Dewy) verifyServerHealth(ctx context.Context) error {
+	hc := d.config.HealthCheck
+	if hc == nil {
+		return nil
+	}
+	host, port, err := d.healthTarget()
+	if err != nil {
+		return err
+	}
+
+	timeout := hc.Timeout
+	if timeout <= 0 {
+		timeout = defaultServerHealthTimeout
+	}
+	retries := max(int(timeout/defaultHealthCheckDelay), 1)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d.RLock()
+	s := d.supervisor
+	d.RUnlock()
+
+	checker := container.NewHealthChecker(d.logger.Slog(), defaultHealthCheckTimeout, retries)
+	if d.telemetryOn() {
+		d.telemetry.Metrics().HealthChecksTotal.Add(ctx, 1)
+	}
+	gen := 0
+	if s != nil {
+		if gen = s.currentGeneration(); gen == 0 {
+			err = errors.New("no worker is running")
+		} else {
+			err = s.drained(ctx)
+		}
+	}
+	if err == nil {
+		if hc.Path != "" {
+			err = checker.CheckHTTP(ctx, fmt.Sprintf("http://%s%s", net.JoinHostPort(host, strconv.Itoa(port)), hc.Path))
+		} else {
+			err = checker.CheckTCP(ctx, host, port)
+		}
+	}
+	if err == nil && s != nil && !s.running(gen) {
+		err = fmt.Errorf("worker generation %d exited", gen)
+	}
+	if err != nil {
+		if d.telemetryOn() {
+			d.telemetry.Metrics().HealthCheckFailures.Add(context.Background(), 1)
+		}
+		d.logger.Error("Server health check failure", slog.String("error", err.Error()))
+		return fmt.Errorf("server did not become healthy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+)
+
+func TestVerifyServerHealth_Disabled(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	if err := d.verifyServerHealth(context.Background()); err != nil {
+		t.Errorf("err = %v, want nil without a health check", err)
+	}
+}
+
+func TestVerifyServerHealth_HTTP(t *testing.T) {
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	d := newPhaseTestDewy(t)
+	d.config.HealthCheck = &HealthCheckConfig{
+		Path:    "/healthz",
+		Port:    strings.TrimPrefix(ts.URL, "http://"),
+		Timeout: 2 * time.Second,
+	}
+	if err := d.verifyServerHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/healthz" {
+		t.Errorf("probed %q, want /healthz", path)
+	}
+}
+
+func TestVerifyServerHealth_TCPFailure(t *testing.T) {
+	// Grab a free port and close it so nothing is listening there.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	d := newPhaseTestDewy(t)
+	d.config.HealthCheck = &HealthCheckConfig{Port: addr, Timeout: time.Second}
+	err = d.verifyServerHealth(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "did not become healthy") {
+		t.Errorf("err = %v, want health failure", err)
+	}
+}
+
+func TestVerifyServerHealth_NewGeneration(t *testing.T) {
+	// The probe target always answers, like an old worker still serving on
+	// the shared port.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	d := newPhaseTestDewy(t)
+	d.config.HealthCheck = &HealthCheckConfig{
+		Path:    "/healthz",
+		Port:    strings.TrimPrefix(ts.URL, "http://"),
+		Timeout: time.Second,
+	}
+
+	t.Run("old worker still running", func(t *testing.T) {
+		s, _, _ := newTestSupervisor(t, `trap '' TERM; exec sleep 6`)
+		defer s.stop(time.Second)
+		d.supervisor = s
+		if err := s.start(workerSpec{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.restart(workerSpec{}); err != nil {
+			t.Fatal(err)
+		}
+		err := d.verifyServerHealth(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "still running") {
+			t.Errorf("err = %v, want failure while the old worker may answer", err)
+		}
+	})
+
+	t.Run("new worker exited", func(t *testing.T) {
+		s, rec, _ := newTestSupervisor(t, `sleep 1.2; exit 1`)
+		defer s.stop(time.Second)
+		d.supervisor = s
+		if err := s.start(workerSpec{}); err != nil {
+			t.Fatal(err)
+		}
+		<-rec.ch
+		err := d.verifyServerHealth(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "no worker is running") {
+			t.Errorf("err = %v, want failure without a running worker", err)
+		}
+	})
+}
+
+func TestHealthTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		ports    []string
+		port     string
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{name: "first server port", ports: []string{"8080", "8081"}, wantHost: "localhost", wantPort: 8080},
+		{name: "explicit host and port", port: "127.0.0.1:9000", wantHost: "127.0.0.1", wantPort: 9000},
+		{name: "no port", wantErr: true},
+		{name: "invalid port", port: "http", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newPhaseTestDewy(t)
+			d.config.Starter = &StarterConfig{ports: tt.ports}
+			d.config.HealthCheck = &HealthCheckConfig{Port: tt.port}
+			host, port, err := d.healthTarget()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (host != tt.wantHost || port != tt.wantPort) {
+				t.Errorf("target = %s:%d, want %s:%d", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestReportDeploymentFailure(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	var got *registry.ReportRequest
+	d.registry = &mockRegistry{
+		reportFunc: func(ctx context.Context, req *registry.ReportRequest) error {
+			got = req
+			return nil
+		},
+	}
+	cause := errors.New("server did not become healthy")
+	hc := hookContext{Tag: "v1", PreviousTag: "v0", Started: time.Now().Add(-time.Second)}
+	d.reportDeploymentFailure(context.Background(), &registry.CurrentResponse{ID: "id-1", Tag: "v1"}, hc, failedIn(phaseHealthCheck, cause))
+	if got == nil || got.Tag != "v1" || !errors.Is(got.Err, cause) {
+		t.Errorf("ReportRequest = %+v, want v1 with the health error", got)
+	}
+	if got.Phase != "health-check" || got.PreviousTag != "v0" || got.Duration < time.Second {
+		t.Errorf("ReportRequest = %+v, want the health-check phase after v0 with its duration", got)
+	}
+	if got.Hostname == "" || got.InstanceID != instanceID {
+		t.Errorf("ReportRequest = %+v, want hostname and instance id", got)
+	}
+}
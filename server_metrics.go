@@ -21,9 +21,10 @@ func (d *Dewy) commandAttr() otelmetric.MeasurementOption {
 }
 
 // recordDeployment records the outcome of one deploy attempt. A non-nil err
-// counts an error; success counts the deployment and its duration. Shared by
-// the server/assets path (Run) and the container path (applyContainerDeployment)
-// so every mode reports consistently.
+// counts an error; success counts the deployment and its duration. The
+// telemetry subscriber calls it for server and assets deploys, timing the
+// whole deploy; the container path calls it from applyContainerDeployment,
+// timing the rolling deployment only.
 func (d *Dewy) recordDeployment(ctx context.Context, dur time.Duration, deployErr error) {
 	if !d.telemetryOn() {
 		return
@@ -46,3 +47,13 @@ func (d *Dewy) recordServerRestart(ctx context.Context, reason string) {
 	d.telemetry.Metrics().ServerRestarts.Add(ctx, 1,
 		otelmetric.WithAttributes(attribute.String("reason", reason)))
 }
+
+// recordServerCrash counts a managed-server crash with its exit code (-1 when
+// the failure carried none).
+func (d *Dewy) recordServerCrash(ctx context.Context, code int) {
+	if !d.telemetryOn() {
+		return
+	}
+	d.telemetry.Metrics().ServerCrashes.Add(ctx, 1,
+		otelmetric.WithAttributes(attribute.Int("exit_code", code)))
+}
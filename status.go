@@ -0,0 +1,193 @@
+package dewy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pollStatus is the outcome of the latest deploy tick, kept for /api/status.
+type pollStatus struct {
+	tickResult
+	At time.Time `json:"at"`
+}
+
+// statusReport is the body of GET /api/status.
+type statusReport struct {
+	Name           string            `json:"name"`
+	Command        string            `json:"command"`
+	Registry       string            `json:"registry"`
+	Slot           string            `json:"slot,omitempty"`
+	CurrentVersion string            `json:"current_version"`
+	DeployedAt     *time.Time        `json:"deployed_at,omitempty"`
+	PendingVersion string            `json:"pending_version,omitempty"`
+	LastPoll       *pollStatus       `json:"last_poll,omitempty"`
+	IsRunning      bool              `json:"is_running"`
+	ProxyBackends  int               `json:"proxy_backends"`
+	Backends       []backendStatus   `json:"backends,omitempty"`
+	Replicas       *replicaStatus    `json:"replicas,omitempty"`
+	Containers     []containerStatus `json:"containers,omitempty"`
+	EnvKeys        []string          `json:"env_keys"`
+	Crashes        map[string]any    `json:"crashes,omitempty"`
+}
+
+// backendStatus lists the upstreams a proxy port currently routes to. Only
+// replicas that passed their health check are registered with a proxy.
+type backendStatus struct {
+	ProxyPort int      `json:"proxy_port"`
+	Upstreams []string `json:"upstreams"`
+}
+
+// replicaStatus compares the desired replica count with what is running.
+type replicaStatus struct {
+	Desired int `json:"desired"`
+	Running int `json:"running"`
+}
+
+// containerStatus is one managed container as shown by dewy status -o wide.
+type containerStatus struct {
+	Name      string    `json:"name"`
+	Replica   string    `json:"replica,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	State     string    `json:"state"`
+	Restarts  int       `json:"restarts"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// redactRegistryURL returns the registry URL safe to show in dewy status:
+// userinfo is dropped and query parameters with secret-looking names, such as
+// token, password or a presigned URL's signature, have their values replaced
+// with redactedValue. A URL that does not parse is redacted whole.
+func redactRegistryURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, p := range params {
+			k, _, _ := strings.Cut(p, "=")
+			if name, err := url.QueryUnescape(k); err != nil || isSecretQueryParam(name) {
+				params[i] = k + "=" + redactedValue
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
+
+// isSecretQueryParam reports whether the value of the URL query parameter
+// name must not be shown. On top of the secret env key markers, it catches
+// the signatures of presigned S3 URLs and Azure SAS tokens.
+func isSecretQueryParam(name string) bool {
+	return isSecretEnvKey(name) || strings.EqualFold(name, "sig") ||
+		strings.Contains(strings.ToUpper(name), "SIGNATURE")
+}
+
+// recordPoll keeps the outcome of a deploy tick at for /api/status.
+func (d *Dewy) recordPoll(r tickResult, at time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	d.lastPoll = &pollStatus{tickResult: r, At: at}
+}
+
+// proxyBackends lists the upstreams of every TCP proxy, by proxy port.
+func (d *Dewy) proxyBackends() []backendStatus {
+	d.proxyMutex.RLock()
+	defer d.proxyMutex.RUnlock()
+
+	var list []backendStatus
+	for port, p := range d.tcpProxies {
+		b := backendStatus{ProxyPort: port, Upstreams: []string{}}
+		p.mu.RLock()
+		for _, be := range p.backends {
+			b.Upstreams = append(b.Upstreams, fmt.Sprintf("%s:%d", be.host, be.port))
+		}
+		p.mu.RUnlock()
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ProxyPort < list[j].ProxyPort })
+	return list
+}
+
+// status builds the /api/status report. Containers are inspected through the
+// runtime, so this can take as long as a docker inspect.
+func (d *Dewy) status(ctx context.Context) statusReport {
+	d.RLock()
+	s := statusReport{
+		Name:           d.appName(),
+		Command:        d.config.Command.String(),
+		Registry:       redactRegistryURL(d.config.Registry),
+		Slot:           d.config.Slot,
+		CurrentVersion: d.cVer,
+		IsRunning:      d.isServerRunning,
+		EnvKeys:        d.envKeys,
+		LastPoll:       d.lastPoll,
+	}
+	if !d.deployedAt.IsZero() {
+		at := d.deployedAt
+		s.DeployedAt = &at
+	}
+	if d.config.Command == SERVER {
+		s.Crashes = d.crashLoopStatus()
+	}
+	if d.config.Command == CONTAINER && d.config.Container != nil {
+		s.Replicas = &replicaStatus{Desired: max(d.config.Container.Replicas, 1)}
+	}
+	d.RUnlock()
+
+	if s.LastPoll != nil && s.LastPoll.Tag != "" && s.LastPoll.Tag != s.CurrentVersion {
+		s.PendingVersion = s.LastPoll.Tag
+	}
+
+	switch d.config.Command {
+	case CONTAINER:
+		s.ProxyBackends = d.totalProxyBackends()
+		s.Backends = d.proxyBackends()
+		if rt := d.containerRuntime; rt != nil && s.Replicas != nil {
+			statuses, err := rt.InspectManaged(ctx, d.appName())
+			if err != nil {
+				d.logger.Warn("Failed to inspect containers for status",
+					slog.String("error", err.Error()))
+			}
+			for _, st := range statuses {
+				if st.State == "running" {
+					s.Replicas.Running++
+				}
+				s.Containers = append(s.Containers, containerStatus{
+					Name: st.Name, Replica: st.Replica, Version: st.Version,
+					State: st.State, Restarts: st.Restarts, StartedAt: st.StartedAt,
+				})
+				// Before the first tick of this process, the running
+				// containers tell when the current version went out.
+				if s.DeployedAt == nil && st.State == "running" && st.Version == s.CurrentVersion {
+					at := st.StartedAt
+					s.DeployedAt = &at
+				}
+			}
+		}
+	default:
+		if s.DeployedAt == nil {
+			if at, err := time.Parse(releaseDir, filepath.Base(d.currentRelease())); err == nil {
+				s.DeployedAt = &at
+			}
+		}
+	}
+	return s
+}
+
+// handleGetStatus handles GET /api/status endpoint.
+func (d *Dewy) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d.writeJSON(w, d.status(r.Context()))
+}
@@ -0,0 +1,383 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	starter "github.com/linyows/server-starter"
+)
+
+// supervisor runs the workers of the managed server in place of
+// server-starter's Run while keeping its contract with the application: the
+// listening sockets are bound once and handed to every worker as inherited
+// descriptors named in SERVER_STARTER_PORT, SERVER_STARTER_GENERATION
+// numbers the workers, and the pid and status files keep their formats.
+//
+// Unlike server-starter, dewy spawns each worker itself, so it chooses the
+// worker's environment and output and learns about every exit. A current
+// worker that exits on its own is not respawned here: onExit reports it and
+// dewy decides when to start the next one.
+type supervisor struct {
+	cfg    starter.Config
+	logger *slog.Logger
+	// onExit is called, without any supervisor lock held, when the current
+	// worker exits on its own rather than by being replaced or stopped.
+	// err is an *exec.ExitError, even for a clean exit.
+	onExit func(gen int, err error)
+
+	mu         sync.Mutex
+	bound      bool
+	listeners  []net.Listener
+	files      []*os.File // dups of listeners, inherited by workers
+	ports      []string   // SERVER_STARTER_PORT entries, "<spec>=<fd>"
+	pidFile    string
+	generation int
+	current    *worker
+	old        map[*worker]struct{} // replaced workers not exited yet
+}
+
+// workerSpec is what differs between workers of one supervisor.
+type workerSpec struct {
+	Env    []string  // added to dewy's environment; later entries win
+	Stdout io.Writer // nil means dewy's stdout
+	Stderr io.Writer // nil means dewy's stderr
+}
+
+// worker is one spawned generation of the managed server.
+type worker struct {
+	gen  int
+	cmd  *exec.Cmd
+	done chan struct{} // closed once the process exited
+	err  error         // *exec.ExitError, set before done is closed
+}
+
+// newSupervisor validates cfg the way starter.NewStarter does.
+func newSupervisor(cfg starter.Config, logger *slog.Logger) (*supervisor, error) {
+	if cfg == nil {
+		return nil, errors.New("server config must be non-nil")
+	}
+	if cfg.Command() == "" {
+		return nil, errors.New("argument Command must be specified")
+	}
+	if _, err := exec.LookPath(cfg.Command()); err != nil {
+		return nil, err
+	}
+	return &supervisor{cfg: cfg, logger: logger, old: make(map[*worker]struct{})}, nil
+}
+
+// listen writes the pid file and binds the listeners, once. Listeners stay
+// open across workers so that no connection is refused during a swap.
+func (s *supervisor) listen() error {
+	if s.bound {
+		return nil
+	}
+
+	if p := s.cfg.PidFile(); p != "" {
+		f, err := os.OpenFile(p, os.O_EXCL|os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(f, "%d", os.Getpid())
+		f.Close()
+		s.pidFile = p
+	}
+
+	type bound struct {
+		l    net.Listener
+		spec string
+	}
+	var all []bound
+	fail := func(err error) error {
+		for _, b := range all {
+			b.l.Close()
+		}
+		return err
+	}
+	for _, addr := range s.cfg.Ports() {
+		host, port, ok := strings.Cut(addr, ":")
+		if !ok {
+			host, port = "", addr
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			return fail(fmt.Errorf("invalid port %q: %w", addr, err))
+		}
+		l, err := net.Listen("tcp4", net.JoinHostPort(host, port))
+		if err != nil {
+			return fail(err)
+		}
+		spec := port
+		if host != "" {
+			spec = host + ":" + port
+		}
+		all = append(all, bound{l, spec})
+	}
+	for _, p := range s.cfg.Paths() {
+		if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(p)
+		}
+		l, err := net.Listen("unix", p)
+		if err != nil {
+			return fail(err)
+		}
+		all = append(all, bound{l, p})
+	}
+
+	for i, b := range all {
+		var f *os.File
+		var err error
+		switch l := b.l.(type) {
+		case *net.TCPListener:
+			f, err = l.File()
+		case *net.UnixListener:
+			f, err = l.File()
+		}
+		if err != nil {
+			return fail(err)
+		}
+		s.listeners = append(s.listeners, b.l)
+		s.files = append(s.files, f)
+		// ExtraFiles start at descriptor 3 in the worker.
+		s.ports = append(s.ports, fmt.Sprintf("%s=%d", b.spec, i+3))
+	}
+	s.bound = true
+	return nil
+}
+
+// start binds the listeners if needed and spawns a worker when none runs.
+func (s *supervisor) start(spec workerSpec) error {
+	s.mu.Lock()
+	err := s.listen()
+	running := s.current != nil
+	s.mu.Unlock()
+	if err != nil || running {
+		return err
+	}
+
+	w, err := s.spawn(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.current = w
+	s.writeStatus()
+	s.mu.Unlock()
+	return nil
+}
+
+// restart spawns a new generation and, once it is up, sends the old one
+// SignalOnHUP. A new worker that exits during startup leaves the old one
+// serving. It returns the generation of the new worker.
+func (s *supervisor) restart(spec workerSpec) (int, error) {
+	w, err := s.spawn(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	prev := s.current
+	s.current = w
+	if prev != nil {
+		s.old[prev] = struct{}{}
+	}
+	s.writeStatus()
+	s.mu.Unlock()
+
+	if prev != nil {
+		s.signal(prev, s.cfg.SignalOnHUP(), syscall.SIGTERM)
+	}
+	return w.gen, nil
+}
+
+// spawn starts a worker and waits the configured interval for it to stay up.
+func (s *supervisor) spawn(spec workerSpec) (*worker, error) {
+	s.mu.Lock()
+	s.generation++
+	gen := s.generation
+	files := s.files
+	ports := strings.Join(s.ports, ";")
+	s.mu.Unlock()
+
+	cmd := exec.Command(s.cfg.Command(), s.cfg.Args()...)
+	cmd.Dir = s.cfg.Dir()
+	cmd.Stdout, cmd.Stderr = spec.Stdout, spec.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(), spec.Env...)
+	cmd.Env = append(cmd.Env,
+		"SERVER_STARTER_PORT="+ports,
+		"SERVER_STARTER_GENERATION="+strconv.Itoa(gen))
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start worker: %w", err)
+	}
+	w := &worker{gen: gen, cmd: cmd, done: make(chan struct{})}
+	s.logger.Info("Started server worker", slog.Int("pid", cmd.Process.Pid), slog.Int("generation", gen))
+	go s.wait(w)
+
+	interval := s.cfg.Interval()
+	if interval <= 0 {
+		interval = defaultWorkerStartupWait
+	}
+	select {
+	case <-w.done:
+		return nil, fmt.Errorf("worker exited during startup: %w", w.err)
+	case <-time.After(interval):
+		return w, nil
+	}
+}
+
+// wait reaps w and reports it when it was the current worker.
+func (s *supervisor) wait(w *worker) {
+	w.err = w.cmd.Wait()
+	if w.err == nil {
+		// The server is not supposed to stop by itself, so a clean exit
+		// is reported as one too.
+		w.err = &exec.ExitError{ProcessState: w.cmd.ProcessState}
+	}
+
+	s.mu.Lock()
+	delete(s.old, w)
+	wasCurrent := s.current == w
+	if wasCurrent {
+		s.current = nil
+	}
+	s.writeStatus()
+	s.mu.Unlock()
+	close(w.done)
+
+	if wasCurrent {
+		s.logger.Warn("Server worker exited",
+			slog.Int("pid", w.cmd.Process.Pid),
+			slog.Int("generation", w.gen),
+			slog.String("status", w.err.Error()))
+		if s.onExit != nil {
+			s.onExit(w.gen, w.err)
+		}
+	}
+}
+
+// drained waits until every replaced worker has exited.
+func (s *supervisor) drained(ctx context.Context) error {
+	s.mu.Lock()
+	var pending []*worker
+	for w := range s.old {
+		pending = append(pending, w)
+	}
+	s.mu.Unlock()
+
+	for _, w := range pending {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return fmt.Errorf("old worker (pid %d) still running: %w", w.cmd.Process.Pid, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// running reports whether gen is the current worker and has not exited.
+func (s *supervisor) running(gen int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current != nil && s.current.gen == gen
+}
+
+// currentGeneration returns the generation of the current worker, or 0.
+func (s *supervisor) currentGeneration() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return 0
+	}
+	return s.current.gen
+}
+
+// stop sends every worker SignalOnTERM, waits up to timeout for them to
+// exit, and releases the listeners and files.
+func (s *supervisor) stop(timeout time.Duration) {
+	s.mu.Lock()
+	workers := make([]*worker, 0, len(s.old)+1)
+	for w := range s.old {
+		workers = append(workers, w)
+	}
+	if s.current != nil {
+		workers = append(workers, s.current)
+	}
+	// Exits from here on are expected, not crashes.
+	s.current = nil
+	for _, w := range workers {
+		s.old[w] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	for _, w := range workers {
+		s.signal(w, s.cfg.SignalOnTERM(), syscall.SIGTERM)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := s.drained(ctx); err != nil {
+		s.logger.Warn("Server workers did not stop", slog.String("error", err.Error()))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, l := range s.listeners {
+		l.Close()
+		s.files[i].Close()
+	}
+	s.listeners, s.files, s.ports = nil, nil, nil
+	s.bound = false
+	if p := s.cfg.StatusFile(); p != "" {
+		_ = os.Remove(p)
+	}
+	if s.pidFile != "" {
+		_ = os.Remove(s.pidFile)
+		s.pidFile = ""
+	}
+}
+
+func (s *supervisor) signal(w *worker, sig, fallback os.Signal) {
+	if sig == nil {
+		sig = fallback
+	}
+	if err := w.cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		s.logger.Warn("Signal server worker failure",
+			slog.Int("pid", w.cmd.Process.Pid),
+			slog.String("error", err.Error()))
+	}
+}
+
+// writeStatus writes "<generation>:<pid>" lines of the live workers to the
+// status file, as server-starter does. The caller must hold s.mu.
+func (s *supervisor) writeStatus() {
+	p := s.cfg.StatusFile()
+	if p == "" || !s.bound {
+		return // stopped: a worker reaped late must not recreate it
+	}
+	var b strings.Builder
+	for w := range s.old {
+		fmt.Fprintf(&b, "%d:%d\n", w.gen, w.cmd.Process.Pid)
+	}
+	if s.current != nil {
+		fmt.Fprintf(&b, "%d:%d\n", s.current.gen, s.current.cmd.Process.Pid)
+	}
+	if err := os.WriteFile(p, []byte(b.String()), 0644); err != nil {
+		s.logger.Warn("Write status file failure", slog.String("error", err.Error()))
+	}
+}
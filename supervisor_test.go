@@ -0,0 +1,168 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopWorker runs until SIGTERM, the default signal for replaced workers.
+const loopWorker = `trap 'exit 0' TERM; while :; do sleep 0.1; done`
+
+type exitRecorder struct {
+	mu    sync.Mutex
+	exits []error
+	ch    chan struct{}
+}
+
+func newExitRecorder() *exitRecorder {
+	return &exitRecorder{ch: make(chan struct{}, 10)}
+}
+
+func (r *exitRecorder) onExit(_ int, err error) {
+	r.mu.Lock()
+	r.exits = append(r.exits, err)
+	r.mu.Unlock()
+	r.ch <- struct{}{}
+}
+
+func (r *exitRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.exits)
+}
+
+func newTestSupervisor(t *testing.T, script string) (*supervisor, *exitRecorder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := StarterConfig{
+		command:    "sh",
+		args:       []string{"-c", script},
+		dir:        dir,
+		ports:      []string{"127.0.0.1:0"},
+		statusfile: filepath.Join(dir, "status"),
+	}
+	s, err := newSupervisor(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := newExitRecorder()
+	s.onExit = rec.onExit
+	// Workers that ignored the stop signal would outlive the test.
+	t.Cleanup(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for w := range s.old {
+			_ = w.cmd.Process.Kill()
+		}
+		if s.current != nil {
+			_ = s.current.cmd.Process.Kill()
+		}
+	})
+	return s, rec, dir
+}
+
+func TestSupervisor_ReportsExit(t *testing.T) {
+	s, rec, dir := newTestSupervisor(t, `echo "$SERVER_STARTER_PORT $SERVER_STARTER_GENERATION $APP_ENV" > out; sleep 2; exit 3`)
+	defer s.stop(time.Second)
+
+	if err := s.start(workerSpec{Env: []string{"APP_ENV=production"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !s.running(1) {
+		t.Error("generation 1 is not running after start")
+	}
+
+	select {
+	case <-rec.ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker exit was not reported")
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(rec.exits[0], &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("exit = %v, want exit status 3", rec.exits[0])
+	}
+	if got := exitCode(rec.exits[0]); got != 3 {
+		t.Errorf("exitCode = %d, want 3", got)
+	}
+	if s.running(1) {
+		t.Error("generation 1 still running after it exited")
+	}
+
+	out, err := os.ReadFile(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(out)); len(got) != 3 || !strings.HasPrefix(got[0], "127.0.0.1:0=3") || got[1] != "1" || got[2] != "production" {
+		t.Errorf("worker env = %q", out)
+	}
+	if _, ok := os.LookupEnv("APP_ENV"); ok {
+		t.Error("worker env leaked into dewy's environment")
+	}
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	s, rec, dir := newTestSupervisor(t, loopWorker)
+
+	if err := s.start(workerSpec{}); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := s.restart(workerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gen != 2 {
+		t.Errorf("generation = %d, want 2", gen)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.drained(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !s.running(2) || s.running(1) {
+		t.Errorf("current generation = %d, want 2", s.currentGeneration())
+	}
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Fields(string(status)); len(lines) != 1 || !strings.HasPrefix(lines[0], "2:") {
+		t.Errorf("status file = %q, want the new worker only", status)
+	}
+
+	s.stop(5 * time.Second)
+	if s.currentGeneration() != 0 {
+		t.Error("worker still current after stop")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "status")); !os.IsNotExist(err) {
+		t.Errorf("status file not removed on stop: %v", err)
+	}
+	if n := rec.count(); n != 0 {
+		t.Errorf("replaced and stopped workers reported as %d exits", n)
+	}
+}
+
+func TestSupervisor_StartupExit(t *testing.T) {
+	s, rec, _ := newTestSupervisor(t, `exit 7`)
+	defer s.stop(time.Second)
+
+	err := s.start(workerSpec{})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+		t.Fatalf("err = %v, want exit status 7", err)
+	}
+	if s.currentGeneration() != 0 {
+		t.Error("worker that exited during startup became current")
+	}
+	if n := rec.count(); n != 0 {
+		t.Errorf("startup exit reported through onExit %d times", n)
+	}
+}
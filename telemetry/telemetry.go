@@ -164,12 +164,12 @@ type Metrics struct {
 	HealthChecksTotal   otelmetric.Int64Counter
 	HealthCheckFailures otelmetric.Int64Counter
 
-	// Server (process supervision) metric. Recorded in server mode when dewy
-	// restarts the managed process — an event only dewy can see. Crash
-	// detection is intentionally not here: the server-starter supervisor
-	// absorbs worker crashes (auto-respawn) and exposes no hook, so counting
-	// them meaningfully needs a supervisor-side event API (a follow-up).
+	// Server (process supervision) metrics. Recorded in server mode when dewy
+	// restarts the managed process, by cause, and when a worker exits on its
+	// own: dewy's supervisor reports every such exit, with its exit code,
+	// before dewy starts the worker again.
 	ServerRestarts otelmetric.Int64Counter
+	ServerCrashes  otelmetric.Int64Counter
 
 	// Container metrics are reported asynchronously via a registered observer
 	// (see container.go); the instruments live in this struct so they share the
@@ -270,12 +270,19 @@ func newMetrics(meter otelmetric.Meter) (*Metrics, error) {
 	}
 
 	if m.ServerRestarts, err = meter.Int64Counter("dewy.server.restarts.total",
-		otelmetric.WithDescription("Total number of managed-server restarts, keyed by reason (deploy|signal)"),
+		otelmetric.WithDescription("Total number of managed-server restarts, keyed by reason (deploy|signal|api|rollback|crash)"),
 		otelmetric.WithUnit("{restart}"),
 	); err != nil {
 		return nil, err
 	}
 
+	if m.ServerCrashes, err = meter.Int64Counter("dewy.server.crashes.total",
+		otelmetric.WithDescription("Total number of managed-server crashes, keyed by exit code"),
+		otelmetric.WithUnit("{crash}"),
+	); err != nil {
+		return nil, err
+	}
+
 	// Container metrics (asynchronous observable gauges).
 	if err = m.container.init(meter); err != nil {
 		return nil, err
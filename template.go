@@ -0,0 +1,147 @@
+package dewy
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+)
+
+// templateSuffix is stripped from rendered file names so "app.yml.tmpl"
+// lands in the release directory as "app.yml". Files without the suffix are
+// rendered under their own name.
+const templateSuffix = ".tmpl"
+
+// templateRelease is the release metadata exposed to templates as .Release.
+type templateRelease struct {
+	ID          string
+	Tag         string
+	ArtifactURL string
+	CreatedAt   *time.Time
+	Slot        string
+	Dir         string
+	Command     string
+	DeployedAt  time.Time
+}
+
+// templateData is the root object passed to every configuration template.
+type templateData struct {
+	Tag     string
+	Release templateRelease
+	Env     map[string]string
+}
+
+// newTemplateData builds the template data for a release being deployed into
+// dir. Env is snapshotted from the process environment at render time.
+func (d *Dewy) newTemplateData(res *registry.CurrentResponse, dir string) templateData {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return templateData{
+		Tag: res.Tag,
+		Release: templateRelease{
+			ID:          res.ID,
+			Tag:         res.Tag,
+			ArtifactURL: res.ArtifactURL,
+			CreatedAt:   res.CreatedAt,
+			Slot:        res.Slot,
+			Dir:         dir,
+			Command:     d.config.Command.String(),
+			DeployedAt:  time.Now().UTC(),
+		},
+		Env: env,
+	}
+}
+
+// templateFuncs returns the helper functions available to templates. Relative
+// paths given to file are resolved against base (the dewy root), so operators
+// can keep secrets and per-host values next to the releases directory.
+func templateFuncs(base string) template.FuncMap {
+	return template.FuncMap{
+		"env": os.Getenv,
+		"file": func(p string) (string, error) {
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(base, p)
+			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(b), "\n"), nil
+		},
+		"default": func(def, v string) string {
+			if v == "" {
+				return def
+			}
+			return v
+		},
+	}
+}
+
+// renderTemplates renders every file under d.config.TemplateDir into dst,
+// keeping the relative layout and file mode. A missing key or a failed file
+// lookup aborts rendering so the caller can discard the release before the
+// current symlink is touched.
+func (d *Dewy) renderTemplates(res *registry.CurrentResponse, dst string) error {
+	src := d.config.TemplateDir
+	if src == "" {
+		return nil
+	}
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(d.root, src)
+	}
+
+	data := d.newTemplateData(res, dst)
+	funcs := templateFuncs(d.root)
+
+	return filepath.WalkDir(src, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(rel).Funcs(funcs).Option("missingkey=error").Parse(string(b))
+		if err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", rel, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, data); err != nil {
+			return fmt.Errorf("failed to render template %s: %w", rel, err)
+		}
+
+		out := filepath.Join(dst, strings.TrimSuffix(rel, templateSuffix))
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, buf.Bytes(), info.Mode().Perm()); err != nil {
+			return err
+		}
+		d.logger.Debug("Rendered template", slog.String("template", rel), slog.String("path", out))
+		return nil
+	})
+}
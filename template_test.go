@@ -0,0 +1,140 @@
+package dewy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linyows/dewy/notifier"
+	"github.com/linyows/dewy/registry"
+)
+
+func writeTemplate(t *testing.T, dir, name, body string) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(body), 0640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	tmplDir := t.TempDir()
+	d.config.TemplateDir = tmplDir
+	t.Setenv("DEWY_TEST_DB_HOST", "db.internal")
+
+	if err := os.WriteFile(filepath.Join(d.root, "secret"), []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate(t, tmplDir, "config/app.yml.tmpl",
+		"tag: {{ .Tag }}\nslot: {{ .Release.Slot }}\ndb: {{ .Env.DEWY_TEST_DB_HOST }}\nsecret: {{ file \"secret\" }}\nport: {{ env \"DEWY_TEST_PORT\" | default \"8080\" }}\n")
+	writeTemplate(t, tmplDir, "plain.txt", "{{ .Release.Command }}")
+
+	dst := t.TempDir()
+	res := &registry.CurrentResponse{ID: "id", Tag: "v1.2.3+blue", Slot: "blue"}
+	if err := d.renderTemplates(res, dst); err != nil {
+		t.Fatalf("renderTemplates: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dst, "config", "app.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "tag: v1.2.3+blue\nslot: blue\ndb: db.internal\nsecret: s3cr3t\nport: 8080\n"
+	if string(got) != want {
+		t.Errorf("app.yml = %q, want %q", got, want)
+	}
+
+	fi, err := os.Stat(filepath.Join(dst, "config", "app.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", fi.Mode().Perm())
+	}
+
+	got, err = os.ReadFile(filepath.Join(dst, "plain.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "assets" {
+		t.Errorf("plain.txt = %q, want %q", got, "assets")
+	}
+}
+
+func TestRenderTemplates_NoDir(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	if err := d.renderTemplates(&registry.CurrentResponse{Tag: "v1"}, t.TempDir()); err != nil {
+		t.Errorf("expected no-op without TemplateDir, got %v", err)
+	}
+}
+
+func TestRenderTemplates_MissingKey(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	tmplDir := t.TempDir()
+	d.config.TemplateDir = tmplDir
+	writeTemplate(t, tmplDir, "app.conf.tmpl", "{{ .Env.DEWY_TEST_UNDEFINED_VARIABLE }}")
+
+	err := d.renderTemplates(&registry.CurrentResponse{Tag: "v1"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "app.conf.tmpl") {
+		t.Errorf("expected render error naming the template, got %v", err)
+	}
+}
+
+// A template failure must fail the deploy before the current symlink moves,
+// so the previously deployed release keeps serving.
+func TestRun_TemplateFailureKeepsCurrent(t *testing.T) {
+	artifact := "ghr://linyows/dewy/tag/v1.2.3/artifact.zip"
+	d := newPhaseTestDewy(t)
+	d.registry = &mockRegistry{url: artifact, tag: "v1.0.0"}
+	d.artifact = &mockArtifact{binary: "dewy", url: artifact}
+	var err error
+	d.notifier, err = notifier.New(context.Background(), "", testLogger().Logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Run(); err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	before, err := os.Readlink(filepath.Join(d.root, symlinkDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmplDir := t.TempDir()
+	d.config.TemplateDir = tmplDir
+	writeTemplate(t, tmplDir, "broken.tmpl", "{{ .Nope }}")
+	var report *registry.ReportRequest
+	d.registry = &mockRegistry{url: artifact, tag: "v2.0.0", reportFunc: func(_ context.Context, req *registry.ReportRequest) error {
+		report = req
+		return nil
+	}}
+	d.artifact = &mockArtifact{binary: "dewy", url: artifact}
+
+	if err := d.Run(); err == nil {
+		t.Fatal("expected Run to fail on broken template")
+	}
+	if report == nil || report.Phase != phaseRender || report.Err == nil {
+		t.Errorf("report = %+v, want a failed render", report)
+	}
+	after, err := os.Readlink(filepath.Join(d.root, symlinkDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Errorf("current moved to %s, want it to stay at %s", after, before)
+	}
+	entries, err := os.ReadDir(filepath.Join(d.root, releasesDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("releases = %d, want the failed release removed", len(entries))
+	}
+}
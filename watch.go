@@ -0,0 +1,66 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/linyows/dewy/registry"
+)
+
+// watchRegistry runs a deploy tick for every release the registry pushes,
+// so a new release goes out without waiting for the next poll or for the
+// registry result cache to expire. Polling
+// keeps running as a safety net. A dropped stream is reopened with
+// exponential backoff; a registry server without the Watch RPC leaves dewy
+// polling only.
+func (d *Dewy) watchRegistry(ctx context.Context, w registry.Watcher) {
+	backoff := defaultWatchBackoff
+	for {
+		received := false
+		err := w.Watch(ctx, func(res *registry.CurrentResponse) {
+			received = true
+			d.logger.Debug("Registry pushed release", slog.String("tag", res.Tag))
+			d.storePushed(res)
+			d.tick(false)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, registry.ErrWatchUnsupported) {
+			d.logger.Info("Registry does not support watch, falling back to polling")
+			return
+		}
+		if received {
+			backoff = defaultWatchBackoff
+		}
+
+		attrs := []any{slog.Duration("retry_in", backoff)}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		d.logger.Warn("Registry watch interrupted", attrs...)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, defaultWatchBackoffMax)
+	}
+}
+
+// storePushed puts a pushed release into the registry result cache, if one
+// wraps the registry, so that the tick it triggers deploys it rather than a
+// result cached before the push. On failure the tick falls back to the
+// cache as is and the next push or the TTL catches up.
+func (d *Dewy) storePushed(res *registry.CurrentResponse) {
+	c, ok := d.registry.(*registry.Cached)
+	if !ok {
+		return
+	}
+	if err := c.Store(res); err != nil {
+		d.logger.Warn("Failed to store pushed release in registry cache", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,153 @@
+package dewy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linyows/dewy/cache"
+	"github.com/linyows/dewy/registry"
+)
+
+// scriptedWatcher plays one step per Watch call: the tags it pushes, then
+// the error the stream ends with.
+type scriptedWatcher struct {
+	steps []watchStep
+	calls int
+}
+
+type watchStep struct {
+	tags []string
+	err  error
+}
+
+func (w *scriptedWatcher) Watch(_ context.Context, fn func(*registry.CurrentResponse)) error {
+	if w.calls >= len(w.steps) {
+		return registry.ErrWatchUnsupported
+	}
+	step := w.steps[w.calls]
+	w.calls++
+	for _, tag := range step.tags {
+		fn(&registry.CurrentResponse{Tag: tag})
+	}
+	return step.err
+}
+
+func TestWatchRegistry(t *testing.T) {
+	d, _, _ := newHookRunDewy(t)
+	w := &scriptedWatcher{steps: []watchStep{
+		{err: errors.New("connection reset")},
+		{tags: []string{"v2.0.0"}, err: registry.ErrWatchUnsupported},
+	}}
+
+	start := time.Now()
+	d.watchRegistry(context.Background(), w)
+
+	if w.calls != 2 {
+		t.Errorf("watch opened %d times, want 2", w.calls)
+	}
+	if elapsed := time.Since(start); elapsed < defaultWatchBackoff {
+		t.Errorf("reconnected after %s, want at least %s", elapsed, defaultWatchBackoff)
+	}
+	if d.cVer != "v2.0.0" {
+		t.Errorf("current version = %q, want the pushed v2.0.0 deployed", d.cVer)
+	}
+}
+
+func TestWatchRegistry_StopsWithContext(t *testing.T) {
+	d := newPhaseTestDewy(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &scriptedWatcher{steps: []watchStep{{err: errors.New("unavailable")}}}
+
+	done := make(chan struct{})
+	go func() {
+		d.watchRegistry(ctx, w)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchRegistry did not return after cancel")
+	}
+}
+
+// versionedCache adds the conditional writes of cache.AtomicCache to a
+// cache, kept in memory, so that a registry-ttl result cache can sit on it.
+type versionedCache struct {
+	cache.Cache
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string][]byte
+	version map[string]int
+}
+
+func (c *versionedCache) RegistryTTL() time.Duration { return c.ttl }
+
+func (c *versionedCache) ReadWithVersion(key string) ([]byte, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	if !ok {
+		return nil, "", cache.ErrNotFound
+	}
+	return data, strconv.Itoa(c.version[key]), nil
+}
+
+func (c *versionedCache) WriteIfMatch(key, version string, data []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current := ""
+	if _, ok := c.entries[key]; ok {
+		current = strconv.Itoa(c.version[key])
+	}
+	if version != current {
+		return "", cache.ErrConflict
+	}
+	if c.entries == nil {
+		c.entries, c.version = make(map[string][]byte), make(map[string]int)
+	}
+	c.entries[key] = data
+	c.version[key]++
+	return strconv.Itoa(c.version[key]), nil
+}
+
+type watchFunc func(context.Context, func(*registry.CurrentResponse)) error
+
+func (f watchFunc) Watch(ctx context.Context, fn func(*registry.CurrentResponse)) error {
+	return f(ctx, fn)
+}
+
+func TestWatchRegistry_RegistryTTL(t *testing.T) {
+	d, _, _ := newHookRunDewy(t)
+	artifact := "ghr://linyows/dewy/tag/v1.2.3/artifact.zip"
+	tag := "v1.0.0"
+	d.registry = &mockRegistry{currentFunc: func(context.Context) (*registry.CurrentResponse, error) {
+		return &registry.CurrentResponse{ID: "id", Tag: tag, ArtifactURL: artifact}, nil
+	}}
+	d.cache = &versionedCache{Cache: d.cache, ttl: time.Hour}
+	d.cacheRegistry()
+	if _, ok := d.registry.(*registry.Cached); !ok {
+		t.Fatalf("registry = %T, want the result cache with registry-ttl set", d.registry)
+	}
+
+	// The upstream moves on, but the cached result is fresh for an hour.
+	d.tick(false)
+	tag = "v2.0.0"
+	d.tick(false)
+	if d.cVer != "v1.0.0" {
+		t.Fatalf("current version = %q, want v1.0.0 served from the cache", d.cVer)
+	}
+
+	w := watchFunc(func(_ context.Context, fn func(*registry.CurrentResponse)) error {
+		fn(&registry.CurrentResponse{ID: "id", Tag: "v2.0.0", ArtifactURL: artifact})
+		return registry.ErrWatchUnsupported
+	})
+	d.watchRegistry(context.Background(), w)
+	if d.cVer != "v2.0.0" {
+		t.Errorf("current version = %q, want the pushed v2.0.0 deployed within the TTL", d.cVer)
+	}
+}
@@ -0,0 +1,281 @@
+package dewy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/linyows/dewy/notifier"
+)
+
+// Webhook declares an HTTP request sent at a hook stage, as an alternative to
+// wrapping curl in a shell hook. Webhooks are loaded from the JSON file given
+// with --webhooks, which holds an array of these objects.
+//
+// URL and header values are expanded with ${VAR} from dewy's environment so
+// tokens need not be written into the file. Body is a Go template rendered
+// with the deploy context (see webhookData).
+type Webhook struct {
+	Stage        string            `json:"stage"`                   // Hook stage, e.g. "after-deploy"
+	Method       string            `json:"method,omitempty"`        // HTTP method (default: POST)
+	URL          string            `json:"url"`                     // http(s) endpoint
+	Headers      map[string]string `json:"headers,omitempty"`       // Request headers
+	Body         string            `json:"body,omitempty"`          // Body template; sent as application/json unless Content-Type is set
+	ExpectStatus []int             `json:"expect_status,omitempty"` // Accepted status codes (default: any 2xx)
+	Retries      int               `json:"retries,omitempty"`       // Additional attempts after a failure
+}
+
+// webhookData is the root object passed to a webhook body template.
+type webhookData struct {
+	Stage              string
+	Command            string
+	Hostname           string
+	Tag                string
+	PreviousTag        string
+	ReleaseDir         string
+	PreviousReleaseDir string
+	ArtifactURL        string
+	Slot               string
+	Replicas           int
+	Error              string
+}
+
+// LoadWebhooks reads and validates a webhook declaration file.
+func LoadWebhooks(p string) ([]Webhook, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Webhook
+	if err := json.Unmarshal(b, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks %s: %w", p, err)
+	}
+	for i, w := range hooks {
+		if err := w.validate(); err != nil {
+			return nil, fmt.Errorf("webhook #%d in %s: %w", i+1, p, err)
+		}
+	}
+	return hooks, nil
+}
+
+func (w Webhook) validate() error {
+	if !validHookStage(w.Stage) {
+		return fmt.Errorf("unknown hook stage: %q", w.Stage)
+	}
+	u, err := url.Parse(os.ExpandEnv(w.URL))
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url must be http or https: %q", w.URL)
+	}
+	if _, err := w.bodyTemplate(""); err != nil {
+		return err
+	}
+	if w.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	return nil
+}
+
+func (w Webhook) method() string {
+	if w.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(w.Method)
+}
+
+// bodyTemplate parses Body with the configuration template helpers plus
+// json, which encodes a value as a JSON literal. It returns nil for a request
+// without a body. base resolves relative paths given to file.
+func (w Webhook) bodyTemplate(base string) (*template.Template, error) {
+	if w.Body == "" {
+		return nil, nil
+	}
+	funcs := templateFuncs(base)
+	funcs["json"] = func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	tmpl, err := template.New("body").Funcs(funcs).Option("missingkey=error").Parse(w.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse body template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// accepts reports whether status counts as success.
+func (w Webhook) accepts(status int) bool {
+	if len(w.ExpectStatus) == 0 {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(w.ExpectStatus, status)
+}
+
+// webhooksFor returns the configured webhooks for stage, in file order.
+func (d *Dewy) webhooksFor(s hookStage) []Webhook {
+	var hooks []Webhook
+	for _, w := range d.config.Webhooks {
+		if w.Stage == string(s) {
+			hooks = append(hooks, w)
+		}
+	}
+	return hooks
+}
+
+// execWebhook sends w and returns a HookResult describing it, so webhooks
+// show up in notifications the same way shell hooks do: Command is the
+// method and unexpanded URL, ExitCode is the final HTTP status (1 when no
+// response was received), Stdout the response body and Stderr the last
+// error. Failed attempts are retried with exponential back-off, all within
+// the hook timeout.
+func (d *Dewy) execWebhook(ctx context.Context, w Webhook, s hookStage, hc hookContext) (*notifier.HookResult, error) {
+	start := time.Now()
+	result := &notifier.HookResult{
+		Command:  w.method() + " " + w.URL,
+		ExitCode: 1,
+	}
+
+	body, err := d.webhookBody(w, s, hc)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Stderr = err.Error()
+		return result, err
+	}
+
+	timeout := d.hookTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := defaultWebhookRetryDelay
+	for attempt := 0; ; attempt++ {
+		var status int
+		status, result.Stdout, err = d.sendWebhook(ctx, w, body)
+		if status != 0 {
+			result.ExitCode = status
+		}
+		if err == nil && !w.accepts(status) {
+			err = fmt.Errorf("unexpected status %d", status)
+		}
+		if err == nil || attempt >= w.Retries {
+			break
+		}
+		d.logger.Warn("Webhook attempt failed",
+			slog.String("url", w.URL),
+			slog.Int("attempt", attempt+1),
+			slog.String("error", err.Error()))
+		if sleepCtx(ctx, delay) != nil {
+			break
+		}
+		delay *= 2
+	}
+
+	if err != nil && ctx.Err() != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("webhook timed out after %s: %w", timeout, err)
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = fmt.Errorf("webhook canceled: %w", err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Stderr = err.Error()
+		d.logger.Info("Execute webhook failed",
+			slog.String("command", result.Command),
+			slog.Int("status", result.ExitCode),
+			slog.Duration("duration", result.Duration),
+			slog.String("error", err.Error()))
+		return result, err
+	}
+
+	result.Success = true
+	d.logger.Info("Execute webhook",
+		slog.String("command", result.Command),
+		slog.Int("status", result.ExitCode),
+		slog.Duration("duration", result.Duration))
+	return result, nil
+}
+
+// webhookBody renders the body template of w for the deploy in hc.
+func (d *Dewy) webhookBody(w Webhook, s hookStage, hc hookContext) ([]byte, error) {
+	tmpl, err := w.bodyTemplate(d.root)
+	if err != nil || tmpl == nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	data := webhookData{
+		Stage:              string(s),
+		Command:            d.config.Command.String(),
+		Hostname:           hostname,
+		Tag:                hc.Tag,
+		PreviousTag:        hc.PreviousTag,
+		ReleaseDir:         hc.ReleaseDir,
+		PreviousReleaseDir: hc.PreviousReleaseDir,
+		ArtifactURL:        hc.ArtifactURL,
+		Slot:               hc.Slot,
+		Replicas:           hc.Replicas,
+		Error:              hc.Error,
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render webhook body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sendWebhook performs a single attempt and returns the status code and the
+// (truncated) response body.
+func (d *Dewy) sendWebhook(ctx context.Context, w Webhook, body []byte) (int, string, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, w.method(), os.ExpandEnv(w.URL), r)
+	if err != nil {
+		return 0, "", err
+	}
+	for k, v := range w.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "dewy")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, webhookResponseLimit))
+	if err != nil {
+		return res.StatusCode, "", err
+	}
+	return res.StatusCode, strings.TrimSpace(string(b)), nil
+}
+
+// sleepCtx waits d, returning early with ctx's error when it is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,196 @@
+package dewy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "webhooks.json")
+	if err := os.WriteFile(p, []byte(`[
+  {"stage": "after-deploy", "url": "https://tracker.example.com/deploys", "body": "{\"tag\": {{json .Tag}}}", "retries": 2}
+]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := LoadWebhooks(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].Stage != "after-deploy" || hooks[0].method() != http.MethodPost {
+		t.Errorf("hooks = %+v", hooks)
+	}
+}
+
+func TestLoadWebhooks_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"unknown stage", `[{"stage": "post-deploy", "url": "https://example.com"}]`},
+		{"bad scheme", `[{"stage": "after-deploy", "url": "ftp://example.com"}]`},
+		{"bad template", `[{"stage": "after-deploy", "url": "https://example.com", "body": "{{.Tag"}]`},
+		{"negative retries", `[{"stage": "after-deploy", "url": "https://example.com", "retries": -1}]`},
+		{"not json", `stage: after-deploy`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "webhooks.json")
+			if err := os.WriteFile(p, []byte(tt.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadWebhooks(p); err == nil {
+				t.Errorf("expected error for %s", tt.in)
+			}
+		})
+	}
+}
+
+func TestExecWebhook(t *testing.T) {
+	var got struct {
+		method string
+		auth   string
+		ctype  string
+		body   map[string]string
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.auth = r.Header.Get("Authorization")
+		got.ctype = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &got.body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("recorded\n"))
+	}))
+	defer ts.Close()
+
+	t.Setenv("DEWY_TEST_TRACKER_TOKEN", "s3cret")
+	d := newPhaseTestDewy(t)
+	w := Webhook{
+		Stage:   "after-deploy",
+		URL:     ts.URL + "/deploys",
+		Headers: map[string]string{"Authorization": "Bearer ${DEWY_TEST_TRACKER_TOKEN}"},
+		Body:    `{"tag": {{json .Tag}}, "previous": {{json .PreviousTag}}, "stage": {{json .Stage}}, "command": {{json .Command}}}`,
+	}
+	hc := hookContext{Tag: `v1.2.0"x`, PreviousTag: "v1.1.0"}
+
+	res, err := d.execWebhook(context.Background(), w, hookAfterDeploy, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.ExitCode != http.StatusCreated || res.Stdout != "recorded" {
+		t.Errorf("result = %+v", res)
+	}
+	if res.Command != "POST "+ts.URL+"/deploys" {
+		t.Errorf("command = %q", res.Command)
+	}
+	if got.method != http.MethodPost || got.auth != "Bearer s3cret" || got.ctype != "application/json" {
+		t.Errorf("request = %+v", got)
+	}
+	want := map[string]string{"tag": `v1.2.0"x`, "previous": "v1.1.0", "stage": "after-deploy", "command": "assets"}
+	for k, v := range want {
+		if got.body[k] != v {
+			t.Errorf("body[%s] = %q, want %q", k, got.body[k], v)
+		}
+	}
+}
+
+func TestExecWebhook_Retries(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	d := newPhaseTestDewy(t)
+	w := Webhook{Stage: "after-deploy", URL: ts.URL, Retries: 2}
+	res, err := d.execWebhook(context.Background(), w, hookAfterDeploy, hookContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 || res.ExitCode != http.StatusOK {
+		t.Errorf("calls = %d, result = %+v", calls.Load(), res)
+	}
+}
+
+func TestExecWebhook_UnexpectedStatus(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	d := newPhaseTestDewy(t)
+	w := Webhook{Stage: "after-deploy", Method: "put", URL: ts.URL, ExpectStatus: []int{http.StatusNoContent}}
+	res, err := d.execWebhook(context.Background(), w, hookAfterDeploy, hookContext{})
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 200") {
+		t.Errorf("err = %v, want unexpected status", err)
+	}
+	if res.Success || res.ExitCode != http.StatusOK || !strings.HasPrefix(res.Command, "PUT ") {
+		t.Errorf("result = %+v", res)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no retries", calls.Load())
+	}
+}
+
+func TestExecWebhook_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	d := newPhaseTestDewy(t)
+	d.config.HookTimeout = 300 * time.Millisecond
+	w := Webhook{Stage: "after-deploy", URL: ts.URL, Retries: 10}
+
+	start := time.Now()
+	_, err := d.execWebhook(context.Background(), w, hookAfterDeploy, hookContext{})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("webhook ran for %s, want it bounded by the hook timeout", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want timeout error", err)
+	}
+}
+
+func TestRunHook_BlockingWebhookAbortsDeploy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	d, notify, prev := newHookRunDewy(t)
+	d.config.Webhooks = []Webhook{{Stage: "before-deploy", URL: ts.URL}}
+	d.config.BlockingHooks = []string{"before-deploy"}
+
+	if err := d.Run(); err == nil {
+		t.Fatal("expected blocking webhook to fail the deploy")
+	}
+	if got := d.currentRelease(); got != prev {
+		t.Errorf("current = %s, want %s kept", got, prev)
+	}
+	var notified bool
+	for _, m := range notify.GetMessages() {
+		if strings.Contains(m, "Before Deploy Hook") && strings.Contains(m, ts.URL) {
+			notified = true
+		}
+	}
+	if !notified {
+		t.Errorf("webhook result not notified: %v", notify.GetMessages())
+	}
+}